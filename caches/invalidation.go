@@ -0,0 +1,195 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// enumerate all the invalidation operations, they are the same as the methods of Cacher
+const (
+	InvalidateDelIds     = "DelIds"
+	InvalidateDelBean    = "DelBean"
+	InvalidateClearIds   = "ClearIds"
+	InvalidateClearBeans = "ClearBeans"
+)
+
+// Invalidation represents an eviction which should be done by all the instances
+type Invalidation struct {
+	// Source is the instance which published the invalidation
+	Source    string `json:"source"`
+	Op        string `json:"op"`
+	TableName string `json:"table"`
+	// Key is the sql of DelIds or the id of DelBean
+	Key string `json:"key,omitempty"`
+}
+
+// Invalidator broadcasts the invalidations to all the instances
+type Invalidator interface {
+	Publish(inv *Invalidation) error
+	// Subscribe registers a handler which is called on all the published invalidations,
+	// including the ones published by this instance
+	Subscribe(handler func(*Invalidation))
+}
+
+// InvalidationCacher wraps a process local cacher, i.e. LRUCacher, and publishes what it
+// deletes and clears to the other instances by an Invalidator, so that a write on one
+// Engine evicts the cached ids and beans of the table on all the Engines.
+type InvalidationCacher struct {
+	Cacher
+	invalidator Invalidator
+	source      string
+	// OnError is called when an invalidation cannot be published, it could be nil
+	OnError func(inv *Invalidation, err error)
+}
+
+var _ StatsCacher = &InvalidationCacher{}
+
+// NewInvalidationCacher creates an InvalidationCacher and subscribes the invalidations
+// published by the other instances
+func NewInvalidationCacher(cacher Cacher, invalidator Invalidator) *InvalidationCacher {
+	var buf [8]byte
+	rand.Read(buf[:])
+	c := &InvalidationCacher{
+		Cacher:      cacher,
+		invalidator: invalidator,
+		source:      hex.EncodeToString(buf[:]),
+	}
+	invalidator.Subscribe(c.invalidate)
+	return c
+}
+
+func (c *InvalidationCacher) publish(op, tableName, key string) {
+	inv := &Invalidation{
+		Source:    c.source,
+		Op:        op,
+		TableName: tableName,
+		Key:       key,
+	}
+	if err := c.invalidator.Publish(inv); err != nil && c.OnError != nil {
+		c.OnError(inv, err)
+	}
+}
+
+// invalidate evicts the local cache according the invalidation of another instance
+func (c *InvalidationCacher) invalidate(inv *Invalidation) {
+	if inv.Source == c.source {
+		return
+	}
+	switch inv.Op {
+	case InvalidateDelIds:
+		c.Cacher.DelIds(inv.TableName, inv.Key)
+	case InvalidateDelBean:
+		c.Cacher.DelBean(inv.TableName, inv.Key)
+	case InvalidateClearIds:
+		c.Cacher.ClearIds(inv.TableName)
+	case InvalidateClearBeans:
+		c.Cacher.ClearBeans(inv.TableName)
+	}
+}
+
+// Stats returns the stats of the wrapped cacher, or nil if it doesn't implement StatsCacher
+func (c *InvalidationCacher) Stats() map[string]*TableCacheStats {
+	if sc, ok := c.Cacher.(StatsCacher); ok {
+		return sc.Stats()
+	}
+	return nil
+}
+
+// DelIds deletes ids on all the instances
+func (c *InvalidationCacher) DelIds(tableName, sql string) {
+	c.Cacher.DelIds(tableName, sql)
+	c.publish(InvalidateDelIds, tableName, sql)
+}
+
+// DelBean deletes the bean on all the instances
+func (c *InvalidationCacher) DelBean(tableName string, id string) {
+	c.Cacher.DelBean(tableName, id)
+	c.publish(InvalidateDelBean, tableName, id)
+}
+
+// ClearIds clears all sql-ids mapping on table tableName on all the instances
+func (c *InvalidationCacher) ClearIds(tableName string) {
+	c.Cacher.ClearIds(tableName)
+	c.publish(InvalidateClearIds, tableName, "")
+}
+
+// ClearBeans clears all beans in some table on all the instances
+func (c *InvalidationCacher) ClearBeans(tableName string) {
+	c.Cacher.ClearBeans(tableName)
+	c.publish(InvalidateClearBeans, tableName, "")
+}
+
+// RedisInvalidator implements Invalidator by the pub/sub of a server speaking the redis protocol
+type RedisInvalidator struct {
+	client     *redisClient
+	subscriber *redisSubscriber
+
+	handlers []func(*Invalidation)
+	mutex    sync.RWMutex
+}
+
+var _ Invalidator = &RedisInvalidator{}
+
+// NewRedisInvalidator subscribes the channel of opts and waits until the subscription
+// is done, it reconnects if the connection is broken later
+func NewRedisInvalidator(opts *RedisOptions) (*RedisInvalidator, error) {
+	inv := &RedisInvalidator{client: newRedisClient(opts)}
+	inv.subscriber = &redisSubscriber{
+		opts:    inv.client.opts,
+		handler: inv.receive,
+		ready:   make(chan struct{}),
+	}
+	go inv.subscriber.run()
+
+	select {
+	case <-inv.subscriber.ready:
+		return inv, nil
+	case <-time.After(inv.client.opts.Timeout):
+		inv.Close()
+		return nil, errors.New("xorm/cache: redis: subscribe " + inv.client.opts.Channel + " timeout")
+	}
+}
+
+func (r *RedisInvalidator) receive(payload []byte) {
+	var inv Invalidation
+	if err := json.Unmarshal(payload, &inv); err != nil {
+		return
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	for _, handler := range r.handlers {
+		handler(&inv)
+	}
+}
+
+// Publish publishes the invalidation to all the instances
+func (r *RedisInvalidator) Publish(inv *Invalidation) error {
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.do("PUBLISH", r.client.opts.Channel, payload)
+	return err
+}
+
+// Subscribe registers a handler of the invalidations
+func (r *RedisInvalidator) Subscribe(handler func(*Invalidation)) {
+	r.mutex.Lock()
+	r.handlers = append(r.handlers, handler)
+	r.mutex.Unlock()
+}
+
+// Close stops the subscription and closes the connections
+func (r *RedisInvalidator) Close() {
+	r.subscriber.close()
+	r.client.close()
+}
@@ -22,8 +22,15 @@ type LRUCacher struct {
 	MaxElementSize int
 	Expired        time.Duration
 	GcInterval     time.Duration
+	// OnEvict is called when an entry is evicted or expired, it's called with the
+	// cacher locked so it should not call the cacher
+	OnEvict func(*EvictEvent)
+
+	stats map[string]*TableCacheStats
 }
 
+var _ StatsCacher = &LRUCacher{}
+
 // NewLRUCacher creates a cacher
 func NewLRUCacher(store CacheStore, maxElementSize int) *LRUCacher {
 	return NewLRUCacher2(store, 3600*time.Second, maxElementSize)
@@ -36,6 +43,7 @@ func NewLRUCacher2(store CacheStore, expired time.Duration, maxElementSize int)
 		GcInterval: CacheGcInterval, MaxElementSize: maxElementSize,
 		sqlIndex: make(map[string]map[string]*list.Element),
 		idIndex:  make(map[string]map[string]*list.Element),
+		stats:    make(map[string]*TableCacheStats),
 	}
 	cacher.RunGC()
 	return cacher
@@ -61,6 +69,7 @@ func (m *LRUCacher) GC() {
 			next := e.Next()
 			node := e.Value.(*idNode)
 			m.delBean(node.tbName, node.id)
+			m.evicted(node.tbName, node.id, true, true)
 			e = next
 		} else {
 			break
@@ -75,6 +84,7 @@ func (m *LRUCacher) GC() {
 			next := e.Next()
 			node := e.Value.(*sqlNode)
 			m.delIds(node.tbName, node.sql)
+			m.evicted(node.tbName, node.sql, false, true)
 			e = next
 		} else {
 			break
@@ -98,15 +108,19 @@ func (m *LRUCacher) GetIds(tableName, sql string) interface{} {
 			// if expired, remove the node and return nil
 			if time.Now().Sub(lastTime) > m.Expired {
 				m.delIds(tableName, sql)
+				m.evicted(tableName, sql, false, true)
+				m.tableStats(tableName).Ids.Misses++
 				return nil
 			}
 			m.sqlList.MoveToBack(el)
 			el.Value.(*sqlNode).lastVisit = time.Now()
 		}
+		m.tableStats(tableName).Ids.Hits++
 		return v
 	}
 
 	m.delIds(tableName, sql)
+	m.tableStats(tableName).Ids.Misses++
 	return nil
 }
 
@@ -124,6 +138,8 @@ func (m *LRUCacher) GetBean(tableName string, id string) interface{} {
 			// if expired, remove the node and return nil
 			if time.Now().Sub(lastTime) > m.Expired {
 				m.delBean(tableName, id)
+				m.evicted(tableName, id, true, true)
+				m.tableStats(tableName).Beans.Misses++
 				return nil
 			}
 			m.idList.MoveToBack(el)
@@ -132,11 +148,13 @@ func (m *LRUCacher) GetBean(tableName string, id string) interface{} {
 			el = m.idList.PushBack(newIDNode(tableName, id))
 			m.idIndex[tableName][id] = el
 		}
+		m.tableStats(tableName).Beans.Hits++
 		return v
 	}
 
 	// store bean is not exist, then remove memory's index
 	m.delBean(tableName, id)
+	m.tableStats(tableName).Beans.Misses++
 	return nil
 }
 
@@ -189,10 +207,12 @@ func (m *LRUCacher) PutIds(tableName, sql string, ids interface{}) {
 		el.Value.(*sqlNode).lastVisit = time.Now()
 	}
 	m.store.Put(sql, ids)
+	m.tableStats(tableName).Ids.Puts++
 	if m.sqlList.Len() > m.MaxElementSize {
 		e := m.sqlList.Front()
 		node := e.Value.(*sqlNode)
 		m.delIds(node.tbName, node.sql)
+		m.evicted(node.tbName, node.sql, false, false)
 	}
 	m.mutex.Unlock()
 }
@@ -211,10 +231,12 @@ func (m *LRUCacher) PutBean(tableName string, id string, obj interface{}) {
 	}
 
 	m.store.Put(genID(tableName, id), obj)
+	m.tableStats(tableName).Beans.Puts++
 	if m.idList.Len() > m.MaxElementSize {
 		e := m.idList.Front()
 		node := e.Value.(*idNode)
 		m.delBean(node.tbName, node.id)
+		m.evicted(node.tbName, node.id, true, false)
 	}
 	m.mutex.Unlock()
 }
@@ -253,6 +275,51 @@ func (m *LRUCacher) DelBean(tableName string, id string) {
 	m.mutex.Unlock()
 }
 
+func (m *LRUCacher) tableStats(tableName string) *TableCacheStats {
+	stats, ok := m.stats[tableName]
+	if !ok {
+		stats = &TableCacheStats{}
+		m.stats[tableName] = stats
+	}
+	return stats
+}
+
+// evicted counts an entry removed by the cacher itself and calls OnEvict
+func (m *LRUCacher) evicted(tableName, key string, isBean, expired bool) {
+	stats := &m.tableStats(tableName).Ids
+	if isBean {
+		stats = &m.tableStats(tableName).Beans
+	}
+	if expired {
+		stats.Expired++
+	} else {
+		stats.Evictions++
+	}
+	if m.OnEvict != nil {
+		m.OnEvict(&EvictEvent{
+			TableName: tableName,
+			Key:       key,
+			IsBean:    isBean,
+			Expired:   expired,
+		})
+	}
+}
+
+// Stats returns the stats of all the tables, Entries is the number of the entries in cache now
+func (m *LRUCacher) Stats() map[string]*TableCacheStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var res = make(map[string]*TableCacheStats, len(m.stats))
+	for tableName, stats := range m.stats {
+		s := *stats
+		s.Ids.Entries = int64(len(m.sqlIndex[tableName]))
+		s.Beans.Entries = int64(len(m.idIndex[tableName]))
+		res[tableName] = &s
+	}
+	return res
+}
+
 type idNode struct {
 	tbName    string
 	id        string
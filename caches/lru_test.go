@@ -5,6 +5,7 @@
 package caches
 
 import (
+	"expvar"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -50,3 +51,48 @@ func TestLRUCache(t *testing.T) {
 		assert.Nil(t, obj4)
 	}
 }
+
+func TestLRUCacherStats(t *testing.T) {
+	type CacheObject1 struct {
+		Id int64
+	}
+
+	var evicted []*EvictEvent
+	cacher := NewLRUCacher(NewMemoryStore(), 2)
+	cacher.OnEvict = func(ev *EvictEvent) {
+		evicted = append(evicted, ev)
+	}
+
+	tableName := "cache_object1"
+	assert.Nil(t, cacher.GetBean(tableName, "1"))
+	for _, id := range []string{"1", "2", "3"} {
+		cacher.PutBean(tableName, id, &CacheObject1{})
+	}
+	assert.NotNil(t, cacher.GetBean(tableName, "3"))
+	assert.Nil(t, cacher.GetIds(tableName, "select * from cache_object1"))
+	cacher.PutIds(tableName, "select * from cache_object1", "1")
+	assert.NotNil(t, cacher.GetIds(tableName, "select * from cache_object1"))
+
+	stats := cacher.Stats()[tableName]
+	assert.EqualValues(t, CacheStats{Hits: 1, Misses: 1, Puts: 3, Evictions: 1, Entries: 2}, stats.Beans)
+	assert.EqualValues(t, CacheStats{Hits: 1, Misses: 1, Puts: 1, Entries: 1}, stats.Ids)
+	assert.EqualValues(t, 0.5, stats.Ids.HitRatio())
+	assert.EqualValues(t, []*EvictEvent{{TableName: tableName, Key: "1", IsBean: true}}, evicted)
+
+	cacher.Expired = 0
+	cacher.GC()
+	stats = cacher.Stats()[tableName]
+	assert.EqualValues(t, 2, stats.Beans.Expired)
+	assert.EqualValues(t, 0, stats.Beans.Entries)
+	// the ids are cleared with the expired beans
+	assert.EqualValues(t, 0, stats.Ids.Entries)
+	assert.Len(t, evicted, 3)
+
+	mgr := NewManager()
+	mgr.SetDefaultCacher(cacher)
+	mgr.SetCacher(tableName, cacher)
+	assert.EqualValues(t, cacher.Stats(), mgr.Stats())
+
+	mgr.PublishExpvar("xorm_lru_cacher_stats")
+	assert.Contains(t, expvar.Get("xorm_lru_cacher_stats").String(), `"cache_object1":{"ids":{"hits":1`)
+}
@@ -12,11 +12,14 @@ type Manager struct {
 
 	cachers    map[string]Cacher
 	cacherLock sync.RWMutex
+
+	queryCacher QueryCacher
 }
 
 func NewManager() *Manager {
 	return &Manager{
-		cachers: make(map[string]Cacher),
+		cachers:     make(map[string]Cacher),
+		queryCacher: NewMemoryQueryCacher(DefaultQueryCacheSize),
 	}
 }
 
@@ -54,3 +57,18 @@ func (mgr *Manager) SetDefaultCacher(cacher Cacher) {
 func (mgr *Manager) GetDefaultCacher() Cacher {
 	return mgr.cacher
 }
+
+// SetQueryCacher set the cacher of the raw query results, the default one is a
+// MemoryQueryCacher with DefaultQueryCacheSize entries
+func (mgr *Manager) SetQueryCacher(cacher QueryCacher) {
+	mgr.cacherLock.Lock()
+	mgr.queryCacher = cacher
+	mgr.cacherLock.Unlock()
+}
+
+// GetQueryCacher returns the cacher of the raw query results
+func (mgr *Manager) GetQueryCacher() QueryCacher {
+	mgr.cacherLock.RLock()
+	defer mgr.cacherLock.RUnlock()
+	return mgr.queryCacher
+}
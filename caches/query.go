@@ -0,0 +1,134 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DefaultQueryCacheSize is the max entries of the query cacher created by NewManager
+const DefaultQueryCacheSize = 1000
+
+// QueryCacher caches the results of the raw queries, every entry is associated with
+// the tables it reads so that it could be invalidated when one of them is written.
+type QueryCacher interface {
+	// Get returns the cached value of key, the second return value is false if the key
+	// is not cached or expired
+	Get(key string) (interface{}, bool)
+	// Put caches the value of key for ttl, a zero ttl means the value never expires
+	Put(key string, tables []string, value interface{}, ttl time.Duration)
+	// ClearTables removes all the entries associated with any of the tables
+	ClearTables(tables ...string)
+}
+
+type queryNode struct {
+	key       string
+	tables    []string
+	value     interface{}
+	expiredAt time.Time
+}
+
+// MemoryQueryCacher is a process local QueryCacher which removes the least recently
+// used entries when it's full
+type MemoryQueryCacher struct {
+	list           *list.List
+	index          map[string]*list.Element
+	tableIndex     map[string]map[string]bool
+	mutex          sync.Mutex
+	MaxElementSize int
+}
+
+var _ QueryCacher = &MemoryQueryCacher{}
+
+// NewMemoryQueryCacher creates a MemoryQueryCacher holding at most maxElementSize entries
+func NewMemoryQueryCacher(maxElementSize int) *MemoryQueryCacher {
+	return &MemoryQueryCacher{
+		list:           list.New(),
+		index:          make(map[string]*list.Element),
+		tableIndex:     make(map[string]map[string]bool),
+		MaxElementSize: maxElementSize,
+	}
+}
+
+// Get returns the cached value of key
+func (m *MemoryQueryCacher) Get(key string) (interface{}, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	el, ok := m.index[key]
+	if !ok {
+		return nil, false
+	}
+	node := el.Value.(*queryNode)
+	if !node.expiredAt.IsZero() && time.Now().After(node.expiredAt) {
+		m.remove(el)
+		return nil, false
+	}
+	m.list.MoveToBack(el)
+	return node.value, true
+}
+
+// Put caches the value of key
+func (m *MemoryQueryCacher) Put(key string, tables []string, value interface{}, ttl time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if el, ok := m.index[key]; ok {
+		m.remove(el)
+	}
+
+	node := &queryNode{key: key, tables: tables, value: value}
+	if ttl > 0 {
+		node.expiredAt = time.Now().Add(ttl)
+	}
+	m.index[key] = m.list.PushBack(node)
+	for _, table := range tables {
+		if _, ok := m.tableIndex[table]; !ok {
+			m.tableIndex[table] = make(map[string]bool)
+		}
+		m.tableIndex[table][key] = true
+	}
+
+	for m.MaxElementSize > 0 && m.list.Len() > m.MaxElementSize {
+		m.remove(m.list.Front())
+	}
+}
+
+// ClearTables removes all the entries associated with any of the tables
+func (m *MemoryQueryCacher) ClearTables(tables ...string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, table := range tables {
+		for key := range m.tableIndex[table] {
+			if el, ok := m.index[key]; ok {
+				m.remove(el)
+			}
+		}
+		delete(m.tableIndex, table)
+	}
+}
+
+// Len returns the number of the cached entries, including the expired ones not removed yet
+func (m *MemoryQueryCacher) Len() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.list.Len()
+}
+
+func (m *MemoryQueryCacher) remove(el *list.Element) {
+	node := m.list.Remove(el).(*queryNode)
+	delete(m.index, node.key)
+	for _, table := range node.tables {
+		if keys, ok := m.tableIndex[table]; ok {
+			delete(keys, node.key)
+			if len(keys) == 0 {
+				delete(m.tableIndex, table)
+			}
+		}
+	}
+}
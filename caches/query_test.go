@@ -0,0 +1,45 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryQueryCacher(t *testing.T) {
+	cacher := NewMemoryQueryCacher(2)
+
+	cacher.Put("a", []string{"user"}, 1, 0)
+	cacher.Put("b", []string{"user", "article"}, 2, 0)
+	v, ok := cacher.Get("a")
+	assert.True(t, ok)
+	assert.EqualValues(t, 1, v)
+
+	// b is the least recently used one
+	cacher.Put("c", []string{"article"}, 3, 0)
+	assert.EqualValues(t, 2, cacher.Len())
+	_, ok = cacher.Get("b")
+	assert.False(t, ok)
+
+	cacher.ClearTables("article")
+	_, ok = cacher.Get("c")
+	assert.False(t, ok)
+	_, ok = cacher.Get("a")
+	assert.True(t, ok)
+
+	cacher.ClearTables("user")
+	assert.EqualValues(t, 0, cacher.Len())
+
+	cacher.Put("d", nil, 4, 10*time.Millisecond)
+	_, ok = cacher.Get("d")
+	assert.True(t, ok)
+	time.Sleep(20 * time.Millisecond)
+	_, ok = cacher.Get("d")
+	assert.False(t, ok)
+	assert.EqualValues(t, 0, cacher.Len())
+}
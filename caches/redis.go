@@ -0,0 +1,552 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RedisOptions represents the options to connect a server speaking the redis protocol
+type RedisOptions struct {
+	// Addr is host:port of the server, default is 127.0.0.1:6379
+	Addr     string
+	Password string
+	DB       int
+	// Prefix is prepended to all the keys and the channel, default is xorm:
+	Prefix string
+	// Expired is the ttl of the cached items, default is CacheExpired
+	Expired time.Duration
+	// Timeout is the dial, read and write timeout, default is 5 seconds
+	Timeout time.Duration
+	// MaxIdleConns is the max number of idle connections, default is 10
+	MaxIdleConns int
+	// Channel is the pub/sub channel of the invalidations, default is Prefix + "invalidation"
+	Channel string
+}
+
+func (opts *RedisOptions) withDefaults() RedisOptions {
+	var res RedisOptions
+	if opts != nil {
+		res = *opts
+	}
+	if res.Addr == "" {
+		res.Addr = "127.0.0.1:6379"
+	}
+	if res.Prefix == "" {
+		res.Prefix = "xorm:"
+	}
+	if res.Expired <= 0 {
+		res.Expired = CacheExpired
+	}
+	if res.Timeout <= 0 {
+		res.Timeout = 5 * time.Second
+	}
+	if res.MaxIdleConns <= 0 {
+		res.MaxIdleConns = 10
+	}
+	if res.Channel == "" {
+		res.Channel = res.Prefix + "invalidation"
+	}
+	return res
+}
+
+// redisError is an error reply of the server, the connection is still usable after it
+type redisError string
+
+func (err redisError) Error() string {
+	return "xorm/cache: redis: " + string(err)
+}
+
+// redisConn is a connection speaking RESP, the redis serialization protocol
+type redisConn struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	w       *bufio.Writer
+	timeout time.Duration
+}
+
+func dialRedis(opts *RedisOptions) (*redisConn, error) {
+	conn, err := net.DialTimeout("tcp", opts.Addr, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	c := &redisConn{
+		conn:    conn,
+		r:       bufio.NewReader(conn),
+		w:       bufio.NewWriter(conn),
+		timeout: opts.Timeout,
+	}
+	if opts.Password != "" {
+		if _, err := c.do("AUTH", opts.Password); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if opts.DB != 0 {
+		if _, err := c.do("SELECT", opts.DB); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *redisConn) Close() error {
+	return c.conn.Close()
+}
+
+// do sends a command and reads its reply
+func (c *redisConn) do(args ...interface{}) (interface{}, error) {
+	if err := c.send(args...); err != nil {
+		return nil, err
+	}
+	c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+	return c.receive()
+}
+
+func (c *redisConn) send(args ...interface{}) error {
+	c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	fmt.Fprintf(c.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		var bs []byte
+		switch v := arg.(type) {
+		case []byte:
+			bs = v
+		case string:
+			bs = []byte(v)
+		case int:
+			bs = strconv.AppendInt(nil, int64(v), 10)
+		case int64:
+			bs = strconv.AppendInt(nil, v, 10)
+		default:
+			bs = []byte(fmt.Sprint(v))
+		}
+		fmt.Fprintf(c.w, "$%d\r\n", len(bs))
+		c.w.Write(bs)
+		c.w.WriteString("\r\n")
+	}
+	return c.w.Flush()
+}
+
+// receive reads a reply which is a string, an int64, a []byte, a []interface{},
+// nil or a redisError
+func (c *redisConn) receive() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("xorm/cache: redis: malformed reply")
+	}
+	tp, line := line[0], line[1:len(line)-2]
+
+	switch tp {
+	case '+':
+		return line, nil
+	case '-':
+		return nil, redisError(line)
+	case ':':
+		return strconv.ParseInt(line, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		res := make([]interface{}, n)
+		for i := range res {
+			if res[i], err = c.receive(); err != nil {
+				if _, ok := err.(redisError); !ok {
+					return nil, err
+				}
+			}
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("xorm/cache: redis: unknown reply type %c", tp)
+}
+
+// redisClient is a pool of connections
+type redisClient struct {
+	opts RedisOptions
+	idle chan *redisConn
+}
+
+func newRedisClient(opts *RedisOptions) *redisClient {
+	o := opts.withDefaults()
+	return &redisClient{
+		opts: o,
+		idle: make(chan *redisConn, o.MaxIdleConns),
+	}
+}
+
+func (c *redisClient) do(args ...interface{}) (interface{}, error) {
+	var conn *redisConn
+	select {
+	case conn = <-c.idle:
+	default:
+		var err error
+		if conn, err = dialRedis(&c.opts); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := conn.do(args...)
+	if err != nil {
+		if _, ok := err.(redisError); !ok {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	select {
+	case c.idle <- conn:
+	default:
+		conn.Close()
+	}
+	return reply, err
+}
+
+func (c *redisClient) close() {
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.Close()
+		default:
+			return
+		}
+	}
+}
+
+func (c *redisClient) ttl() int64 {
+	return c.opts.Expired.Milliseconds()
+}
+
+// RedisStore implements CacheStore on a server speaking the redis protocol, the values
+// are encoded by gob, so the types of the cached beans have to be registered by gob.Register
+type RedisStore struct {
+	client *redisClient
+	Debug  bool
+}
+
+var _ CacheStore = &RedisStore{}
+
+// NewRedisStore creates a redis store, the connections are created when used
+func NewRedisStore(opts *RedisOptions) *RedisStore {
+	return &RedisStore{client: newRedisClient(opts)}
+}
+
+func (s *RedisStore) key(key string) string {
+	return s.client.opts.Prefix + key
+}
+
+// Put puts object into store
+func (s *RedisStore) Put(key string, value interface{}) error {
+	val, err := Encode(value)
+	if err != nil {
+		if s.Debug {
+			log.Println("[Redis]EncodeErr: ", err, "Key:", key)
+		}
+		return err
+	}
+	if _, err = s.client.do("SET", s.key(key), val, "PX", s.client.ttl()); err != nil {
+		if s.Debug {
+			log.Println("[Redis]PutErr: ", err, "Key:", key)
+		}
+		return err
+	}
+	return nil
+}
+
+// Get gets object from store
+func (s *RedisStore) Get(key string) (interface{}, error) {
+	reply, err := s.client.do("GET", s.key(key))
+	if err != nil {
+		if s.Debug {
+			log.Println("[Redis]GetErr: ", err, "Key:", key)
+		}
+		return nil, err
+	}
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, ErrNotExist
+	}
+
+	var v interface{}
+	if err = Decode(data, &v); err != nil {
+		if s.Debug {
+			log.Println("[Redis]DecodeErr: ", err, "Key:", key)
+		}
+		return nil, err
+	}
+	return v, nil
+}
+
+// Del deletes object
+func (s *RedisStore) Del(key string) error {
+	_, err := s.client.do("DEL", s.key(key))
+	if err != nil && s.Debug {
+		log.Println("[Redis]DelErr: ", err, "Key:", key)
+	}
+	return err
+}
+
+// Close closes the idle connections
+func (s *RedisStore) Close() {
+	s.client.close()
+}
+
+// RedisCacher implements Cacher on a server speaking the redis protocol, so all the
+// instances share the same cache and what one clears is cleared for all. The keys of
+// a table are indexed by a redis set so that they can be cleared together.
+// Like RedisStore, the types of the cached beans have to be registered by gob.Register.
+type RedisCacher struct {
+	store *RedisStore
+	Debug bool
+	stats sync.Map
+}
+
+var _ StatsCacher = &RedisCacher{}
+
+type redisTableStats struct {
+	ids, beans atomicCacheStats
+}
+
+func (c *RedisCacher) tableStats(tableName string) *redisTableStats {
+	stats, _ := c.stats.LoadOrStore(tableName, &redisTableStats{})
+	return stats.(*redisTableStats)
+}
+
+// Stats returns the hits, misses and puts of this instance, the others are not
+// counted since the cache is shared and expired by the server
+func (c *RedisCacher) Stats() map[string]*TableCacheStats {
+	var res = make(map[string]*TableCacheStats)
+	c.stats.Range(func(key, value interface{}) bool {
+		stats := value.(*redisTableStats)
+		res[key.(string)] = &TableCacheStats{
+			Ids:   stats.ids.load(),
+			Beans: stats.beans.load(),
+		}
+		return true
+	})
+	return res
+}
+
+// NewRedisCacher creates a redis cacher, the connections are created when used
+func NewRedisCacher(opts *RedisOptions) *RedisCacher {
+	return &RedisCacher{store: NewRedisStore(opts)}
+}
+
+func (c *RedisCacher) idsSet(tableName string) string {
+	return c.store.key("ids:" + tableName)
+}
+
+func (c *RedisCacher) idsKey(tableName, sql string) string {
+	return "ids:" + tableName + ":" + Md5(sql)
+}
+
+func (c *RedisCacher) beansSet(tableName string) string {
+	return c.store.key("beans:" + tableName)
+}
+
+func (c *RedisCacher) beanKey(tableName, id string) string {
+	return "bean:" + genID(tableName, id)
+}
+
+func (c *RedisCacher) logErr(op string, err error, args ...interface{}) {
+	if err != nil && c.Debug {
+		log.Println(append([]interface{}{"[Redis]" + op + "Err: ", err}, args...)...)
+	}
+}
+
+func (c *RedisCacher) get(key string) interface{} {
+	v, err := c.store.Get(key)
+	if err != nil {
+		if err != ErrNotExist {
+			c.logErr("Get", err, "Key:", key)
+		}
+		return nil
+	}
+	return v
+}
+
+func (c *RedisCacher) put(set, key string, value interface{}) {
+	if err := c.store.Put(key, value); err != nil {
+		c.logErr("Put", err, "Key:", key)
+		return
+	}
+	_, err := c.store.client.do("SADD", set, key)
+	c.logErr("SAdd", err, "Key:", set)
+	_, err = c.store.client.do("PEXPIRE", set, c.store.client.ttl())
+	c.logErr("PExpire", err, "Key:", set)
+}
+
+func (c *RedisCacher) del(set, key string) {
+	c.logErr("Del", c.store.Del(key), "Key:", key)
+	_, err := c.store.client.do("SREM", set, key)
+	c.logErr("SRem", err, "Key:", set)
+}
+
+func (c *RedisCacher) clear(set string) {
+	reply, err := c.store.client.do("SMEMBERS", set)
+	if err != nil {
+		c.logErr("SMembers", err, "Key:", set)
+		return
+	}
+	members, _ := reply.([]interface{})
+	args := make([]interface{}, 0, len(members)+2)
+	args = append(args, "DEL", set)
+	for _, member := range members {
+		if key, ok := member.([]byte); ok {
+			args = append(args, c.store.key(string(key)))
+		}
+	}
+	_, err = c.store.client.do(args...)
+	c.logErr("Del", err, "Key:", set)
+}
+
+// GetIds returns all bean's ids according to sql and parameter from cache
+func (c *RedisCacher) GetIds(tableName, sql string) interface{} {
+	v := c.get(c.idsKey(tableName, sql))
+	c.tableStats(tableName).ids.hit(v != nil)
+	return v
+}
+
+// GetBean returns bean according tableName and id from cache
+func (c *RedisCacher) GetBean(tableName string, id string) interface{} {
+	v := c.get(c.beanKey(tableName, id))
+	c.tableStats(tableName).beans.hit(v != nil)
+	return v
+}
+
+// PutIds puts ids into table
+func (c *RedisCacher) PutIds(tableName, sql string, ids interface{}) {
+	c.put(c.idsSet(tableName), c.idsKey(tableName, sql), ids)
+	c.tableStats(tableName).ids.put()
+}
+
+// PutBean puts beans into table
+func (c *RedisCacher) PutBean(tableName string, id string, obj interface{}) {
+	c.put(c.beansSet(tableName), c.beanKey(tableName, id), obj)
+	c.tableStats(tableName).beans.put()
+}
+
+// DelIds deletes ids
+func (c *RedisCacher) DelIds(tableName, sql string) {
+	c.del(c.idsSet(tableName), c.idsKey(tableName, sql))
+}
+
+// DelBean deletes beans in some table, and like LRUCacher the ids of the table are cleared
+func (c *RedisCacher) DelBean(tableName string, id string) {
+	c.del(c.beansSet(tableName), c.beanKey(tableName, id))
+	c.ClearIds(tableName)
+}
+
+// ClearIds clears all sql-ids mapping on table tableName from cache
+func (c *RedisCacher) ClearIds(tableName string) {
+	c.clear(c.idsSet(tableName))
+}
+
+// ClearBeans clears all beans in some table
+func (c *RedisCacher) ClearBeans(tableName string) {
+	c.clear(c.beansSet(tableName))
+}
+
+// Close closes the idle connections
+func (c *RedisCacher) Close() {
+	c.store.Close()
+}
+
+// redisSubscriber receives the messages of a channel and reconnects when the
+// connection is broken until it's closed
+type redisSubscriber struct {
+	opts    RedisOptions
+	handler func([]byte)
+	ready   chan struct{}
+
+	mutex  sync.Mutex
+	once   sync.Once
+	conn   *redisConn
+	closed bool
+}
+
+func (s *redisSubscriber) run() {
+	for {
+		conn, err := dialRedis(&s.opts)
+		if err == nil {
+			err = conn.send("SUBSCRIBE", s.opts.Channel)
+		}
+
+		s.mutex.Lock()
+		if s.closed {
+			s.mutex.Unlock()
+			if conn != nil {
+				conn.Close()
+			}
+			return
+		}
+		s.conn = conn
+		s.mutex.Unlock()
+
+		if err == nil {
+			s.receive(conn)
+		}
+		if conn != nil {
+			conn.Close()
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func (s *redisSubscriber) receive(conn *redisConn) {
+	for {
+		// subscribed connections wait for the messages without timeout
+		conn.conn.SetReadDeadline(time.Time{})
+		reply, err := conn.receive()
+		if err != nil {
+			return
+		}
+		msg, ok := reply.([]interface{})
+		if !ok || len(msg) != 3 {
+			continue
+		}
+		switch kind, _ := msg[0].([]byte); string(kind) {
+		case "subscribe":
+			s.once.Do(func() {
+				close(s.ready)
+			})
+		case "message":
+			if payload, ok := msg[2].([]byte); ok {
+				s.handler(payload)
+			}
+		}
+	}
+}
+
+func (s *redisSubscriber) close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.closed = true
+	if s.conn != nil {
+		s.conn.Close()
+	}
+}
@@ -0,0 +1,280 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedis is an in-process server which implements the commands used by the redis cache
+type fakeRedis struct {
+	listener net.Listener
+
+	mutex       sync.Mutex
+	values      map[string][]byte
+	sets        map[string]map[string]bool
+	subscribers map[string][]*fakeRedisConn
+}
+
+type fakeRedisConn struct {
+	mutex sync.Mutex
+	w     *bufio.Writer
+}
+
+func (c *fakeRedisConn) reply(format string, args ...interface{}) {
+	c.mutex.Lock()
+	fmt.Fprintf(c.w, format, args...)
+	c.w.Flush()
+	c.mutex.Unlock()
+}
+
+func (c *fakeRedisConn) replyBulks(bulks ...string) {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "*%d\r\n", len(bulks))
+	for _, bulk := range bulks {
+		fmt.Fprintf(&buf, "$%d\r\n%s\r\n", len(bulk), bulk)
+	}
+	c.reply("%s", buf.String())
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	s := &fakeRedis{
+		listener:    listener,
+		values:      make(map[string][]byte),
+		sets:        make(map[string]map[string]bool),
+		subscribers: make(map[string][]*fakeRedisConn),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeRedis) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeRedis) Close() {
+	s.listener.Close()
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	c := &fakeRedisConn{w: bufio.NewWriter(conn)}
+	for {
+		args, err := readFakeCommand(r)
+		if err != nil {
+			return
+		}
+		s.exec(c, args)
+	}
+}
+
+func readFakeCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err = r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (s *fakeRedis) exec(c *fakeRedisConn, args []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "PING", "AUTH", "SELECT", "PEXPIRE":
+		c.reply("+OK\r\n")
+	case "SET":
+		s.values[args[1]] = []byte(args[2])
+		c.reply("+OK\r\n")
+	case "GET":
+		if v, ok := s.values[args[1]]; ok {
+			c.reply("$%d\r\n%s\r\n", len(v), v)
+		} else {
+			c.reply("$-1\r\n")
+		}
+	case "DEL":
+		var n int
+		for _, key := range args[1:] {
+			if _, ok := s.values[key]; ok {
+				n++
+			}
+			if _, ok := s.sets[key]; ok {
+				n++
+			}
+			delete(s.values, key)
+			delete(s.sets, key)
+		}
+		c.reply(":%d\r\n", n)
+	case "SADD":
+		if s.sets[args[1]] == nil {
+			s.sets[args[1]] = make(map[string]bool)
+		}
+		for _, member := range args[2:] {
+			s.sets[args[1]][member] = true
+		}
+		c.reply(":%d\r\n", len(args)-2)
+	case "SREM":
+		for _, member := range args[2:] {
+			delete(s.sets[args[1]], member)
+		}
+		c.reply(":%d\r\n", len(args)-2)
+	case "SMEMBERS":
+		var members []string
+		for member := range s.sets[args[1]] {
+			members = append(members, member)
+		}
+		c.replyBulks(members...)
+	case "PUBLISH":
+		subscribers := s.subscribers[args[1]]
+		for _, sub := range subscribers {
+			sub.replyBulks("message", args[1], args[2])
+		}
+		c.reply(":%d\r\n", len(subscribers))
+	case "SUBSCRIBE":
+		s.subscribers[args[1]] = append(s.subscribers[args[1]], c)
+		c.reply("*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(args[1]), args[1])
+	default:
+		c.reply("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+type RedisCacheObject struct {
+	Id   int64
+	Name string
+}
+
+func init() {
+	gob.Register(&RedisCacheObject{})
+}
+
+func TestRedisStore(t *testing.T) {
+	server := newFakeRedis(t)
+	defer server.Close()
+
+	store := NewRedisStore(&RedisOptions{Addr: server.Addr()})
+	defer store.Close()
+
+	assert.NoError(t, store.Put("a", "b"))
+	val, err := store.Get("a")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "b", val)
+
+	assert.NoError(t, store.Put("obj", &RedisCacheObject{1, "lunny"}))
+	val, err = store.Get("obj")
+	assert.NoError(t, err)
+	assert.EqualValues(t, &RedisCacheObject{1, "lunny"}, val)
+
+	assert.NoError(t, store.Del("a"))
+	_, err = store.Get("a")
+	assert.EqualValues(t, ErrNotExist, err)
+}
+
+func TestRedisCacher(t *testing.T) {
+	server := newFakeRedis(t)
+	defer server.Close()
+
+	cacher := NewRedisCacher(&RedisOptions{Addr: server.Addr()})
+	defer cacher.Close()
+	cacher2 := NewRedisCacher(&RedisOptions{Addr: server.Addr()})
+	defer cacher2.Close()
+
+	tableName := "redis_cache_object"
+	cacher.PutIds(tableName, "select * from redis_cache_object", "1")
+	assert.EqualValues(t, "1", cacher2.GetIds(tableName, "select * from redis_cache_object"))
+
+	var obj = &RedisCacheObject{1, "lunny"}
+	cacher.PutBean(tableName, "1", obj)
+	assert.EqualValues(t, obj, cacher2.GetBean(tableName, "1"))
+
+	cacher2.ClearIds(tableName)
+	assert.Nil(t, cacher.GetIds(tableName, "select * from redis_cache_object"))
+	assert.EqualValues(t, obj, cacher.GetBean(tableName, "1"))
+
+	cacher2.ClearBeans(tableName)
+	assert.Nil(t, cacher.GetBean(tableName, "1"))
+
+	cacher.PutBean(tableName, "1", obj)
+	cacher2.DelBean(tableName, "1")
+	assert.Nil(t, cacher.GetBean(tableName, "1"))
+}
+
+func TestInvalidationCacher(t *testing.T) {
+	server := newFakeRedis(t)
+	defer server.Close()
+
+	newCacher := func() (*InvalidationCacher, *RedisInvalidator) {
+		inv, err := NewRedisInvalidator(&RedisOptions{Addr: server.Addr()})
+		assert.NoError(t, err)
+		return NewInvalidationCacher(NewLRUCacher(NewMemoryStore(), 1000), inv), inv
+	}
+	cacher1, inv1 := newCacher()
+	defer inv1.Close()
+	cacher2, inv2 := newCacher()
+	defer inv2.Close()
+
+	tableName := "redis_cache_object"
+	var obj = &RedisCacheObject{1, "lunny"}
+	for _, cacher := range []Cacher{cacher1, cacher2} {
+		cacher.PutIds(tableName, "select * from redis_cache_object", "1")
+		// LRUCacher creates the index of the table on GetBean
+		assert.Nil(t, cacher.GetBean(tableName, "1"))
+		cacher.PutBean(tableName, "1", obj)
+	}
+
+	cacher1.ClearIds(tableName)
+	assert.Nil(t, cacher1.GetIds(tableName, "select * from redis_cache_object"))
+	assert.Eventually(t, func() bool {
+		return cacher2.GetIds(tableName, "select * from redis_cache_object") == nil
+	}, time.Second, 10*time.Millisecond)
+	assert.NotNil(t, cacher2.GetBean(tableName, "1"))
+
+	cacher2.ClearBeans(tableName)
+	assert.Eventually(t, func() bool {
+		return cacher1.GetBean(tableName, "1") == nil
+	}, time.Second, 10*time.Millisecond)
+}
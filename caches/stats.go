@@ -0,0 +1,132 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package caches
+
+import (
+	"expvar"
+	"sync/atomic"
+)
+
+// CacheStats represents the counters of the ids or beans cache of a table
+type CacheStats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Puts      int64 `json:"puts"`
+	Evictions int64 `json:"evictions"`
+	Expired   int64 `json:"expired"`
+	Entries   int64 `json:"entries"`
+}
+
+// HitRatio returns hits / (hits + misses), or 0 if there is no get
+func (s CacheStats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+func (s *CacheStats) add(other *CacheStats) {
+	s.Hits += other.Hits
+	s.Misses += other.Misses
+	s.Puts += other.Puts
+	s.Evictions += other.Evictions
+	s.Expired += other.Expired
+	s.Entries += other.Entries
+}
+
+// TableCacheStats represents the stats of the sql-ids cache and the beans cache of a table
+type TableCacheStats struct {
+	Ids   CacheStats `json:"ids"`
+	Beans CacheStats `json:"beans"`
+}
+
+func (s *TableCacheStats) add(other *TableCacheStats) {
+	s.Ids.add(&other.Ids)
+	s.Beans.add(&other.Beans)
+}
+
+// StatsCacher is implemented by the cachers which count their operations
+type StatsCacher interface {
+	Cacher
+	// Stats returns the stats of all the tables keyed by the table names
+	Stats() map[string]*TableCacheStats
+}
+
+// EvictEvent represents an entry which is removed by the cacher itself
+type EvictEvent struct {
+	TableName string
+	// Key is the sql of an ids entry or the id of a bean entry
+	Key    string
+	IsBean bool
+	// Expired is true if the entry is expired, otherwise it's evicted because
+	// the cacher is full
+	Expired bool
+}
+
+// atomicCacheStats counts the operations of the cachers which have no lock
+type atomicCacheStats struct {
+	hits, misses, puts int64
+}
+
+func (s *atomicCacheStats) hit(ok bool) {
+	if ok {
+		atomic.AddInt64(&s.hits, 1)
+	} else {
+		atomic.AddInt64(&s.misses, 1)
+	}
+}
+
+func (s *atomicCacheStats) put() {
+	atomic.AddInt64(&s.puts, 1)
+}
+
+func (s *atomicCacheStats) load() CacheStats {
+	return CacheStats{
+		Hits:   atomic.LoadInt64(&s.hits),
+		Misses: atomic.LoadInt64(&s.misses),
+		Puts:   atomic.LoadInt64(&s.puts),
+	}
+}
+
+// Stats returns the stats of all the cachers managed which implement StatsCacher,
+// a table's stats are summed up if it's cached by more than one cacher
+func (mgr *Manager) Stats() map[string]*TableCacheStats {
+	var cachers = make([]Cacher, 0, 1)
+	mgr.cacherLock.RLock()
+	if mgr.cacher != nil {
+		cachers = append(cachers, mgr.cacher)
+	}
+	for _, cacher := range mgr.cachers {
+		cachers = append(cachers, cacher)
+	}
+	mgr.cacherLock.RUnlock()
+
+	var (
+		res     = make(map[string]*TableCacheStats)
+		visited = make(map[StatsCacher]bool)
+	)
+	for _, cacher := range cachers {
+		sc, ok := cacher.(StatsCacher)
+		if !ok || visited[sc] {
+			continue
+		}
+		visited[sc] = true
+		for tableName, stats := range sc.Stats() {
+			if _, ok := res[tableName]; !ok {
+				res[tableName] = &TableCacheStats{}
+			}
+			res[tableName].add(stats)
+		}
+	}
+	return res
+}
+
+// PublishExpvar exports Stats as an expvar variable. Like expvar.Publish, it
+// panics if the name is already published.
+func (mgr *Manager) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return mgr.Stats()
+	}))
+}
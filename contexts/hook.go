@@ -12,23 +12,53 @@ import (
 
 // ContextHook represents a hook context
 type ContextHook struct {
-	start       time.Time
-	Ctx         context.Context
-	SQL         string        // log content or SQL
-	Args        []interface{} // if it's a SQL, it's the arguments
-	Result      sql.Result
-	ExecuteTime time.Duration
-	Err         error // SQL executed error
+	start        time.Time
+	Ctx          context.Context
+	SQL          string        // log content or SQL
+	Args         []interface{} // if it's a SQL, it's the arguments
+	Result       sql.Result
+	ExecuteTime  time.Duration
+	Err          error     // SQL executed error
+	Operation    Operation // the operation of the session, i.e. insert, find, update
+	TableName    string    // the table of the operation, it's empty for raw SQL
+	SessionID    string
+	TxID         string // the transaction of the session, it's empty if there is none
+	RowsAffected int64  // the rows affected by an exec, it's -1 if it's unknown
+	rawArgs      []interface{}
 }
 
-// NewContextHook return context for hook
+// NewContextHook return context for hook, the operation is read from ctx
 func NewContextHook(ctx context.Context, sql string, args []interface{}) *ContextHook {
-	return &ContextHook{
-		start: time.Now(),
-		Ctx:   ctx,
-		SQL:   sql,
-		Args:  args,
+	c := &ContextHook{
+		start:        time.Now(),
+		Ctx:          ctx,
+		SQL:          sql,
+		Args:         args,
+		RowsAffected: -1,
+		rawArgs:      args,
 	}
+	if info := OperationFromContext(ctx); info != nil {
+		c.Operation = info.Operation
+		c.TableName = info.TableName
+		c.SessionID = info.SessionID
+		c.TxID = info.TxID
+	}
+	// the context of a transaction is the one which begins it
+	if op := txOperation(sql); op != "" {
+		c.Operation = op
+	}
+	return c
+}
+
+// RawArgs returns the arguments bound to the SQL, while Args may have the sensitive ones
+// masked. They should never be logged.
+func (c *ContextHook) RawArgs() []interface{} {
+	return c.rawArgs
+}
+
+// InTx returns true if the SQL is executed in a transaction
+func (c *ContextHook) InTx() bool {
+	return c.TxID != ""
 }
 
 func (c *ContextHook) End(ctx context.Context, result sql.Result, err error) {
@@ -36,6 +66,11 @@ func (c *ContextHook) End(ctx context.Context, result sql.Result, err error) {
 	c.Result = result
 	c.Err = err
 	c.ExecuteTime = time.Now().Sub(c.start)
+	if result != nil && err == nil {
+		if affected, err := result.RowsAffected(); err == nil {
+			c.RowsAffected = affected
+		}
+	}
 }
 
 type Hook interface {
@@ -51,6 +86,13 @@ func (h *Hooks) AddHook(hooks ...Hook) {
 	h.hooks = append(h.hooks, hooks...)
 }
 
+// Len returns the number of the hooks
+func (h *Hooks) Len() int {
+	return len(h.hooks)
+}
+
+// BeforeProcess invokes the hooks in order, the context returned by a hook is passed to
+// the next one by c.Ctx
 func (h *Hooks) BeforeProcess(c *ContextHook) (context.Context, error) {
 	ctx := c.Ctx
 	for _, h := range h.hooks {
@@ -59,8 +101,11 @@ func (h *Hooks) BeforeProcess(c *ContextHook) (context.Context, error) {
 		if err != nil {
 			return nil, err
 		}
+		if ctx != nil {
+			c.Ctx = ctx
+		}
 	}
-	return ctx, nil
+	return c.Ctx, nil
 }
 
 func (h *Hooks) AfterProcess(c *ContextHook) error {
@@ -138,3 +138,63 @@ func TestAfterProcess(t *testing.T) {
 		})
 	}
 }
+
+func TestNewContextHook(t *testing.T) {
+	ctx := WithOperation(context.Background(), &OperationInfo{
+		Operation: OperationInsert,
+		TableName: "user",
+		SessionID: "s1",
+		TxID:      "t1",
+	})
+	c := NewContextHook(ctx, "INSERT INTO user VALUES (?)", []interface{}{1})
+	if c.Operation != OperationInsert || c.TableName != "user" || c.SessionID != "s1" || !c.InTx() {
+		t.Errorf("got %#v", c)
+	}
+	if c.RowsAffected != -1 {
+		t.Errorf("got rows affected %d, expect -1", c.RowsAffected)
+	}
+
+	// the context of a transaction is the one which begins it
+	c = NewContextHook(ctx, "COMMIT", nil)
+	if c.Operation != OperationCommit || c.TxID != "t1" {
+		t.Errorf("got %#v", c)
+	}
+
+	c = NewContextHook(context.Background(), "SELECT 1", nil)
+	if c.Operation != "" || c.InTx() {
+		t.Errorf("got %#v", c)
+	}
+}
+
+type ctxKey struct{}
+
+func TestBeforeProcessContext(t *testing.T) {
+	hooks := Hooks{}
+	hooks.AddHook(
+		&testHook{
+			before: func(c *ContextHook) (context.Context, error) {
+				return context.WithValue(c.Ctx, ctxKey{}, 1), nil
+			},
+		},
+		&testHook{
+			before: func(c *ContextHook) (context.Context, error) {
+				if c.Ctx.Value(ctxKey{}) != 1 {
+					t.Errorf("the context of the first hook is not passed")
+				}
+				return c.Ctx, nil
+			},
+		},
+	)
+	ctx, err := hooks.BeforeProcess(&ContextHook{Ctx: context.Background()})
+	if err != nil || ctx.Value(ctxKey{}) != 1 {
+		t.Errorf("got %v, %v", ctx, err)
+	}
+}
+
+func TestRawArgs(t *testing.T) {
+	c := NewContextHook(context.Background(), "SELECT * FROM user WHERE password = ?", []interface{}{"secret"})
+	c.Args = []interface{}{"******"}
+	if len(c.RawArgs()) != 1 || c.RawArgs()[0] != "secret" {
+		t.Fatalf("unexpected raw args %v", c.RawArgs())
+	}
+}
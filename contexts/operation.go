@@ -0,0 +1,73 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package contexts
+
+import (
+	"context"
+	"strings"
+)
+
+// Operation represents the kind of the operation which executes a SQL
+type Operation string
+
+// enumerate all the operations
+const (
+	OperationInsert   Operation = "insert"
+	OperationUpdate   Operation = "update"
+	OperationDelete   Operation = "delete"
+	OperationUpsert   Operation = "upsert"
+	OperationFind     Operation = "find"
+	OperationGet      Operation = "get"
+	OperationCount    Operation = "count"
+	OperationSum      Operation = "sum"
+	OperationExist    Operation = "exist"
+	OperationIterate  Operation = "iterate"
+	OperationQuery    Operation = "query"
+	OperationExec     Operation = "exec"
+	OperationBegin    Operation = "begin"
+	OperationCommit   Operation = "commit"
+	OperationRollback Operation = "rollback"
+	OperationPrepare  Operation = "prepare"
+)
+
+// OperationInfo describes the operation of a session which executes the SQL
+type OperationInfo struct {
+	Operation Operation
+	TableName string
+	SessionID string
+	// TxID identifies the transaction of the session, it's empty if there is none
+	TxID string
+}
+
+type operationKey struct{}
+
+// WithOperation returns a context carrying the operation info
+func WithOperation(ctx context.Context, info *OperationInfo) context.Context {
+	return context.WithValue(ctx, operationKey{}, info)
+}
+
+// OperationFromContext returns the operation info of the context, or nil if there is none
+func OperationFromContext(ctx context.Context) *OperationInfo {
+	if ctx == nil {
+		return nil
+	}
+	info, _ := ctx.Value(operationKey{}).(*OperationInfo)
+	return info
+}
+
+// txOperation returns the operation of the SQL controlling a transaction
+func txOperation(sql string) Operation {
+	switch strings.ToUpper(sql) {
+	case "BEGIN TRANSACTION":
+		return OperationBegin
+	case "COMMIT":
+		return OperationCommit
+	case "ROLLBACK":
+		return OperationRollback
+	case "PREPARE":
+		return OperationPrepare
+	}
+	return ""
+}
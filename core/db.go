@@ -88,6 +88,7 @@ type DB struct {
 	reflectCache      map[reflect.Type]*cacheStruct
 	reflectCacheMutex sync.RWMutex
 	Logger            log.ContextLogger
+	Redactor          *log.Redactor // masks the args of the hooks and the errors, it could be nil
 	hooks             contexts.Hooks
 }
 
@@ -141,13 +142,13 @@ func (db *DB) reflectNew(typ reflect.Type) reflect.Value {
 
 // QueryContext overwrites sql.DB.QueryContext
 func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
-	hookCtx := contexts.NewContextHook(ctx, query, args)
+	hookCtx := db.newContextHook(ctx, query, args)
 	ctx, err := db.beforeProcess(hookCtx)
 	if err != nil {
 		return nil, err
 	}
 	rows, err := db.DB.QueryContext(ctx, query, args...)
-	hookCtx.End(ctx, nil, err)
+	hookCtx.End(ctx, nil, db.Redactor.RedactError(err, query, args))
 	if err := db.afterProcess(hookCtx); err != nil {
 		if rows != nil {
 			rows.Close()
@@ -252,13 +253,13 @@ func (db *DB) ExecStructContext(ctx context.Context, query string, st interface{
 }
 
 func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	hookCtx := contexts.NewContextHook(ctx, query, args)
+	hookCtx := db.newContextHook(ctx, query, args)
 	ctx, err := db.beforeProcess(hookCtx)
 	if err != nil {
 		return nil, err
 	}
 	res, err := db.DB.ExecContext(ctx, query, args...)
-	hookCtx.End(ctx, res, err)
+	hookCtx.End(ctx, res, db.Redactor.RedactError(err, query, args))
 	if err := db.afterProcess(hookCtx); err != nil {
 		return nil, err
 	}
@@ -269,6 +270,17 @@ func (db *DB) ExecStruct(query string, st interface{}) (sql.Result, error) {
 	return db.ExecStructContext(context.Background(), query, st)
 }
 
+// newContextHook creates the context of the hooks, the real args are kept as its RawArgs.
+// The args are masked by the redactor only if they will be logged or passed to the hooks,
+// so the SQLs are not parsed for nothing.
+func (db *DB) newContextHook(ctx context.Context, query string, args []interface{}) *contexts.ContextHook {
+	c := contexts.NewContextHook(ctx, query, args)
+	if len(args) > 0 && (db.hooks.Len() > 0 || db.NeedLogSQL(ctx)) {
+		c.Args = db.Redactor.Redact(query, args)
+	}
+	return c
+}
+
 func (db *DB) beforeProcess(c *contexts.ContextHook) (context.Context, error) {
 	if db.NeedLogSQL(c.Ctx) {
 		db.Logger.BeforeSQL(log.LogContext(*c))
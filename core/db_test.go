@@ -5,6 +5,7 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"os"
@@ -13,6 +14,8 @@ import (
 
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/xormplus/xorm/contexts"
+	"github.com/xormplus/xorm/log"
 	"github.com/xormplus/xorm/names"
 )
 
@@ -682,3 +685,32 @@ func BenchmarkExecStruct(b *testing.B) {
 		}
 	}
 }
+
+type nopHook struct{}
+
+func (nopHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) { return c.Ctx, nil }
+func (nopHook) AfterProcess(c *contexts.ContextHook) error                     { return nil }
+
+func TestNewContextHook(t *testing.T) {
+	db, err := testOpen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	db.Redactor = log.NewRedactor()
+	db.Redactor.AddSensitiveColumns("name")
+	query, args := "select * from user where `name` = ?", []interface{}{"xlw"}
+
+	// the args are not masked if they are neither logged nor passed to the hooks
+	c := db.newContextHook(context.Background(), query, args)
+	if c.Args[0] != "xlw" || c.RawArgs()[0] != "xlw" {
+		t.Errorf("got args %v, raw args %v", c.Args, c.RawArgs())
+	}
+
+	db.AddHook(nopHook{})
+	c = db.newContextHook(context.Background(), query, args)
+	if c.Args[0] != log.RedactedValue || c.RawArgs()[0] != "xlw" {
+		t.Errorf("got args %v, raw args %v", c.Args, c.RawArgs())
+	}
+}
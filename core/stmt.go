@@ -81,13 +81,13 @@ func (s *Stmt) ExecStruct(st interface{}) (sql.Result, error) {
 }
 
 func (s *Stmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
-	hookCtx := contexts.NewContextHook(ctx, s.query, args)
+	hookCtx := s.db.newContextHook(ctx, s.query, args)
 	ctx, err := s.db.beforeProcess(hookCtx)
 	if err != nil {
 		return nil, err
 	}
 	res, err := s.Stmt.ExecContext(ctx, args)
-	hookCtx.End(ctx, res, err)
+	hookCtx.End(ctx, res, s.db.Redactor.RedactError(err, s.query, args))
 	if err := s.db.afterProcess(hookCtx); err != nil {
 		return nil, err
 	}
@@ -95,13 +95,13 @@ func (s *Stmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result
 }
 
 func (s *Stmt) QueryContext(ctx context.Context, args ...interface{}) (*Rows, error) {
-	hookCtx := contexts.NewContextHook(ctx, s.query, args)
+	hookCtx := s.db.newContextHook(ctx, s.query, args)
 	ctx, err := s.db.beforeProcess(hookCtx)
 	if err != nil {
 		return nil, err
 	}
 	rows, err := s.Stmt.QueryContext(ctx, args...)
-	hookCtx.End(ctx, nil, err)
+	hookCtx.End(ctx, nil, s.db.Redactor.RedactError(err, s.query, args))
 	if err := s.db.afterProcess(hookCtx); err != nil {
 		return nil, err
 	}
@@ -123,13 +123,13 @@ func (tx *Tx) ExecStructContext(ctx context.Context, query string, st interface{
 }
 
 func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	hookCtx := contexts.NewContextHook(ctx, query, args)
+	hookCtx := tx.db.newContextHook(ctx, query, args)
 	ctx, err := tx.db.beforeProcess(hookCtx)
 	if err != nil {
 		return nil, err
 	}
 	res, err := tx.Tx.ExecContext(ctx, query, args...)
-	hookCtx.End(ctx, res, err)
+	hookCtx.End(ctx, res, tx.db.Redactor.RedactError(err, query, args))
 	if err := tx.db.afterProcess(hookCtx); err != nil {
 		return nil, err
 	}
@@ -141,13 +141,13 @@ func (tx *Tx) ExecStruct(query string, st interface{}) (sql.Result, error) {
 }
 
 func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
-	hookCtx := contexts.NewContextHook(ctx, query, args)
+	hookCtx := tx.db.newContextHook(ctx, query, args)
 	ctx, err := tx.db.beforeProcess(hookCtx)
 	if err != nil {
 		return nil, err
 	}
 	rows, err := tx.Tx.QueryContext(ctx, query, args...)
-	hookCtx.End(ctx, nil, err)
+	hookCtx.End(ctx, nil, tx.db.Redactor.RedactError(err, query, args))
 	if err := tx.db.afterProcess(hookCtx); err != nil {
 		if rows != nil {
 			rows.Close()
@@ -7,6 +7,7 @@ package dialects
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -56,6 +57,10 @@ type Dialect interface {
 	CreateIndexSQL(tableName string, index *schemas.Index) string
 	DropIndexSQL(tableName string, index *schemas.Index) string
 
+	GetForeignKeys(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.ForeignKey, error)
+	CreateForeignKeySQL(tableName string, fk *schemas.ForeignKey) string
+	DropForeignKeySQL(tableName string, fk *schemas.ForeignKey) string
+
 	GetTables(queryer core.Queryer, ctx context.Context) ([]*schemas.Table, error)
 	IsTableExist(queryer core.Queryer, ctx context.Context, tableName string) (bool, error)
 	CreateTableSQL(table *schemas.Table, tableName string) ([]string, bool)
@@ -65,6 +70,7 @@ type Dialect interface {
 	IsColumnExist(queryer core.Queryer, ctx context.Context, tableName string, colName string) (bool, error)
 	AddColumnSQL(tableName string, col *schemas.Column) string
 	ModifyColumnSQL(tableName string, col *schemas.Column) string
+	DropColumnSQL(tableName, colName string) string
 
 	ForUpdateSQL(query string) string
 
@@ -72,6 +78,27 @@ type Dialect interface {
 	SetParams(params map[string]string)
 }
 
+// VersionDetector is implemented by the dialects whose supported SQLs depend on the version
+// of the database, the version is detected once the database is opened
+type VersionDetector interface {
+	DetectVersion(queryer core.Queryer, ctx context.Context) error
+}
+
+// SupportReturning returns true if the dialect supports the RETURNING clause of insert,
+// update and delete, i.e. postgres, oracle and sqlite3 3.35.0 or later
+func SupportReturning(dialect Dialect) bool {
+	switch dialect.URI().DBType {
+	case schemas.POSTGRES, schemas.ORACLE:
+		return true
+	case schemas.SQLITE:
+		if db, ok := dialect.(*sqlite3); ok {
+			return db.versionAtLeast(sqliteReturningVersion)
+		}
+		return true
+	}
+	return false
+}
+
 // Base represents a basic dialect and all real dialects could embed this struct
 type Base struct {
 	dialect Dialect
@@ -161,11 +188,64 @@ func (db *Base) DropIndexSQL(tableName string, index *schemas.Index) string {
 	return fmt.Sprintf("DROP INDEX %v ON %s", quote(name), quote(tableName))
 }
 
+func (db *Base) CreateForeignKeySQL(tableName string, fk *schemas.ForeignKey) string {
+	return fmt.Sprintf("ALTER TABLE %v ADD %v", db.dialect.Quoter().Quote(tableName),
+		ForeignKeyString(db.dialect, tableName, fk))
+}
+
+func (db *Base) DropForeignKeySQL(tableName string, fk *schemas.ForeignKey) string {
+	quote := db.dialect.Quoter().Quote
+	return fmt.Sprintf("ALTER TABLE %v DROP CONSTRAINT %v", quote(tableName), quote(fk.XName(tableName)))
+}
+
+// queryForeignKeys reads the foreign keys from a query which returns constraint name,
+// column, referenced table, referenced column, update rule and delete rule ordered by
+// constraint name and column position
+func (db *Base) queryForeignKeys(queryer core.Queryer, ctx context.Context, tableName, query string, args ...interface{}) (map[string]*schemas.ForeignKey, error) {
+	rows, err := queryer.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fks := make(map[string]*schemas.ForeignKey)
+	for rows.Next() {
+		var fkName, colName, refTable, refCol, onUpdate, onDelete string
+		err = rows.Scan(&fkName, &colName, &refTable, &refCol, &onUpdate, &onDelete)
+		if err != nil {
+			return nil, err
+		}
+
+		fkName = strings.Trim(fkName, "`\" []")
+		var isRegular bool
+		if len(fkName) > 4+len(tableName) && strings.HasPrefix(fkName, "FK_"+tableName+"_") {
+			fkName = fkName[4+len(tableName):]
+			isRegular = true
+		}
+
+		fk, ok := fks[fkName]
+		if !ok {
+			fk = schemas.NewForeignKey(fkName, strings.Trim(refTable, "`\" []"))
+			fk.IsRegular = isRegular
+			fk.OnUpdate, _ = schemas.ParseFKAction(onUpdate)
+			fk.OnDelete, _ = schemas.ParseFKAction(onDelete)
+			fks[fkName] = fk
+		}
+		fk.AddColumn(strings.Trim(colName, "`\" []"), strings.Trim(refCol, "`\" []"))
+	}
+	return fks, nil
+}
+
 func (db *Base) ModifyColumnSQL(tableName string, col *schemas.Column) string {
 	s, _ := ColumnString(db.dialect, col, false)
 	return fmt.Sprintf("alter table %s MODIFY COLUMN %s", tableName, s)
 }
 
+func (db *Base) DropColumnSQL(tableName, colName string) string {
+	quoter := db.dialect.Quoter()
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", quoter.Quote(tableName), quoter.Quote(colName))
+}
+
 func (b *Base) ForUpdateSQL(query string) string {
 	return query + " FOR UPDATE"
 }
@@ -282,3 +362,60 @@ func ColumnString(dialect Dialect, col *schemas.Column, includePrimaryKey bool)
 
 	return bd.String(), nil
 }
+
+// ForeignKeyString generate foreign key constraint description string according dialect
+func ForeignKeyString(dialect Dialect, tableName string, fk *schemas.ForeignKey) string {
+	quoter := dialect.Quoter()
+	var bd strings.Builder
+	bd.WriteString("CONSTRAINT ")
+	bd.WriteString(quoter.Quote(fk.XName(tableName)))
+	bd.WriteString(" FOREIGN KEY (")
+	bd.WriteString(quoter.Join(fk.Cols, ","))
+	bd.WriteString(") REFERENCES ")
+	bd.WriteString(quoter.Quote(fk.RefTable))
+	bd.WriteString(" (")
+	bd.WriteString(quoter.Join(fk.RefCols, ","))
+	bd.WriteString(")")
+
+	onDelete, onUpdate := fk.OnDelete, fk.OnUpdate
+	switch dialect.(type) {
+	case *oracle:
+		// oracle only supports ON DELETE CASCADE and ON DELETE SET NULL
+		if onDelete != schemas.FKCascade && onDelete != schemas.FKSetNull {
+			onDelete = ""
+		}
+		onUpdate = ""
+	case *mssql:
+		if onDelete == schemas.FKRestrict {
+			onDelete = schemas.FKNoAction
+		}
+		if onUpdate == schemas.FKRestrict {
+			onUpdate = schemas.FKNoAction
+		}
+	}
+	if onDelete != "" {
+		bd.WriteString(" ON DELETE ")
+		bd.WriteString(onDelete)
+	}
+	if onUpdate != "" {
+		bd.WriteString(" ON UPDATE ")
+		bd.WriteString(onUpdate)
+	}
+	return bd.String()
+}
+
+// foreignKeysSQL returns the foreign key constraints of table sorted by name
+// so that the generated create table SQL is stable
+func foreignKeysSQL(dialect Dialect, table *schemas.Table, tableName string) []string {
+	var names = make([]string, 0, len(table.ForeignKeys))
+	for name := range table.ForeignKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sqls = make([]string, 0, len(names))
+	for _, name := range names {
+		sqls = append(sqls, ForeignKeyString(dialect, tableName, table.ForeignKeys[name]))
+	}
+	return sqls
+}
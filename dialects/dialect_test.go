@@ -0,0 +1,69 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dialects
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xormplus/xorm/schemas"
+)
+
+func TestForeignKeyString(t *testing.T) {
+	fk := schemas.NewForeignKey("user_id", "user")
+	fk.AddColumn("user_id", "id")
+	fk.OnDelete = schemas.FKCascade
+	fk.OnUpdate = schemas.FKRestrict
+
+	var kases = []struct {
+		dbType   schemas.DBType
+		expected string
+	}{
+		{
+			schemas.MYSQL,
+			"CONSTRAINT `FK_order_user_id` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE RESTRICT",
+		},
+		{
+			schemas.POSTGRES,
+			`CONSTRAINT "FK_order_user_id" FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON DELETE CASCADE ON UPDATE RESTRICT`,
+		},
+		{
+			schemas.MSSQL,
+			"CONSTRAINT [FK_order_user_id] FOREIGN KEY ([user_id]) REFERENCES [user] ([id]) ON DELETE CASCADE ON UPDATE NO ACTION",
+		},
+		{
+			schemas.ORACLE,
+			`CONSTRAINT "FK_order_user_id" FOREIGN KEY ("user_id") REFERENCES "user" ("id") ON DELETE CASCADE`,
+		},
+	}
+
+	for _, kase := range kases {
+		dialect := QueryDialect(kase.dbType)
+		assert.NoError(t, dialect.Init(&URI{DBType: kase.dbType}))
+		assert.EqualValues(t, kase.expected, ForeignKeyString(dialect, "order", fk))
+	}
+}
+
+func TestCreateTableWithForeignKey(t *testing.T) {
+	table := schemas.NewTable("order", nil)
+	col := schemas.NewColumn("id", "Id", schemas.SQLType{Name: schemas.BigInt}, 0, 0, false)
+	col.IsPrimaryKey = true
+	table.AddColumn(col)
+	table.AddColumn(schemas.NewColumn("user_id", "UserId", schemas.SQLType{Name: schemas.BigInt}, 0, 0, true))
+
+	fk := schemas.NewForeignKey("user_id", "user")
+	fk.AddColumn("user_id", "id")
+	fk.OnDelete = schemas.FKSetNull
+	table.AddForeignKey(fk)
+
+	dialect := QueryDialect(schemas.SQLITE)
+	assert.NoError(t, dialect.Init(&URI{DBType: schemas.SQLITE}))
+	sqls, _ := dialect.CreateTableSQL(table, "")
+	assert.EqualValues(t, []string{
+		"CREATE TABLE IF NOT EXISTS `order` (`id` INTEGER PRIMARY KEY NOT NULL, `user_id` INTEGER NULL, " +
+			"CONSTRAINT `FK_order_user_id` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE SET NULL)",
+	}, sqls)
+	assert.EqualValues(t, "", dialect.CreateForeignKeySQL("order", fk))
+}
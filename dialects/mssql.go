@@ -578,6 +578,10 @@ func (db *mssql) CreateTableSQL(table *schemas.Table, tableName string) ([]strin
 		sql += " ), "
 	}
 
+	for _, s := range foreignKeysSQL(db, table, tableName) {
+		sql += s + ", "
+	}
+
 	sql = sql[:len(sql)-2] + ")"
 	sql += ";"
 	return []string{sql}, true
@@ -587,6 +591,20 @@ func (db *mssql) ForUpdateSQL(query string) string {
 	return query
 }
 
+func (db *mssql) GetForeignKeys(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.ForeignKey, error) {
+	args := []interface{}{tableName}
+	s := `SELECT fk.name, pc.name, rt.name, rc.name,
+fk.update_referential_action_desc, fk.delete_referential_action_desc
+FROM sys.foreign_keys fk
+INNER JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id
+INNER JOIN sys.columns pc ON pc.object_id = fkc.parent_object_id AND pc.column_id = fkc.parent_column_id
+INNER JOIN sys.tables rt ON rt.object_id = fkc.referenced_object_id
+INNER JOIN sys.columns rc ON rc.object_id = fkc.referenced_object_id AND rc.column_id = fkc.referenced_column_id
+WHERE OBJECT_NAME(fk.parent_object_id) = ?
+ORDER BY fk.name, fkc.constraint_column_id`
+	return db.queryForeignKeys(queryer, ctx, tableName, s, args...)
+}
+
 func (db *mssql) Filters() []Filter {
 	return []Filter{}
 }
@@ -554,6 +554,10 @@ func (db *mysql) CreateTableSQL(table *schemas.Table, tableName string) ([]strin
 			sql += " ), "
 		}
 
+		for _, s := range foreignKeysSQL(db, table, tableName) {
+			sql += s + ", "
+		}
+
 		sql = sql[:len(sql)-2]
 	}
 	sql += ")"
@@ -576,6 +580,22 @@ func (db *mysql) CreateTableSQL(table *schemas.Table, tableName string) ([]strin
 	return []string{sql}, true
 }
 
+func (db *mysql) GetForeignKeys(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.ForeignKey, error) {
+	args := []interface{}{db.uri.DBName, tableName}
+	s := "SELECT kcu.`CONSTRAINT_NAME`, kcu.`COLUMN_NAME`, kcu.`REFERENCED_TABLE_NAME`, kcu.`REFERENCED_COLUMN_NAME`, " +
+		"rc.`UPDATE_RULE`, rc.`DELETE_RULE` FROM `INFORMATION_SCHEMA`.`KEY_COLUMN_USAGE` kcu " +
+		"INNER JOIN `INFORMATION_SCHEMA`.`REFERENTIAL_CONSTRAINTS` rc ON rc.`CONSTRAINT_SCHEMA` = kcu.`CONSTRAINT_SCHEMA` " +
+		"AND rc.`CONSTRAINT_NAME` = kcu.`CONSTRAINT_NAME` " +
+		"WHERE kcu.`TABLE_SCHEMA` = ? AND kcu.`TABLE_NAME` = ? AND kcu.`REFERENCED_TABLE_NAME` IS NOT NULL " +
+		"ORDER BY kcu.`CONSTRAINT_NAME`, kcu.`ORDINAL_POSITION`"
+	return db.queryForeignKeys(queryer, ctx, tableName, s, args...)
+}
+
+func (db *mysql) DropForeignKeySQL(tableName string, fk *schemas.ForeignKey) string {
+	quote := db.Quoter().Quote
+	return fmt.Sprintf("ALTER TABLE %v DROP FOREIGN KEY %v", quote(tableName), quote(fk.XName(tableName)))
+}
+
 func (db *mysql) Filters() []Filter {
 	return []Filter{}
 }
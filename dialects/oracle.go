@@ -589,6 +589,10 @@ func (db *oracle) CreateTableSQL(table *schemas.Table, tableName string) ([]stri
 		sql += " ), "
 	}
 
+	for _, s := range foreignKeysSQL(db, table, tableName) {
+		sql += s + ", "
+	}
+
 	sql = sql[:len(sql)-2] + ")"
 	return []string{sql}, false
 }
@@ -801,6 +805,16 @@ func (db *oracle) GetIndexes(queryer core.Queryer, ctx context.Context, tableNam
 	return indexes, nil
 }
 
+func (db *oracle) GetForeignKeys(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.ForeignKey, error) {
+	args := []interface{}{tableName}
+	s := "SELECT c.constraint_name, cc.column_name, rc.table_name, rcc.column_name, 'NO ACTION', c.delete_rule " +
+		"FROM user_constraints c, user_cons_columns cc, user_constraints rc, user_cons_columns rcc " +
+		"WHERE c.constraint_type = 'R' AND c.table_name = :1 AND cc.constraint_name = c.constraint_name " +
+		"AND rc.constraint_name = c.r_constraint_name AND rcc.constraint_name = rc.constraint_name " +
+		"AND rcc.position = cc.position ORDER BY c.constraint_name, cc.position"
+	return db.queryForeignKeys(queryer, ctx, tableName, s, args...)
+}
+
 func (db *oracle) Filters() []Filter {
 	return []Filter{
 		&SeqFilter{Prefix: ":", Start: 1},
@@ -926,6 +926,10 @@ func (db *postgres) CreateTableSQL(table *schemas.Table, tableName string) ([]st
 			sql += " ), "
 		}
 
+		for _, s := range foreignKeysSQL(db, table, tableName) {
+			sql += s + ", "
+		}
+
 		sql = sql[:len(sql)-2]
 	}
 	sql += ")"
@@ -1240,6 +1244,23 @@ func (db *postgres) GetIndexes(queryer core.Queryer, ctx context.Context, tableN
 	return indexes, nil
 }
 
+func (db *postgres) GetForeignKeys(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.ForeignKey, error) {
+	args := []interface{}{tableName}
+	s := "SELECT tc.constraint_name, kcu.column_name, ccu.table_name, ccu.column_name, rc.update_rule, rc.delete_rule " +
+		"FROM information_schema.table_constraints tc " +
+		"JOIN information_schema.key_column_usage kcu ON kcu.constraint_name = tc.constraint_name AND kcu.constraint_schema = tc.constraint_schema " +
+		"JOIN information_schema.referential_constraints rc ON rc.constraint_name = tc.constraint_name AND rc.constraint_schema = tc.constraint_schema " +
+		"JOIN information_schema.key_column_usage ccu ON ccu.constraint_name = rc.unique_constraint_name " +
+		"AND ccu.constraint_schema = rc.unique_constraint_schema AND ccu.ordinal_position = kcu.position_in_unique_constraint " +
+		"WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_name = $1"
+	if len(db.getSchema()) != 0 {
+		args = append(args, db.getSchema())
+		s = s + " AND tc.table_schema = $2"
+	}
+	s = s + " ORDER BY tc.constraint_name, kcu.ordinal_position"
+	return db.queryForeignKeys(queryer, ctx, tableName, s, args...)
+}
+
 func (db *postgres) Filters() []Filter {
 	return []Filter{&SeqFilter{Prefix: "$", Start: 1}}
 }
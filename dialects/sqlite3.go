@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/xormplus/xorm/core"
@@ -151,8 +152,73 @@ var (
 	}
 )
 
+// sqliteReturningVersion is the first version of SQLite supporting RETURNING and
+// ALTER TABLE DROP COLUMN
+var sqliteReturningVersion = []int{3, 35, 0}
+
 type sqlite3 struct {
 	Base
+	version []int // the version of SQLite, nil if it's not detected
+}
+
+// DetectVersion implements VersionDetector by sqlite_version()
+func (db *sqlite3) DetectVersion(queryer core.Queryer, ctx context.Context) error {
+	rows, err := queryer.QueryContext(ctx, "SELECT sqlite_version()")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var version string
+	if rows.Next() {
+		if err := rows.Scan(&version); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	db.version = parseVersion(version)
+	return nil
+}
+
+// versionAtLeast returns true if the detected version is not older than version, the
+// latest version is assumed if it's not detected
+func (db *sqlite3) versionAtLeast(version []int) bool {
+	if db.version == nil {
+		return true
+	}
+	for i, v := range version {
+		var cur int
+		if i < len(db.version) {
+			cur = db.version[i]
+		}
+		if cur != v {
+			return cur > v
+		}
+	}
+	return true
+}
+
+// parseVersion parses the numbers of a version like 3.34.0, nil if it's invalid
+func parseVersion(version string) []int {
+	var nums []int
+	for _, s := range strings.Split(strings.TrimSpace(version), ".") {
+		num, err := strconv.Atoi(s)
+		if err != nil {
+			return nil
+		}
+		nums = append(nums, num)
+	}
+	return nums
+}
+
+// DropColumnSQL returns empty on SQLite older than 3.35.0 which could not drop columns
+func (db *sqlite3) DropColumnSQL(tableName, colName string) string {
+	if !db.versionAtLeast(sqliteReturningVersion) {
+		return ""
+	}
+	return db.Base.DropColumnSQL(tableName, colName)
 }
 
 func (db *sqlite3) Init(uri *URI) error {
@@ -276,6 +342,10 @@ func (db *sqlite3) CreateTableSQL(table *schemas.Table, tableName string) ([]str
 			sql += " ), "
 		}
 
+		for _, s := range foreignKeysSQL(db, table, tableName) {
+			sql += s + ", "
+		}
+
 		sql = sql[:len(sql)-2]
 	}
 	sql += ")"
@@ -396,8 +466,10 @@ func (db *sqlite3) GetColumns(queryer core.Queryer, ctx context.Context, tableNa
 
 	nStart := strings.Index(name, "(")
 	nEnd := strings.LastIndex(name, ")")
+	// foreign key constraints are read by GetForeignKeys
+	colDefs := sqliteFKClauseRe.ReplaceAllString(name[nStart+1:nEnd], "")
 	reg := regexp.MustCompile(`[^\(,\)]*(\([^\(]*\))?`)
-	colCreates := reg.FindAllString(name[nStart+1:nEnd], -1)
+	colCreates := reg.FindAllString(colDefs, -1)
 	cols := make(map[string]*schemas.Column)
 	colSeq := make([]string, 0)
 
@@ -513,6 +585,93 @@ func (db *sqlite3) GetIndexes(queryer core.Queryer, ctx context.Context, tableNa
 	return indexes, nil
 }
 
+// sqlite does not keep the names of foreign key constraints in its pragmas,
+// so they are recovered from the create table SQL
+var sqliteFKNameRe = regexp.MustCompile("(?i)CONSTRAINT\\s+[`\"\\[]?([^`\"\\]\\s]+)[`\"\\]]?\\s+FOREIGN\\s+KEY\\s*\\(([^)]*)\\)")
+
+var sqliteFKClauseRe = regexp.MustCompile(`(?i),\s*(CONSTRAINT\s+\S+\s+)?FOREIGN\s+KEY\s*\([^)]*\)\s*REFERENCES\s+\S+\s*(\([^)]*\))?` +
+	`(\s+ON\s+(DELETE|UPDATE)\s+(SET\s+NULL|SET\s+DEFAULT|NO\s+ACTION|CASCADE|RESTRICT))*`)
+
+func (db *sqlite3) GetForeignKeys(queryer core.Queryer, ctx context.Context, tableName string) (map[string]*schemas.ForeignKey, error) {
+	var fkNames = make(map[string]string)
+	tableRows, err := queryer.QueryContext(ctx, "SELECT sql FROM sqlite_master WHERE type='table' and name = ?", tableName)
+	if err != nil {
+		return nil, err
+	}
+	var createSQL sql.NullString
+	if tableRows.Next() {
+		err = tableRows.Scan(&createSQL)
+	}
+	tableRows.Close()
+	if err != nil {
+		return nil, err
+	}
+	for _, matches := range sqliteFKNameRe.FindAllStringSubmatch(createSQL.String, -1) {
+		var cols []string
+		for _, col := range strings.Split(matches[2], ",") {
+			cols = append(cols, strings.ToLower(strings.Trim(col, "` []\"")))
+		}
+		fkNames[strings.Join(cols, ",")] = matches[1]
+	}
+
+	rows, err := queryer.QueryContext(ctx, "PRAGMA foreign_key_list("+db.Quoter().Quote(tableName)+")")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	var byID = make(map[int]*schemas.ForeignKey)
+	for rows.Next() {
+		var id, seq int
+		var refTable, from, onUpdate, onDelete, match string
+		var to sql.NullString
+		if err = rows.Scan(&id, &seq, &refTable, &from, &to, &onUpdate, &onDelete, &match); err != nil {
+			return nil, err
+		}
+
+		fk, ok := byID[id]
+		if !ok {
+			fk = schemas.NewForeignKey("", refTable)
+			fk.OnUpdate, _ = schemas.ParseFKAction(onUpdate)
+			fk.OnDelete, _ = schemas.ParseFKAction(onDelete)
+			byID[id] = fk
+			ids = append(ids, id)
+		}
+		fk.AddColumn(from, to.String)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	fks := make(map[string]*schemas.ForeignKey, len(ids))
+	for _, id := range ids {
+		fk := byID[id]
+		fkName, ok := fkNames[strings.ToLower(strings.Join(fk.Cols, ","))]
+		if !ok {
+			fkName = "FK_" + tableName + "_" + strings.Join(fk.Cols, "_")
+		}
+		if len(fkName) > 4+len(tableName) && strings.HasPrefix(fkName, "FK_"+tableName+"_") {
+			fk.Name = fkName[4+len(tableName):]
+		} else {
+			fk.Name = fkName
+			fk.IsRegular = false
+		}
+		fks[fk.Name] = fk
+	}
+	return fks, nil
+}
+
+// CreateForeignKeySQL returns empty since sqlite cannot add a constraint to an existing table
+func (db *sqlite3) CreateForeignKeySQL(tableName string, fk *schemas.ForeignKey) string {
+	return ""
+}
+
+// DropForeignKeySQL returns empty since sqlite cannot drop a constraint from an existing table
+func (db *sqlite3) DropForeignKeySQL(tableName string, fk *schemas.ForeignKey) string {
+	return ""
+}
+
 func (db *sqlite3) Filters() []Filter {
 	return []Filter{}
 }
@@ -33,3 +33,30 @@ func TestSplitColStr(t *testing.T) {
 		assert.EqualValues(t, kase.fields, splitColStr(kase.colStr))
 	}
 }
+
+func TestSQLiteVersion(t *testing.T) {
+	assert.EqualValues(t, []int{3, 34, 0}, parseVersion("3.34.0"))
+	assert.Nil(t, parseVersion("3.x"))
+
+	db := QueryDialect("sqlite3").(*sqlite3)
+	assert.NoError(t, db.Init(&URI{DBType: "sqlite3"}))
+	assert.True(t, SupportReturning(db))
+
+	db.version = parseVersion("3.34.0")
+	assert.False(t, SupportReturning(db))
+
+	db.version = parseVersion("3.35.0")
+	assert.True(t, SupportReturning(db))
+
+	db.version = parseVersion("3.40")
+	assert.True(t, SupportReturning(db))
+}
+
+func TestSQLiteDropColumnSQL(t *testing.T) {
+	db := QueryDialect("sqlite3").(*sqlite3)
+	assert.NoError(t, db.Init(&URI{DBType: "sqlite3"}))
+	assert.EqualValues(t, "ALTER TABLE `user` DROP COLUMN `name`", db.DropColumnSQL("user", "name"))
+
+	db.version = parseVersion("3.34.0")
+	assert.EqualValues(t, "", db.DropColumnSQL("user", "name"))
+}
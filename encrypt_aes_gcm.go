@@ -0,0 +1,61 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+// AesGcmEncrypt is an AES-GCM cipher which encrypts every value with a random nonce, the
+// nonce is stored before the ciphertext. So the same plaintexts are encrypted to different
+// ciphertexts and they are authenticated. The key should be 16, 24 or 32 bytes.
+type AesGcmEncrypt struct {
+	Key []byte
+}
+
+var _ Cipher = &AesGcmEncrypt{}
+
+func (this *AesGcmEncrypt) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(this.Key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt encrypts the message, the nonce is prepended to the result
+func (this *AesGcmEncrypt) Encrypt(strMesg string) ([]byte, error) {
+	aead, err := this.aead()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, []byte(strMesg), nil), nil
+}
+
+// Decrypt decrypts the base64 of the result of Encrypt
+func (this *AesGcmEncrypt) Decrypt(src []byte) ([]byte, error) {
+	src, err := base64.StdEncoding.DecodeString(string(src))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := this.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(src) < aead.NonceSize() {
+		return nil, errors.New("the ciphertext is too short")
+	}
+	nonce, ciphertext := src[:aead.NonceSize()], src[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
@@ -13,6 +13,7 @@ import (
 	"os"
 	"reflect"
 	//"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +24,8 @@ import (
 	"github.com/xormplus/xorm/contexts"
 	"github.com/xormplus/xorm/core"
 	"github.com/xormplus/xorm/dialects"
+	"github.com/xormplus/xorm/exporters"
+	"github.com/xormplus/xorm/importers"
 	"github.com/xormplus/xorm/internal/utils"
 	"github.com/xormplus/xorm/log"
 	"github.com/xormplus/xorm/names"
@@ -52,6 +55,8 @@ type Engine struct {
 	DatabaseTZ *time.Location // The timezone of the database
 
 	logSessionID bool // create session id
+	slowQuery    *slowQueryHook
+	ciphers      *cipherKeyring
 }
 
 // EnableSessionID if enable session id
@@ -69,6 +74,26 @@ func (engine *Engine) GetCacher(tableName string) caches.Cacher {
 	return engine.cacherMgr.GetCacher(tableName)
 }
 
+// CacheStats returns the stats of all the cachers which implement caches.StatsCacher
+func (engine *Engine) CacheStats() map[string]*caches.TableCacheStats {
+	return engine.cacherMgr.Stats()
+}
+
+// PublishCacheStats exports CacheStats as an expvar variable
+func (engine *Engine) PublishCacheStats(name string) {
+	engine.cacherMgr.PublishExpvar(name)
+}
+
+// SetQueryCacher sets the cacher of the raw query results cached by CacheFor
+func (engine *Engine) SetQueryCacher(cacher caches.QueryCacher) {
+	engine.cacherMgr.SetQueryCacher(cacher)
+}
+
+// GetQueryCacher returns the cacher of the raw query results cached by CacheFor
+func (engine *Engine) GetQueryCacher() caches.QueryCacher {
+	return engine.cacherMgr.GetQueryCacher()
+}
+
 // SetQuotePolicy sets the special quote policy
 func (engine *Engine) SetQuotePolicy(quotePolicy dialects.QuotePolicy) {
 	engine.dialect.SetQuotePolicy(quotePolicy)
@@ -105,6 +130,13 @@ func (engine *Engine) SetLogger(logger interface{}) {
 	engine.DB().Logger = realLogger
 }
 
+// AddRedactRules adds the rules masking the args in the logs, the hooks, LastSQL and the
+// errors, i.e. log.RedactColumns("password", "*_token"). The args of the columns tagged
+// sensitive are always masked.
+func (engine *Engine) AddRedactRules(rules ...log.RedactRule) {
+	engine.db.Redactor.AddRules(rules...)
+}
+
 // SetLogLevel sets the logger level
 func (engine *Engine) SetLogLevel(level log.LogLevel) {
 	engine.logger.SetLevel(level)
@@ -202,6 +234,20 @@ func (engine *Engine) GetDefaultCacher() caches.Cacher {
 	return engine.cacherMgr.GetDefaultCacher()
 }
 
+// UseMain sends the reads of the session to the main engine of the group
+func (engine *Engine) UseMain() *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.UseMain()
+}
+
+// UseSubordinate sends the reads of the session to the subordinates of the group
+func (engine *Engine) UseSubordinate() *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.UseSubordinate()
+}
+
 // NoCache If you has set default cacher, and you want temporilly stop use cache,
 // you can use NoCache()
 func (engine *Engine) NoCache() *Session {
@@ -210,6 +256,55 @@ func (engine *Engine) NoCache() *Session {
 	return session.NoCache()
 }
 
+// CacheFor caches the results of the next raw query for ttl until any of tables is written
+func (engine *Engine) CacheFor(ttl time.Duration, tables ...string) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.CacheFor(ttl, tables...)
+}
+
+// Partitions reads the partitions of the partitioned table holding the records in [from, to)
+func (engine *Engine) Partitions(from, to time.Time) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.Partitions(from, to)
+}
+
+// With adds a common table expression to the WITH clause of the query
+func (engine *Engine) With(name string, query interface{}, args ...interface{}) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.With(name, query, args...)
+}
+
+// WithRecursive adds a recursive common table expression to the WITH clause of the query
+func (engine *Engine) WithRecursive(name string, query interface{}, args ...interface{}) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.WithRecursive(name, query, args...)
+}
+
+// From selects from the sub query named by alias instead of the table
+func (engine *Engine) From(query interface{}, alias string, args ...interface{}) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.From(query, alias, args...)
+}
+
+// Paginate returns a Paginator reading the page of pageSize rows after the cursor
+func (engine *Engine) Paginate(cursor string, pageSize int, orderCols ...string) *Paginator {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.Paginate(cursor, pageSize, orderCols...)
+}
+
+// Preload loads the relations of the found beans by batched queries
+func (engine *Engine) Preload(paths ...string) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.Preload(paths...)
+}
+
 // NoCascade If you do not want to auto cascade load object
 func (engine *Engine) NoCascade() *Session {
 	session := engine.NewSession()
@@ -245,6 +340,9 @@ func (engine *Engine) NewSession() *Session {
 
 // Close the engine
 func (engine *Engine) Close() error {
+	if engine.slowQuery != nil {
+		engine.slowQuery.wait()
+	}
 	return engine.DB().Close()
 }
 
@@ -303,6 +401,12 @@ func (engine *Engine) loadTableInfo(table *schemas.Table) error {
 	}
 	table.Indexes = indexes
 
+	fks, err := engine.dialect.GetForeignKeys(engine.db, engine.defaultContext, table.Name)
+	if err != nil {
+		return err
+	}
+	table.ForeignKeys = fks
+
 	var seq int
 	for _, index := range indexes {
 		for _, name := range index.Cols {
@@ -447,6 +551,66 @@ func formatColumnValue(dstDialect dialects.Dialect, d interface{}, col *schemas.
 	return s
 }
 
+// sortTablesByForeignKeys orders tables so that referenced tables come before
+// the tables referencing them, the original order is kept otherwise
+func sortTablesByForeignKeys(tables []*schemas.Table) []*schemas.Table {
+	var byName = make(map[string]*schemas.Table, len(tables))
+	for _, table := range tables {
+		byName[strings.ToLower(table.Name)] = table
+	}
+
+	var sorted = make([]*schemas.Table, 0, len(tables))
+	var visited = make(map[*schemas.Table]bool, len(tables))
+	var visit func(table *schemas.Table)
+	visit = func(table *schemas.Table) {
+		if visited[table] {
+			return
+		}
+		visited[table] = true
+
+		var refNames = make([]string, 0, len(table.ForeignKeys))
+		for _, fk := range table.ForeignKeys {
+			refNames = append(refNames, strings.ToLower(fk.RefTable))
+		}
+		sort.Strings(refNames)
+		for _, refName := range refNames {
+			if refTable, ok := byName[refName]; ok {
+				visit(refTable)
+			}
+		}
+		sorted = append(sorted, table)
+	}
+
+	for _, table := range tables {
+		visit(table)
+	}
+	return sorted
+}
+
+// sortBeansByForeignKeys orders beans like sortTablesByForeignKeys
+func (engine *Engine) sortBeansByForeignKeys(beans []interface{}) ([]interface{}, error) {
+	var (
+		tables  = make([]*schemas.Table, 0, len(beans))
+		byTable = make(map[*schemas.Table][]interface{}, len(beans))
+	)
+	for _, bean := range beans {
+		table, err := engine.tagParser.ParseWithCache(utils.ReflectValue(bean))
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := byTable[table]; !ok {
+			tables = append(tables, table)
+		}
+		byTable[table] = append(byTable[table], bean)
+	}
+
+	var sorted = make([]interface{}, 0, len(beans))
+	for _, table := range sortTablesByForeignKeys(tables) {
+		sorted = append(sorted, byTable[table]...)
+	}
+	return sorted, nil
+}
+
 // dumpTables dump database all table structs and data to w with specify db type
 func (engine *Engine) dumpTables(tables []*schemas.Table, w io.Writer, tp ...schemas.DBType) error {
 	var dstDialect dialects.Dialect
@@ -472,7 +636,7 @@ func (engine *Engine) dumpTables(tables []*schemas.Table, w io.Writer, tp ...sch
 		return err
 	}
 
-	for i, table := range tables {
+	for i, table := range sortTablesByForeignKeys(tables) {
 		tableName := table.Name
 		if dstDialect.URI().Schema != "" {
 			tableName = fmt.Sprintf("%s.%s", dstDialect.URI().Schema, table.Name)
@@ -656,6 +820,14 @@ func (engine *Engine) Omit(columns ...string) *Session {
 	return session.Omit(columns...)
 }
 
+// Returning fills the beans with the given columns, or all the columns if none is given,
+// of the rows affected by the following Insert, Update or Delete
+func (engine *Engine) Returning(columns ...string) *Session {
+	session := engine.NewSession()
+	session.isAutoClose = true
+	return session.Returning(columns...)
+}
+
 // Nullable set null when column is zero-value and nullable for update
 func (engine *Engine) Nullable(columns ...string) *Session {
 	session := engine.NewSession()
@@ -958,6 +1130,28 @@ func (engine *Engine) Sync2(beans ...interface{}) error {
 	return s.Sync2(beans...)
 }
 
+// SyncPartitions synchronizes the partitions of the partitioned tables holding the records
+// in [from, to)
+func (engine *Engine) SyncPartitions(from, to time.Time, beans ...interface{}) error {
+	s := engine.NewSession()
+	defer s.Close()
+	return s.SyncPartitions(from, to, beans...)
+}
+
+// SchemaDiff compares the structs with the database tables and returns a migration plan
+func (engine *Engine) SchemaDiff(beans ...interface{}) (*SchemaDiff, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.SchemaDiff(beans...)
+}
+
+// ApplySchemaDiff executes the SQLs of a migration plan
+func (engine *Engine) ApplySchemaDiff(diff *SchemaDiff) error {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.ApplySchemaDiff(diff)
+}
+
 // CreateTables create tabls according bean
 func (engine *Engine) CreateTables(beans ...interface{}) error {
 	session := engine.NewSession()
@@ -1061,6 +1255,21 @@ func (engine *Engine) InsertOne(bean interface{}) (int64, error) {
 	return session.InsertOne(bean)
 }
 
+// Upsert inserts one bean or a slice of beans, or updates them if the conflictCols,
+// or the primary keys by default, conflict with the existing records
+func (engine *Engine) Upsert(bean interface{}, conflictCols ...string) (int64, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.Upsert(bean, conflictCols...)
+}
+
+// InsertOrUpdate is an alias of Upsert
+func (engine *Engine) InsertOrUpdate(bean interface{}, conflictCols ...string) (int64, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.InsertOrUpdate(bean, conflictCols...)
+}
+
 // Update records, bean's non-empty fields are updated contents,
 // condiBean' non-empty filds are conditions
 // CAUTION:
@@ -1095,6 +1304,21 @@ func (engine *Engine) Exist(bean ...interface{}) (bool, error) {
 	return session.Exist(bean...)
 }
 
+// Export writes the records of the table of bean to w row by row, bean's non-empty
+// fields are conditions.
+func (engine *Engine) Export(w exporters.Writer, bean interface{}) error {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.Export(w, bean)
+}
+
+// ExportQuery writes the rows of a raw sql to w row by row
+func (engine *Engine) ExportQuery(w exporters.Writer, sqlOrArgs ...interface{}) error {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.ExportQuery(w, sqlOrArgs...)
+}
+
 // Find retrieve records from table, condiBeans's non-empty fields
 // are conditions. beans could be []Struct, []*Struct, map[int64]Struct
 // map[int64]*Struct
@@ -1111,6 +1335,13 @@ func (engine *Engine) FindAndCount(rowsSlicePtr interface{}, condiBean ...interf
 	return session.FindAndCount(rowsSlicePtr, condiBean...)
 }
 
+// FindJoined finds the joined rows of the tables joined by beans into the fields of the structs
+func (engine *Engine) FindJoined(rowsSlicePtr interface{}) error {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.FindJoined(rowsSlicePtr)
+}
+
 // Iterate record by record handle records from table, bean's non-empty fields
 // are conditions.
 func (engine *Engine) Iterate(bean interface{}, fun IterFunc) error {
@@ -1175,6 +1406,20 @@ func (engine *Engine) Import(r io.Reader) ([]sql.Result, error) {
 	return session.Import(r)
 }
 
+// ImportData reads the rows of r in format and inserts them into the table of bean
+func (engine *Engine) ImportData(bean interface{}, r io.Reader, format importers.Format) (*ImportResult, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.ImportData(bean, r, format)
+}
+
+// ImportReader inserts the rows read by reader into the table of bean
+func (engine *Engine) ImportReader(bean interface{}, reader importers.Reader) (*ImportResult, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.ImportReader(bean, reader)
+}
+
 // nowTime return current time
 func (engine *Engine) nowTime(col *schemas.Column) (interface{}, time.Time) {
 	t := time.Now()
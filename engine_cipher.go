@@ -0,0 +1,187 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/xormplus/xorm/internal/statements"
+)
+
+// cipherKeyIDSep separates the key id and the ciphertext of an encrypted value
+const cipherKeyIDSep = ":"
+
+// ErrDeterministicCipher represents the cipher encrypts the same plaintexts to the same
+// ciphertexts, i.e. AesEncrypt and DesEncrypt whose IVs are derived from the keys, so it
+// could not encrypt the columns
+var ErrDeterministicCipher = errors.New("the cipher with a fixed IV could not encrypt the columns")
+
+// isDeterministicCipher returns true if the cipher has no random IV, nonce or padding
+func isDeterministicCipher(cipher Cipher) bool {
+	switch t := cipher.(type) {
+	case *AesEncrypt, *DesEncrypt, *TripleDesEncrypt:
+		return true
+	case *RsaEncrypt:
+		return t.EncryptMode == RSA_PRIKEY_ENCRYPT_MODE
+	}
+	return false
+}
+
+// cipherKey represents the versions of a named key, the current one encrypts the values
+// and all of them decrypt the values
+type cipherKey struct {
+	currentID string
+	ciphers   map[string]Cipher
+	indexKey  []byte
+}
+
+// cipherKeyring keeps the ciphers of the columns tagged encrypt by the key names
+type cipherKeyring struct {
+	mutex sync.RWMutex
+	keys  map[string]*cipherKey
+}
+
+var _ statements.Encrypter = &cipherKeyring{}
+
+func newCipherKeyring() *cipherKeyring {
+	return &cipherKeyring{
+		keys: make(map[string]*cipherKey),
+	}
+}
+
+func (k *cipherKeyring) key(keyName string) (*cipherKey, error) {
+	key, ok := k.keys[keyName]
+	if !ok {
+		return nil, fmt.Errorf("cipher key %s is not registered", keyName)
+	}
+	return key, nil
+}
+
+// Encrypt implements statements.Encrypter, the key id of the current cipher is stored as the
+// prefix of the ciphertext
+func (k *cipherKeyring) Encrypt(keyName, plaintext string) (string, error) {
+	k.mutex.RLock()
+	key, err := k.key(keyName)
+	if err != nil {
+		k.mutex.RUnlock()
+		return "", err
+	}
+	keyID, cipher := key.currentID, key.ciphers[key.currentID]
+	k.mutex.RUnlock()
+	if cipher == nil {
+		return "", fmt.Errorf("no cipher of cipher key %s is registered", keyName)
+	}
+
+	encrypted, err := cipher.Encrypt(plaintext)
+	if err != nil {
+		return "", err
+	}
+	return keyID + cipherKeyIDSep + base64.StdEncoding.EncodeToString(encrypted), nil
+}
+
+// Decrypt decrypts a value encrypted by Encrypt with the cipher of its key id
+func (k *cipherKeyring) Decrypt(keyName string, data []byte) ([]byte, error) {
+	s := string(data)
+	idx := strings.Index(s, cipherKeyIDSep)
+	if idx < 0 {
+		return nil, fmt.Errorf("the value of cipher key %s has no key id", keyName)
+	}
+	keyID := s[:idx]
+
+	k.mutex.RLock()
+	key, err := k.key(keyName)
+	if err != nil {
+		k.mutex.RUnlock()
+		return nil, err
+	}
+	cipher, ok := key.ciphers[keyID]
+	k.mutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("key id %s of cipher key %s is not registered", keyID, keyName)
+	}
+	return cipher.Decrypt([]byte(s[idx+len(cipherKeyIDSep):]))
+}
+
+// BlindIndex implements statements.Encrypter, it's the hex of the HMAC-SHA256 of the
+// plaintext by the blind index key
+func (k *cipherKeyring) BlindIndex(keyName, plaintext string) (string, error) {
+	k.mutex.RLock()
+	key, err := k.key(keyName)
+	if err != nil {
+		k.mutex.RUnlock()
+		return "", err
+	}
+	indexKey := key.indexKey
+	k.mutex.RUnlock()
+	if len(indexKey) == 0 {
+		return "", fmt.Errorf("blind index key of cipher key %s is not set", keyName)
+	}
+
+	mac := hmac.New(sha256.New, indexKey)
+	mac.Write([]byte(plaintext))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// RegisterCipher registers the cipher of the key id of the key name used by the tag
+// encrypt(keyname). The last registered key id encrypts the new values and all the
+// registered ones decrypt the stored values, so the keys could be rotated by registering
+// a new key id. The key id should not contain ":".
+//
+// The cipher should encrypt every value with a random IV or nonce, i.e. AesGcmEncrypt,
+// otherwise the equal values could be found by their ciphertexts and the XOR of the
+// plaintexts could be recovered, so AesEncrypt, DesEncrypt, TripleDesEncrypt and
+// RsaEncrypt encrypting by the private key are rejected by ErrDeterministicCipher.
+func (engine *Engine) RegisterCipher(keyName, keyID string, cipher Cipher) error {
+	if keyID == "" || strings.Contains(keyID, cipherKeyIDSep) {
+		return fmt.Errorf("invalid key id %q of cipher key %s", keyID, keyName)
+	}
+	if cipher == nil {
+		return fmt.Errorf("the cipher of key id %s of cipher key %s is nil", keyID, keyName)
+	}
+	if isDeterministicCipher(cipher) {
+		return ErrDeterministicCipher
+	}
+
+	k := engine.ciphers
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	key, ok := k.keys[keyName]
+	if !ok {
+		key = &cipherKey{ciphers: make(map[string]Cipher)}
+		k.keys[keyName] = key
+	}
+	key.ciphers[keyID] = cipher
+	key.currentID = keyID
+	return nil
+}
+
+// SetBlindIndexKey sets the HMAC key of the blind indexes of the columns encrypted by the key
+// name, it should not be changed once the blind indexes are stored
+func (engine *Engine) SetBlindIndexKey(keyName string, indexKey []byte) {
+	k := engine.ciphers
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	key, ok := k.keys[keyName]
+	if !ok {
+		key = &cipherKey{ciphers: make(map[string]Cipher)}
+		k.keys[keyName] = key
+	}
+	key.indexKey = indexKey
+}
+
+// BlindIndex returns the blind index of the value encrypted by the key name, i.e.
+//
+//	index, err := engine.BlindIndex("pii", "a@b.com")
+//	has, err := engine.Where("email_index = ?", index).Get(&user)
+func (engine *Engine) BlindIndex(keyName, value string) (string, error) {
+	return engine.ciphers.BlindIndex(keyName, value)
+}
@@ -6,6 +6,7 @@ package xorm
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/xormplus/xorm/caches"
@@ -19,12 +20,17 @@ import (
 type EngineGroup struct {
 	*Engine
 	subordinates []*Engine
-	policy GroupPolicy
+	policy       GroupPolicy
+
+	health     *groupHealth
+	healthLock sync.RWMutex
+
+	stickyMainWindow time.Duration
 }
 
 // NewEngineGroup creates a new engine group
 func NewEngineGroup(args1 interface{}, args2 interface{}, policies ...GroupPolicy) (*EngineGroup, error) {
-	var eg EngineGroup
+	var eg = EngineGroup{stickyMainWindow: DefaultStickyMainWindow}
 	if len(policies) > 0 {
 		eg.policy = policies[0]
 	} else {
@@ -46,6 +52,7 @@ func NewEngineGroup(args1 interface{}, args2 interface{}, policies ...GroupPolic
 
 		eg.Engine = engines[0]
 		eg.subordinates = engines[1:]
+		eg.SetQueryCacher(eg.Engine.GetQueryCacher())
 		return &eg, nil
 	}
 
@@ -58,6 +65,7 @@ func NewEngineGroup(args1 interface{}, args2 interface{}, policies ...GroupPolic
 		}
 		eg.Engine = main
 		eg.subordinates = subordinates
+		eg.SetQueryCacher(eg.Engine.GetQueryCacher())
 		return &eg, nil
 	}
 	return nil, ErrParamsType
@@ -65,6 +73,8 @@ func NewEngineGroup(args1 interface{}, args2 interface{}, policies ...GroupPolic
 
 // Close the engine
 func (eg *EngineGroup) Close() error {
+	eg.StopHealthCheck()
+
 	err := eg.Engine.Close()
 	if err != nil {
 		return err
@@ -136,6 +146,15 @@ func (eg *EngineGroup) SetDefaultCacher(cacher caches.Cacher) {
 	}
 }
 
+// SetQueryCacher set the query cacher of all the engines, they share the same one so that
+// the results cached by the subordinates are cleared by the writes on the main engine
+func (eg *EngineGroup) SetQueryCacher(cacher caches.QueryCacher) {
+	eg.Engine.SetQueryCacher(cacher)
+	for i := 0; i < len(eg.subordinates); i++ {
+		eg.subordinates[i].SetQueryCacher(cacher)
+	}
+}
+
 // SetLogger set the new logger
 func (eg *EngineGroup) SetLogger(logger interface{}) {
 	eg.Engine.SetLogger(logger)
@@ -151,6 +170,14 @@ func (eg *EngineGroup) AddHook(hook contexts.Hook) {
 	}
 }
 
+// AddRedactRules adds the rules masking the args of all the engines
+func (eg *EngineGroup) AddRedactRules(rules ...log.RedactRule) {
+	eg.Engine.AddRedactRules(rules...)
+	for i := 0; i < len(eg.subordinates); i++ {
+		eg.subordinates[i].AddRedactRules(rules...)
+	}
+}
+
 // SetLogLevel sets the logger level
 func (eg *EngineGroup) SetLogLevel(level log.LogLevel) {
 	eg.Engine.SetLogLevel(level)
@@ -197,6 +224,43 @@ func (eg *EngineGroup) SetQuotePolicy(quotePolicy dialects.QuotePolicy) {
 	}
 }
 
+// RegisterCipher registers the cipher of the key id of the key name to all the engines
+func (eg *EngineGroup) RegisterCipher(keyName, keyID string, cipher Cipher) error {
+	if err := eg.Engine.RegisterCipher(keyName, keyID, cipher); err != nil {
+		return err
+	}
+	for i := 0; i < len(eg.subordinates); i++ {
+		if err := eg.subordinates[i].RegisterCipher(keyName, keyID, cipher); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetBlindIndexKey sets the blind index key of the key name of all the engines
+func (eg *EngineGroup) SetBlindIndexKey(keyName string, indexKey []byte) {
+	eg.Engine.SetBlindIndexKey(keyName, indexKey)
+	for i := 0; i < len(eg.subordinates); i++ {
+		eg.subordinates[i].SetBlindIndexKey(keyName, indexKey)
+	}
+}
+
+// SetSlowQueryHandler sets the callback of the slow queries of all the engines
+func (eg *EngineGroup) SetSlowQueryHandler(handler func(*SlowQuery)) {
+	eg.Engine.SetSlowQueryHandler(handler)
+	for i := 0; i < len(eg.subordinates); i++ {
+		eg.subordinates[i].SetSlowQueryHandler(handler)
+	}
+}
+
+// SetSlowQueryThreshold sets the slow query threshold of all the engines
+func (eg *EngineGroup) SetSlowQueryThreshold(threshold time.Duration) {
+	eg.Engine.SetSlowQueryThreshold(threshold)
+	for i := 0; i < len(eg.subordinates); i++ {
+		eg.subordinates[i].SetSlowQueryThreshold(threshold)
+	}
+}
+
 // SetTableMapper set the table name mapping rule
 func (eg *EngineGroup) SetTableMapper(mapper names.Mapper) {
 	eg.Engine.SetTableMapper(mapper)
@@ -213,19 +277,26 @@ func (eg *EngineGroup) ShowSQL(show ...bool) {
 	}
 }
 
-// Subordinate returns one of the physical databases which is a subordinate according the policy
+// Subordinate returns one of the physical databases which is a subordinate according the policy,
+// or the main engine if there is no healthy subordinate
 func (eg *EngineGroup) Subordinate() *Engine {
-	switch len(eg.subordinates) {
+	subordinates := eg.healthySubordinates()
+	switch len(subordinates) {
 	case 0:
 		return eg.Engine
 	case 1:
-		return eg.subordinates[0]
+		return subordinates[0]
 	}
 	return eg.policy.Subordinate(eg)
 }
 
-// Subordinates returns all the subordinates
+// Subordinates returns all the subordinates except the ones ejected by the health check
 func (eg *EngineGroup) Subordinates() []*Engine {
+	return eg.healthySubordinates()
+}
+
+// AllSubordinates returns all the subordinates including the unhealthy ones
+func (eg *EngineGroup) AllSubordinates() []*Engine {
 	return eg.subordinates
 }
 
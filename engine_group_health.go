@@ -0,0 +1,275 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PostgresLagQuery returns the replication lag in seconds of a postgres standby
+const PostgresLagQuery = "SELECT CASE WHEN pg_is_in_recovery() THEN COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) ELSE 0 END"
+
+// the default values of HealthCheckOptions
+const (
+	DefaultHealthCheckInterval = 5 * time.Second
+	DefaultHealthCheckTimeout  = 2 * time.Second
+)
+
+// HealthCheckOptions represents the options of the health checks of the subordinates
+type HealthCheckOptions struct {
+	// Interval is the interval between two checks, default is DefaultHealthCheckInterval
+	Interval time.Duration
+	// Timeout is the timeout of the ping and the lag query, default is DefaultHealthCheckTimeout
+	Timeout time.Duration
+	// LagQuery is an optional SQL which returns the replication lag of a subordinate in
+	// seconds, i.e. PostgresLagQuery. A NULL lag is treated as 0.
+	LagQuery string
+	// MaxLag ejects the subordinates whose lag is greater than it, 0 means no limit
+	MaxLag time.Duration
+	// FailureThreshold is the number of the consecutive failed checks before a subordinate
+	// is ejected, default is 1. An ejected subordinate comes back after one successful check.
+	FailureThreshold int
+	// OnChange is called when a subordinate is ejected or comes back, it could be nil
+	OnChange func(SubordinateHealth)
+}
+
+func (opts *HealthCheckOptions) withDefaults() *HealthCheckOptions {
+	var o HealthCheckOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Interval <= 0 {
+		o.Interval = DefaultHealthCheckInterval
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultHealthCheckTimeout
+	}
+	if o.FailureThreshold <= 0 {
+		o.FailureThreshold = 1
+	}
+	return &o
+}
+
+// SubordinateHealth represents the health state of a subordinate
+type SubordinateHealth struct {
+	Engine  *Engine
+	Healthy bool
+	// Lag is the replication lag returned by the last successful lag query
+	Lag time.Duration
+	// Failures is the number of the consecutive failed checks
+	Failures  int
+	LastError error
+	LastCheck time.Time
+}
+
+// groupHealth keeps the health state of the subordinates of an EngineGroup
+type groupHealth struct {
+	opts    *HealthCheckOptions
+	states  []SubordinateHealth
+	healthy []*Engine
+	mutex   sync.RWMutex
+
+	// the builtin close is shadowed in this package, so the context and the wait group
+	// are used to stop the background checks
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// StartHealthCheck checks the subordinates once and then periodically in background.
+// The unhealthy subordinates are not returned by Subordinates so that the policies never
+// choose them, and the main engine is used when all of them are unhealthy. A health check
+// started before is stopped.
+func (eg *EngineGroup) StartHealthCheck(opts *HealthCheckOptions) {
+	eg.StopHealthCheck()
+
+	h := &groupHealth{
+		opts:    opts.withDefaults(),
+		states:  make([]SubordinateHealth, len(eg.subordinates)),
+		healthy: eg.subordinates,
+	}
+	h.ctx, h.cancel = context.WithCancel(context.Background())
+	for i, subordinate := range eg.subordinates {
+		h.states[i] = SubordinateHealth{Engine: subordinate, Healthy: true}
+	}
+
+	eg.healthLock.Lock()
+	eg.health = h
+	eg.healthLock.Unlock()
+
+	eg.checkHealth(h)
+	h.wg.Add(1)
+	go eg.runHealthCheck(h)
+}
+
+// StopHealthCheck stops the background health check, all the subordinates will be chosen
+// by the policy again
+func (eg *EngineGroup) StopHealthCheck() {
+	eg.healthLock.Lock()
+	h := eg.health
+	eg.health = nil
+	eg.healthLock.Unlock()
+
+	if h != nil {
+		h.cancel()
+		h.wg.Wait()
+	}
+}
+
+// CheckHealth checks all the subordinates immediately, it does nothing if the health check
+// is not started
+func (eg *EngineGroup) CheckHealth() {
+	if h := eg.getHealth(); h != nil {
+		eg.checkHealth(h)
+	}
+}
+
+// Health returns the health state of all the subordinates, they are all healthy if the
+// health check is not started
+func (eg *EngineGroup) Health() []SubordinateHealth {
+	h := eg.getHealth()
+	if h == nil {
+		var states = make([]SubordinateHealth, 0, len(eg.subordinates))
+		for _, subordinate := range eg.subordinates {
+			states = append(states, SubordinateHealth{Engine: subordinate, Healthy: true})
+		}
+		return states
+	}
+
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	var states = make([]SubordinateHealth, len(h.states))
+	copy(states, h.states)
+	return states
+}
+
+func (eg *EngineGroup) getHealth() *groupHealth {
+	eg.healthLock.RLock()
+	defer eg.healthLock.RUnlock()
+	return eg.health
+}
+
+// healthySubordinates returns the subordinates which are not ejected
+func (eg *EngineGroup) healthySubordinates() []*Engine {
+	h := eg.getHealth()
+	if h == nil {
+		return eg.subordinates
+	}
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.healthy
+}
+
+// isHealthy returns true if the idx-th subordinate is not ejected
+func (eg *EngineGroup) isHealthy(idx int) bool {
+	h := eg.getHealth()
+	if h == nil {
+		return true
+	}
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return idx < len(h.states) && h.states[idx].Healthy
+}
+
+func (eg *EngineGroup) runHealthCheck(h *groupHealth) {
+	defer h.wg.Done()
+
+	ticker := time.NewTicker(h.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-ticker.C:
+			eg.checkHealth(h)
+		}
+	}
+}
+
+func (eg *EngineGroup) checkHealth(h *groupHealth) {
+	var (
+		lags = make([]time.Duration, len(eg.subordinates))
+		errs = make([]error, len(eg.subordinates))
+		wg   sync.WaitGroup
+	)
+	for i, subordinate := range eg.subordinates {
+		wg.Add(1)
+		go func(i int, subordinate *Engine) {
+			defer wg.Done()
+			lags[i], errs[i] = checkSubordinate(h.ctx, subordinate, h.opts)
+		}(i, subordinate)
+	}
+	wg.Wait()
+
+	var changes []int
+	h.mutex.Lock()
+	healthy := make([]*Engine, 0, len(eg.subordinates))
+	for i := range h.states {
+		state := &h.states[i]
+		state.LastCheck = time.Now()
+		state.LastError = errs[i]
+		if errs[i] != nil {
+			state.Failures++
+		} else {
+			state.Failures = 0
+		}
+		if errs[i] == nil || lags[i] > 0 {
+			state.Lag = lags[i]
+		}
+
+		wasHealthy := state.Healthy
+		state.Healthy = state.Failures < h.opts.FailureThreshold
+		if state.Healthy {
+			healthy = append(healthy, state.Engine)
+		}
+		if wasHealthy != state.Healthy {
+			changes = append(changes, i)
+		}
+	}
+	h.healthy = healthy
+	var states = make([]SubordinateHealth, 0, len(changes))
+	for _, i := range changes {
+		states = append(states, h.states[i])
+	}
+	h.mutex.Unlock()
+
+	for j, state := range states {
+		if state.Healthy {
+			eg.Engine.logger.Infof("[EngineGroup] subordinate %d is healthy again", changes[j])
+		} else {
+			eg.Engine.logger.Warnf("[EngineGroup] subordinate %d is ejected: %v", changes[j], state.LastError)
+		}
+		if h.opts.OnChange != nil {
+			h.opts.OnChange(state)
+		}
+	}
+}
+
+// checkSubordinate pings the subordinate and queries its lag if the lag query is given
+func checkSubordinate(ctx context.Context, subordinate *Engine, opts *HealthCheckOptions) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	if err := subordinate.DB().PingContext(ctx); err != nil {
+		return 0, err
+	}
+	if opts.LagQuery == "" {
+		return 0, nil
+	}
+
+	var seconds sql.NullFloat64
+	if err := subordinate.DB().DB.QueryRowContext(ctx, opts.LagQuery).Scan(&seconds); err != nil {
+		return 0, err
+	}
+	lag := time.Duration(seconds.Float64 * float64(time.Second))
+	if opts.MaxLag > 0 && lag > opts.MaxLag {
+		return lag, fmt.Errorf("replication lag %v exceeds %v", lag, opts.MaxLag)
+	}
+	return lag, nil
+}
@@ -27,11 +27,24 @@ func (h GroupPolicyHandler) Subordinate(eg *EngineGroup) *Engine {
 func RandomPolicy() GroupPolicyHandler {
 	var r = rand.New(rand.NewSource(time.Now().UnixNano()))
 	return func(g *EngineGroup) *Engine {
-		return g.Subordinates()[r.Intn(len(g.Subordinates()))]
+		var subordinates = g.Subordinates()
+		if len(subordinates) == 0 {
+			return g.Main()
+		}
+		return subordinates[r.Intn(len(subordinates))]
+	}
+}
+
+// weightIndex returns the index of the subordinate which the idx-th weight belongs to
+func weightIndex(rands []int, idx int, subordinates []*Engine) int {
+	if rands[idx] >= len(subordinates) {
+		return len(subordinates) - 1
 	}
+	return rands[idx]
 }
 
-// WeightRandomPolicy implmentes randomly chose the subordinate of subordinates
+// WeightRandomPolicy implmentes randomly chose the subordinate of subordinates, the weights
+// are the ones of AllSubordinates and the unhealthy subordinates are skipped
 func WeightRandomPolicy(weights []int) GroupPolicyHandler {
 	var rands = make([]int, 0, len(weights))
 	for i := 0; i < len(weights); i++ {
@@ -40,14 +53,24 @@ func WeightRandomPolicy(weights []int) GroupPolicyHandler {
 		}
 	}
 	var r = rand.New(rand.NewSource(time.Now().UnixNano()))
+	var lock sync.Mutex
 
 	return func(g *EngineGroup) *Engine {
-		var subordinates = g.Subordinates()
-		idx := rands[r.Intn(len(rands))]
-		if idx >= len(subordinates) {
-			idx = len(subordinates) - 1
+		var subordinates = g.AllSubordinates()
+		var healthy = make([]int, 0, len(rands))
+		for i := range rands {
+			if g.isHealthy(weightIndex(rands, i, subordinates)) {
+				healthy = append(healthy, i)
+			}
 		}
-		return subordinates[idx]
+		if len(healthy) == 0 {
+			return g.Main()
+		}
+
+		lock.Lock()
+		n := r.Intn(len(healthy))
+		lock.Unlock()
+		return subordinates[weightIndex(rands, healthy[n], subordinates)]
 	}
 }
 
@@ -58,6 +81,10 @@ func RoundRobinPolicy() GroupPolicyHandler {
 	return func(g *EngineGroup) *Engine {
 		var subordinates = g.Subordinates()
 
+		if len(subordinates) == 0 {
+			return g.Main()
+		}
+
 		lock.Lock()
 		defer lock.Unlock()
 		pos++
@@ -69,7 +96,8 @@ func RoundRobinPolicy() GroupPolicyHandler {
 	}
 }
 
-// WeightRoundRobinPolicy returns a group policy handler
+// WeightRoundRobinPolicy returns a group policy handler, the weights are the ones of
+// AllSubordinates and the unhealthy subordinates are skipped
 func WeightRoundRobinPolicy(weights []int) GroupPolicyHandler {
 	var rands = make([]int, 0, len(weights))
 	for i := 0; i < len(weights); i++ {
@@ -81,19 +109,21 @@ func WeightRoundRobinPolicy(weights []int) GroupPolicyHandler {
 	var lock sync.Mutex
 
 	return func(g *EngineGroup) *Engine {
-		var subordinates = g.Subordinates()
+		var subordinates = g.AllSubordinates()
 		lock.Lock()
 		defer lock.Unlock()
-		pos++
-		if pos >= len(rands) {
-			pos = 0
-		}
+		for range rands {
+			pos++
+			if pos >= len(rands) {
+				pos = 0
+			}
 
-		idx := rands[pos]
-		if idx >= len(subordinates) {
-			idx = len(subordinates) - 1
+			idx := weightIndex(rands, pos, subordinates)
+			if g.isHealthy(idx) {
+				return subordinates[idx]
+			}
 		}
-		return subordinates[idx]
+		return g.Main()
 	}
 }
 
@@ -101,6 +131,9 @@ func WeightRoundRobinPolicy(weights []int) GroupPolicyHandler {
 func LeastConnPolicy() GroupPolicyHandler {
 	return func(g *EngineGroup) *Engine {
 		var subordinates = g.Subordinates()
+		if len(subordinates) == 0 {
+			return g.Main()
+		}
 		connections := 0
 		idx := 0
 		for i := 0; i < len(subordinates); i++ {
@@ -0,0 +1,138 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultStickyMainWindow is the default window after a write in which the reads of an
+// EngineGroup session whose context carries a StickyMain go to the main engine
+const DefaultStickyMainWindow = 5 * time.Second
+
+type stickyMainKey struct{}
+
+// StickyMain records the last write of a user, i.e. a web session. When a StickyMain is
+// carried by the context of the sessions of an EngineGroup, the reads go to the main engine
+// instead of the subordinates within the sticky main window after the last write, so that
+// the user always reads their own writes even if the subordinates lag behind.
+//
+//	sticky := xorm.NewStickyMain(lastWriteFromCookie)
+//	ctx := xorm.WithStickyMain(req.Context(), sticky)
+//	eg.Context(ctx).Insert(&user)
+//	eg.Context(ctx).Find(&users) // reads from main
+//	// save sticky.LastWrite() to the cookie for the next request
+type StickyMain struct {
+	lastWrite int64
+}
+
+// NewStickyMain creates a StickyMain with the last write time, it could be zero
+func NewStickyMain(lastWrite time.Time) *StickyMain {
+	var s StickyMain
+	if !lastWrite.IsZero() {
+		s.lastWrite = lastWrite.UnixNano()
+	}
+	return &s
+}
+
+// LastWrite returns the time of the last write, it's zero if nothing is written
+func (s *StickyMain) LastWrite() time.Time {
+	lastWrite := atomic.LoadInt64(&s.lastWrite)
+	if lastWrite == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, lastWrite)
+}
+
+// MarkWrite records a write at now, it's called by the sessions automatically after
+// a successful write or commit
+func (s *StickyMain) MarkWrite() {
+	atomic.StoreInt64(&s.lastWrite, time.Now().UnixNano())
+}
+
+// isSticky returns true if the last write is in the window
+func (s *StickyMain) isSticky(window time.Duration) bool {
+	lastWrite := atomic.LoadInt64(&s.lastWrite)
+	return lastWrite != 0 && time.Since(time.Unix(0, lastWrite)) < window
+}
+
+// WithStickyMain returns a context carrying the StickyMain
+func WithStickyMain(ctx context.Context, s *StickyMain) context.Context {
+	return context.WithValue(ctx, stickyMainKey{}, s)
+}
+
+// StickyMainFromContext returns the StickyMain carried by ctx or nil
+func StickyMainFromContext(ctx context.Context) *StickyMain {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(stickyMainKey{}).(*StickyMain)
+	return s
+}
+
+// SetStickyMainWindow sets the window after a write in which the reads of the sessions
+// carrying a StickyMain go to the main engine, default is DefaultStickyMainWindow
+func (eg *EngineGroup) SetStickyMainWindow(window time.Duration) *EngineGroup {
+	eg.stickyMainWindow = window
+	return eg
+}
+
+// StickyMainWindow returns the sticky main window
+func (eg *EngineGroup) StickyMainWindow() time.Duration {
+	return eg.stickyMainWindow
+}
+
+// sessionRouting decides where the reads of a session go
+type sessionRouting int
+
+const (
+	routeDefault sessionRouting = iota
+	routeMain
+	routeSubordinate
+)
+
+// UseMain sends all the reads of the session to the main engine of the group
+func (session *Session) UseMain() *Session {
+	session.routing = routeMain
+	return session
+}
+
+// UseSubordinate sends all the reads of the session to the subordinates of the group, even
+// if the session is created by the main engine or there is a recent write of its StickyMain.
+// The reads in a transaction always go to the main engine.
+func (session *Session) UseSubordinate() *Session {
+	session.routing = routeSubordinate
+	return session
+}
+
+// readFromSubordinate returns true if the read should go to a subordinate of the group
+func (session *Session) readFromSubordinate() bool {
+	eg := session.engine.engineGroup
+	if eg == nil {
+		return false
+	}
+	switch session.routing {
+	case routeMain:
+		return false
+	case routeSubordinate:
+		return true
+	}
+	if session.sessionType != groupSession {
+		return false
+	}
+	if sticky := StickyMainFromContext(session.ctx); sticky != nil && sticky.isSticky(eg.stickyMainWindow) {
+		return false
+	}
+	return true
+}
+
+// markWrite records the write to the StickyMain carried by the context of the session
+func (session *Session) markWrite() {
+	if sticky := StickyMainFromContext(session.ctx); sticky != nil {
+		sticky.MarkWrite()
+	}
+}
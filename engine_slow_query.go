@@ -0,0 +1,234 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xormplus/xorm/contexts"
+	"github.com/xormplus/xorm/schemas"
+)
+
+// the limits of the EXPLAINs of the slow queries
+const (
+	// SlowQueryExplainTimeout is the timeout of an EXPLAIN
+	SlowQueryExplainTimeout = 5 * time.Second
+	// maxPendingExplains is the max number of the running EXPLAINs, the plans of the
+	// slow queries beyond it are not captured
+	maxPendingExplains = 4
+)
+
+var (
+	// ErrExplainNotSupported represents the dialect has no EXPLAIN for the slow queries
+	ErrExplainNotSupported = errors.New("explain is not supported")
+	// ErrTooManyExplains represents the plan is not captured because of too many running EXPLAINs
+	ErrTooManyExplains = errors.New("too many running explains")
+)
+
+// SlowQuery represents a SQL which took longer than the slow query threshold
+type SlowQuery struct {
+	SQL       string
+	Args      []interface{} // the sensitive args are masked
+	Duration  time.Duration
+	Operation contexts.Operation
+	TableName string
+	SessionID string
+	TxID      string
+	Err       error  // the error of the SQL
+	Plan      string // the plan captured by EXPLAIN, it's empty if PlanErr is not nil
+	PlanErr   error
+}
+
+// slowQueryHook is a contexts.Hook which captures the plans of the slow queries on another
+// connection and reports them to the logger and the handler
+type slowQueryHook struct {
+	engine    *Engine
+	threshold int64 // time.Duration, 0 means disabled
+	explains  chan struct{}
+	wg        sync.WaitGroup
+
+	mutex   sync.RWMutex
+	handler func(*SlowQuery)
+}
+
+var _ contexts.Hook = &slowQueryHook{}
+
+func newSlowQueryHook(engine *Engine) *slowQueryHook {
+	return &slowQueryHook{
+		engine:   engine,
+		explains: make(chan struct{}, maxPendingExplains),
+	}
+}
+
+// explainSQL returns the EXPLAIN of the dialect for sqlStr, it's empty if sqlStr could not
+// be explained
+func explainSQL(dbType schemas.DBType, sqlStr string) (string, error) {
+	fields := strings.Fields(sqlStr)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	switch strings.ToUpper(strings.TrimLeft(fields[0], "(")) {
+	case "SELECT", "INSERT", "UPDATE", "DELETE", "REPLACE", "WITH":
+	default:
+		return "", nil
+	}
+
+	switch dbType {
+	case schemas.MYSQL:
+		return "EXPLAIN FORMAT=JSON " + sqlStr, nil
+	case schemas.POSTGRES:
+		return "EXPLAIN (FORMAT JSON) " + sqlStr, nil
+	case schemas.SQLITE:
+		return "EXPLAIN QUERY PLAN " + sqlStr, nil
+	}
+	return "", ErrExplainNotSupported
+}
+
+// explain runs the EXPLAIN on a connection of the pool other than the one of the query,
+// the plan is the last column of the rows, i.e. the JSON of mysql and postgres and the
+// details of sqlite3
+func (h *slowQueryHook) explain(sqlStr string, args []interface{}) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), SlowQueryExplainTimeout)
+	defer cancel()
+
+	conn, err := h.engine.DB().DB.Conn(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, sqlStr, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	var (
+		lines  []string
+		values = make([]interface{}, len(cols))
+		plan   sql.NullString
+	)
+	for i := range values {
+		values[i] = new(sql.RawBytes)
+	}
+	values[len(values)-1] = &plan
+	for rows.Next() {
+		if err := rows.Scan(values...); err != nil {
+			return "", err
+		}
+		lines = append(lines, plan.String)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// BeforeProcess implements contexts.Hook
+func (h *slowQueryHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	return c.Ctx, nil
+}
+
+// AfterProcess implements contexts.Hook
+func (h *slowQueryHook) AfterProcess(c *contexts.ContextHook) error {
+	threshold := time.Duration(atomic.LoadInt64(&h.threshold))
+	if threshold <= 0 || c.ExecuteTime < threshold {
+		return nil
+	}
+
+	query := &SlowQuery{
+		SQL:       c.SQL,
+		Args:      append([]interface{}(nil), c.Args...),
+		Duration:  c.ExecuteTime,
+		Operation: c.Operation,
+		TableName: c.TableName,
+		SessionID: c.SessionID,
+		TxID:      c.TxID,
+		Err:       c.Err,
+	}
+	explain, err := explainSQL(h.engine.dialect.URI().DBType, c.SQL)
+	if err != nil || explain == "" {
+		query.PlanErr = err
+		h.report(query)
+		return nil
+	}
+
+	select {
+	case h.explains <- struct{}{}:
+	default:
+		query.PlanErr = ErrTooManyExplains
+		h.report(query)
+		return nil
+	}
+	// the EXPLAIN is bound to the real args while the reported ones are masked
+	args := append([]interface{}(nil), c.RawArgs()...)
+	h.wg.Add(1)
+	go func() {
+		defer func() {
+			<-h.explains
+			h.wg.Done()
+		}()
+		query.Plan, query.PlanErr = h.explain(explain, args)
+		h.report(query)
+	}()
+	return nil
+}
+
+// report logs the slow query and passes it to the handler
+func (h *slowQueryHook) report(query *SlowQuery) {
+	var plan = query.Plan
+	if query.PlanErr != nil {
+		plan = fmt.Sprintf("explain failed: %v", query.PlanErr)
+	}
+	if logger := h.engine.logger; logger != nil {
+		logger.Warnf("[SLOW SQL] %s %v - %v\n%s", query.SQL, query.Args, query.Duration, plan)
+	}
+
+	h.mutex.RLock()
+	handler := h.handler
+	h.mutex.RUnlock()
+	if handler != nil {
+		handler(query)
+	}
+}
+
+// wait waits for the running EXPLAINs
+func (h *slowQueryHook) wait() {
+	h.wg.Wait()
+}
+
+func (engine *Engine) slowQueryHook() *slowQueryHook {
+	if engine.slowQuery == nil {
+		engine.slowQuery = newSlowQueryHook(engine)
+		engine.AddHook(engine.slowQuery)
+	}
+	return engine.slowQuery
+}
+
+// SetSlowQueryThreshold logs the SQLs which take longer than threshold as warnings with their
+// plans, the plans are captured by EXPLAIN asynchronously on another connection. 0 disables it.
+func (engine *Engine) SetSlowQueryThreshold(threshold time.Duration) {
+	atomic.StoreInt64(&engine.slowQueryHook().threshold, int64(threshold))
+}
+
+// SetSlowQueryHandler sets the callback of the slow queries, it's called after the plan is
+// captured and may be called from another goroutine
+func (engine *Engine) SetSlowQueryHandler(handler func(*SlowQuery)) {
+	h := engine.slowQueryHook()
+	h.mutex.Lock()
+	h.handler = handler
+	h.mutex.Unlock()
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exporters
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+)
+
+// CSVWriter writes the rows as CSV with a header line of the column names
+type CSVWriter struct {
+	// TimeFormat is the layout of the times, time.RFC3339 by default
+	TimeFormat string
+	// NoHeader omits the header line
+	NoHeader bool
+
+	writer  *csv.Writer
+	columns []Column
+	record  []string
+}
+
+// NewCSVWriter creates a CSVWriter writing to w, comma is the field delimiter which is ','
+// if it's not given
+func NewCSVWriter(w io.Writer, comma ...rune) *CSVWriter {
+	writer := csv.NewWriter(w)
+	if len(comma) > 0 {
+		writer.Comma = comma[0]
+	}
+	return &CSVWriter{
+		TimeFormat: time.RFC3339,
+		writer:     writer,
+	}
+}
+
+// WriteHeader implements Writer
+func (w *CSVWriter) WriteHeader(columns []Column) error {
+	w.columns = columns
+	w.record = make([]string, len(columns))
+	if w.NoHeader {
+		return nil
+	}
+	for i, col := range columns {
+		w.record[i] = col.Name
+	}
+	return w.writer.Write(w.record)
+}
+
+// WriteRow implements Writer
+func (w *CSVWriter) WriteRow(values []interface{}) error {
+	row, err := normalizeRow(w.columns, values)
+	if err != nil {
+		return err
+	}
+	for i, v := range row {
+		w.record[i] = formatValue(v, w.TimeFormat)
+	}
+	return w.writer.Write(w.record)
+}
+
+// Close implements Writer
+func (w *CSVWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
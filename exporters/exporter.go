@@ -0,0 +1,270 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package exporters writes the rows of a query row by row to an io.Writer as CSV,
+// JSON Lines, XLSX or Parquet without holding all of them in memory.
+package exporters
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type represents the type of the values of a column
+type Type int
+
+// enumerate all the column types, the values of the other types are exported as strings
+const (
+	String Type = iota
+	Int
+	Float
+	Bool
+	Time
+	Bytes
+)
+
+// String returns the name of the type
+func (t Type) String() string {
+	switch t {
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case Bool:
+		return "bool"
+	case Time:
+		return "time"
+	case Bytes:
+		return "bytes"
+	}
+	return "string"
+}
+
+// Column represents a column of the exported rows
+type Column struct {
+	Name string
+	Type Type
+}
+
+// Writer writes the rows one by one, WriteHeader should be called once before the rows
+// and Close after them. Close flushes the written data but does not close the underlying
+// io.Writer.
+type Writer interface {
+	WriteHeader(columns []Column) error
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	bytesType     = reflect.TypeOf([]byte{})
+	rawBytesType  = reflect.TypeOf(sql.RawBytes{})
+	nullInt64Type = reflect.TypeOf(sql.NullInt64{})
+	nullInt32Type = reflect.TypeOf(sql.NullInt32{})
+	nullFloatType = reflect.TypeOf(sql.NullFloat64{})
+	nullBoolType  = reflect.TypeOf(sql.NullBool{})
+	nullTimeType  = reflect.TypeOf(sql.NullTime{})
+)
+
+// TypeOf returns the column type of the values of the Go type, i.e. the type of a struct
+// field or the scan type of a column of the database
+func TypeOf(t reflect.Type) Type {
+	if t == nil {
+		return String
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType, nullTimeType:
+		return Time
+	case rawBytesType:
+		return String
+	case bytesType:
+		return Bytes
+	case nullInt64Type, nullInt32Type:
+		return Int
+	case nullFloatType:
+		return Float
+	case nullBoolType:
+		return Bool
+	}
+	if t.ConvertibleTo(timeType) {
+		return Time
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Int
+	case reflect.Float32, reflect.Float64:
+		return Float
+	case reflect.Bool:
+		return Bool
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Bytes
+		}
+	}
+	return String
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// normalize converts a value to the Go type of the column type, which is int64, float64,
+// bool, time.Time, string or []byte, NULL is nil
+func normalize(t Type, value interface{}) (interface{}, error) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+		value = v
+	}
+	if value == nil {
+		return nil, nil
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Type().ConvertibleTo(timeType) && v.Kind() == reflect.Struct {
+		v = v.Convert(timeType)
+	}
+
+	var s string
+	switch v.Kind() {
+	case reflect.String:
+		s = v.String()
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			return nil, fmt.Errorf("unsupported value type %v", v.Type())
+		}
+		if t == Bytes {
+			return append([]byte(nil), v.Bytes()...), nil
+		}
+		s = string(v.Bytes())
+	}
+
+	switch t {
+	case Int:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return v.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return int64(v.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return int64(v.Float()), nil
+		case reflect.Bool:
+			if v.Bool() {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case reflect.String, reflect.Slice:
+			return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		}
+	case Float:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(v.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return float64(v.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return v.Float(), nil
+		case reflect.String, reflect.Slice:
+			return strconv.ParseFloat(strings.TrimSpace(s), 64)
+		}
+	case Bool:
+		switch v.Kind() {
+		case reflect.Bool:
+			return v.Bool(), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return v.Int() != 0, nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return v.Uint() != 0, nil
+		case reflect.String, reflect.Slice:
+			return strconv.ParseBool(strings.TrimSpace(s))
+		}
+	case Time:
+		switch v.Kind() {
+		case reflect.Struct:
+			if v.Type() == timeType {
+				return v.Interface(), nil
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return time.Unix(v.Int(), 0), nil
+		case reflect.String, reflect.Slice:
+			for _, layout := range timeLayouts {
+				if tm, err := time.Parse(layout, strings.TrimSpace(s)); err == nil {
+					return tm, nil
+				}
+			}
+			return nil, fmt.Errorf("unsupported time %s", s)
+		}
+	case Bytes:
+		if v.Kind() == reflect.String {
+			return []byte(s), nil
+		}
+	default:
+		switch v.Kind() {
+		case reflect.String, reflect.Slice:
+			return s, nil
+		}
+		return formatValue(v.Interface(), time.RFC3339Nano), nil
+	}
+	return nil, fmt.Errorf("could not convert %v to %v", v.Type(), t)
+}
+
+// normalizeRow normalizes the values of a row by the column types
+func normalizeRow(columns []Column, values []interface{}) ([]interface{}, error) {
+	if len(values) != len(columns) {
+		return nil, fmt.Errorf("row has %d values but there are %d columns", len(values), len(columns))
+	}
+	var row = make([]interface{}, len(values))
+	for i, value := range values {
+		v, err := normalize(columns[i].Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %v", columns[i].Name, err)
+		}
+		row[i] = v
+	}
+	return row, nil
+}
+
+// formatValue formats a value as text, the times are formatted by timeFormat and the
+// bytes are encoded by base64
+func formatValue(value interface{}, timeFormat string) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(timeFormat)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	}
+	return fmt.Sprint(value)
+}
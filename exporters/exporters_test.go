@@ -0,0 +1,121 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exporters
+
+import (
+	"bytes"
+	"database/sql"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testColumns = []Column{
+	{"id", Int},
+	{"name", String},
+	{"score", Float},
+	{"active", Bool},
+	{"created", Time},
+}
+
+func writeRows(t *testing.T, w Writer, rows ...[]interface{}) {
+	assert.NoError(t, w.WriteHeader(testColumns))
+	for _, row := range rows {
+		assert.NoError(t, w.WriteRow(row))
+	}
+	assert.NoError(t, w.Close())
+}
+
+func TestTypeOf(t *testing.T) {
+	for v, expected := range map[interface{}]Type{
+		int8(1):           Int,
+		uint64(1):         Int,
+		float32(1):        Float,
+		true:              Bool,
+		"":                String,
+		time.Time{}:       Time,
+		sql.NullInt64{}:   Int,
+		sql.NullString{}:  String,
+		sql.NullTime{}:    Time,
+		sql.NullFloat64{}: Float,
+	} {
+		assert.EqualValues(t, expected, TypeOf(reflect.TypeOf(v)), "%T", v)
+	}
+	assert.EqualValues(t, Bytes, TypeOf(reflect.TypeOf([]byte{})))
+	assert.EqualValues(t, String, TypeOf(reflect.TypeOf(sql.RawBytes{})))
+	assert.EqualValues(t, Int, TypeOf(reflect.TypeOf(new(int))))
+	assert.EqualValues(t, String, TypeOf(nil))
+}
+
+func TestNormalize(t *testing.T) {
+	var i = 3
+	for _, c := range []struct {
+		tp       Type
+		value    interface{}
+		expected interface{}
+	}{
+		{Int, []byte("12"), int64(12)},
+		{Int, &i, int64(3)},
+		{Int, (*int)(nil), nil},
+		{Int, sql.NullInt64{Int64: 5, Valid: true}, int64(5)},
+		{Int, sql.NullInt64{}, nil},
+		{Float, "1.5", 1.5},
+		{Bool, int64(1), true},
+		{Bool, []byte("false"), false},
+		{String, []byte("abc"), "abc"},
+		{String, int64(7), "7"},
+		{Bytes, "abc", []byte("abc")},
+		{Time, "2020-01-02 03:04:05", time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+	} {
+		v, err := normalize(c.tp, c.value)
+		assert.NoError(t, err)
+		assert.EqualValues(t, c.expected, v)
+	}
+
+	_, err := normalize(Int, "abc")
+	assert.Error(t, err)
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writeRows(t, NewCSVWriter(&buf),
+		[]interface{}{1, "a,b", 1.5, true, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		[]interface{}{2, nil, nil, nil, nil},
+	)
+	assert.EqualValues(t, "id,name,score,active,created\n"+
+		"1,\"a,b\",1.5,true,2020-01-02T03:04:05Z\n"+
+		"2,,,,\n", buf.String())
+
+	buf.Reset()
+	w := NewCSVWriter(&buf, ';')
+	w.NoHeader = true
+	assert.NoError(t, w.WriteHeader(testColumns[:2]))
+	assert.NoError(t, w.WriteRow([]interface{}{1, "a"}))
+	assert.Error(t, w.WriteRow([]interface{}{1}))
+	assert.NoError(t, w.Close())
+	assert.EqualValues(t, "1;a\n", buf.String())
+}
+
+func TestJSONLinesWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writeRows(t, NewJSONLinesWriter(&buf),
+		[]interface{}{1, "a\"b", 1.5, true, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		[]interface{}{2, nil, math.NaN(), false, nil},
+	)
+	assert.EqualValues(t, `{"id":1,"name":"a\"b","score":1.5,"active":true,"created":"2020-01-02T03:04:05Z"}`+"\n"+
+		`{"id":2,"name":null,"score":null,"active":false,"created":null}`+"\n", buf.String())
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writeRows(t, NewXLSXWriter(&buf, ""),
+		[]interface{}{1, "a", 1.5, true, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+	)
+	// xlsx is a zip file
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("PK")))
+}
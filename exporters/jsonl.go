@@ -0,0 +1,74 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exporters
+
+import (
+	"bufio"
+	"io"
+	"math"
+
+	"github.com/xormplus/xorm/internal/json"
+)
+
+// JSONLinesWriter writes every row as a JSON object in a line, the keys of the objects
+// are in the order of the columns
+type JSONLinesWriter struct {
+	writer *bufio.Writer
+	// keys are the encoded column names followed by :
+	keys    [][]byte
+	columns []Column
+}
+
+// NewJSONLinesWriter creates a JSONLinesWriter writing to w
+func NewJSONLinesWriter(w io.Writer) *JSONLinesWriter {
+	return &JSONLinesWriter{writer: bufio.NewWriter(w)}
+}
+
+// WriteHeader implements Writer
+func (w *JSONLinesWriter) WriteHeader(columns []Column) error {
+	w.columns = columns
+	w.keys = make([][]byte, 0, len(columns))
+	for _, col := range columns {
+		key, err := json.DefaultJSONHandler.Marshal(col.Name)
+		if err != nil {
+			return err
+		}
+		w.keys = append(w.keys, append(key, ':'))
+	}
+	return nil
+}
+
+// WriteRow implements Writer
+func (w *JSONLinesWriter) WriteRow(values []interface{}) error {
+	row, err := normalizeRow(w.columns, values)
+	if err != nil {
+		return err
+	}
+
+	w.writer.WriteByte('{')
+	for i, v := range row {
+		if i > 0 {
+			w.writer.WriteByte(',')
+		}
+		w.writer.Write(w.keys[i])
+		// NaN and Inf are not valid JSON numbers
+		if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			v = nil
+		}
+		bs, err := json.DefaultJSONHandler.Marshal(v)
+		if err != nil {
+			return err
+		}
+		w.writer.Write(bs)
+	}
+	w.writer.WriteByte('}')
+	_, err = w.writer.WriteString("\n")
+	return err
+}
+
+// Close implements Writer
+func (w *JSONLinesWriter) Close() error {
+	return w.writer.Flush()
+}
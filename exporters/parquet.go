@@ -0,0 +1,292 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exporters
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+// the physical types, converted types and encodings of parquet
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetUTF8            = 0
+	parquetTimestampMicros = 10
+
+	parquetOptional = 1
+
+	parquetPlain = 0
+	parquetRLE   = 3
+)
+
+var parquetMagic = []byte("PAR1")
+
+// ParquetWriter writes the rows to a Parquet file, all the columns are optional and the
+// values are stored uncompressed by the plain encoding. The rows are held in memory until
+// a row group is full, then it's written to the underlying io.Writer. The times are stored
+// as microseconds of UTC.
+type ParquetWriter struct {
+	// RowGroupSize is the number of rows of a row group, 10000 by default
+	RowGroupSize int
+
+	w         io.Writer
+	offset    int64
+	columns   []Column
+	rows      [][]interface{}
+	numRows   int64
+	rowGroups []parquetRowGroup
+}
+
+type parquetColumnChunk struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+type parquetRowGroup struct {
+	columns []parquetColumnChunk
+	size    int64
+	numRows int64
+}
+
+// NewParquetWriter creates a ParquetWriter writing to w
+func NewParquetWriter(w io.Writer) *ParquetWriter {
+	return &ParquetWriter{
+		RowGroupSize: 10000,
+		w:            w,
+	}
+}
+
+func (w *ParquetWriter) write(bs []byte) error {
+	n, err := w.w.Write(bs)
+	w.offset += int64(n)
+	return err
+}
+
+// WriteHeader implements Writer
+func (w *ParquetWriter) WriteHeader(columns []Column) error {
+	if len(columns) == 0 {
+		return errors.New("parquet needs at least one column")
+	}
+	w.columns = columns
+	return w.write(parquetMagic)
+}
+
+// WriteRow implements Writer
+func (w *ParquetWriter) WriteRow(values []interface{}) error {
+	row, err := normalizeRow(w.columns, values)
+	if err != nil {
+		return err
+	}
+	w.rows = append(w.rows, row)
+	if w.RowGroupSize > 0 && len(w.rows) >= w.RowGroupSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Close implements Writer, it writes the rows left and the footer
+func (w *ParquetWriter) Close() error {
+	if w.columns == nil {
+		return errors.New("parquet header is not written")
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	meta := w.fileMetaData()
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(meta)))
+	if err := w.write(meta); err != nil {
+		return err
+	}
+	if err := w.write(size[:]); err != nil {
+		return err
+	}
+	return w.write(parquetMagic)
+}
+
+// flush writes the buffered rows as a row group with a data page per column
+func (w *ParquetWriter) flush() error {
+	if len(w.rows) == 0 {
+		return nil
+	}
+	var group = parquetRowGroup{numRows: int64(len(w.rows))}
+	for i, col := range w.columns {
+		page := w.dataPage(i, col.Type)
+		var header thriftWriter
+		header.structBegin(0)
+		header.i32(1, 0) // DATA_PAGE
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.structBegin(5)
+		header.i32(1, int32(len(w.rows)))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.structEnd()
+		header.structEnd()
+
+		chunk := parquetColumnChunk{
+			offset:    w.offset,
+			size:      int64(header.buf.Len() + len(page)),
+			numValues: int64(len(w.rows)),
+		}
+		if err := w.write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if err := w.write(page); err != nil {
+			return err
+		}
+		group.columns = append(group.columns, chunk)
+		group.size += chunk.size
+	}
+	w.rowGroups = append(w.rowGroups, group)
+	w.numRows += group.numRows
+	w.rows = w.rows[:0]
+	return nil
+}
+
+// dataPage encodes the definition levels and the values of a column of the buffered rows
+func (w *ParquetWriter) dataPage(idx int, tp Type) []byte {
+	var (
+		page    bytes.Buffer
+		defined = make([]bool, len(w.rows))
+		values  bytes.Buffer
+		bools   []bool
+	)
+	for i, row := range w.rows {
+		v := row[idx]
+		if v == nil {
+			continue
+		}
+		defined[i] = true
+		switch tp {
+		case Int:
+			binary.Write(&values, binary.LittleEndian, v.(int64))
+		case Float:
+			binary.Write(&values, binary.LittleEndian, math.Float64bits(v.(float64)))
+		case Bool:
+			bools = append(bools, v.(bool))
+		case Time:
+			t := v.(time.Time)
+			binary.Write(&values, binary.LittleEndian, t.Unix()*1e6+int64(t.Nanosecond()/1e3))
+		case Bytes:
+			binary.Write(&values, binary.LittleEndian, uint32(len(v.([]byte))))
+			values.Write(v.([]byte))
+		default:
+			binary.Write(&values, binary.LittleEndian, uint32(len(v.(string))))
+			values.WriteString(v.(string))
+		}
+	}
+
+	// the definition levels are a bit-packed run of the RLE hybrid encoding with bit width 1
+	levels := packBits(defined)
+	var levelsBuf bytes.Buffer
+	var header [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(header[:], uint64(len(levels))<<1|1)
+	levelsBuf.Write(header[:n])
+	levelsBuf.Write(levels)
+
+	binary.Write(&page, binary.LittleEndian, uint32(levelsBuf.Len()))
+	page.Write(levelsBuf.Bytes())
+	if tp == Bool {
+		page.Write(packBits(bools))
+	} else {
+		page.Write(values.Bytes())
+	}
+	return page.Bytes()
+}
+
+// packBits packs the bits LSB first into bytes
+func packBits(bits []bool) []byte {
+	var bs = make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b {
+			bs[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return bs
+}
+
+// parquetType returns the physical type and the converted type of a column type, the
+// converted type is -1 if there is none
+func parquetType(tp Type) (int32, int32) {
+	switch tp {
+	case Int:
+		return parquetInt64, -1
+	case Float:
+		return parquetDouble, -1
+	case Bool:
+		return parquetBoolean, -1
+	case Time:
+		return parquetInt64, parquetTimestampMicros
+	case Bytes:
+		return parquetByteArray, -1
+	}
+	return parquetByteArray, parquetUTF8
+}
+
+// fileMetaData encodes the FileMetaData of the footer
+func (w *ParquetWriter) fileMetaData() []byte {
+	var t thriftWriter
+	t.structBegin(0)
+	t.i32(1, 1)
+
+	t.listBegin(2, thriftStruct, len(w.columns)+1)
+	t.structBegin(0)
+	t.str(4, "schema")
+	t.i32(5, int32(len(w.columns)))
+	t.structEnd()
+	for _, col := range w.columns {
+		physical, converted := parquetType(col.Type)
+		t.structBegin(0)
+		t.i32(1, physical)
+		t.i32(3, parquetOptional)
+		t.str(4, col.Name)
+		if converted >= 0 {
+			t.i32(6, converted)
+		}
+		t.structEnd()
+	}
+
+	t.i64(3, w.numRows)
+
+	t.listBegin(4, thriftStruct, len(w.rowGroups))
+	for _, group := range w.rowGroups {
+		t.structBegin(0)
+		t.listBegin(1, thriftStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			physical, _ := parquetType(w.columns[i].Type)
+			t.structBegin(0)
+			t.i64(2, chunk.offset)
+			t.structBegin(3)
+			t.i32(1, physical)
+			t.i32List(2, parquetPlain, parquetRLE)
+			t.strList(3, w.columns[i].Name)
+			t.i32(4, 0) // UNCOMPRESSED
+			t.i64(5, chunk.numValues)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.i64(2, group.size)
+		t.i64(3, group.numRows)
+		t.structEnd()
+	}
+
+	t.str(6, "xorm")
+	t.structEnd()
+	return t.buf.Bytes()
+}
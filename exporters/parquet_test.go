@@ -0,0 +1,181 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exporters
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// thriftReader decodes the thrift compact protocol into maps of the field ids
+type thriftReader struct {
+	bs  []byte
+	pos int
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.bs[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(tp byte) interface{} {
+	switch tp {
+	case 1:
+		return true
+	case 2:
+		return false
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.varint())
+		r.pos += n
+		return string(r.bs[r.pos-n : r.pos])
+	case thriftList:
+		header := r.bs[r.pos]
+		r.pos++
+		size, elemType := int(header>>4), header&0x0f
+		if size == 15 {
+			size = int(r.varint())
+		}
+		var list = make([]interface{}, 0, size)
+		for i := 0; i < size; i++ {
+			list = append(list, r.value(elemType))
+		}
+		return list
+	case thriftStruct:
+		return r.structValue()
+	}
+	panic("unsupported thrift type")
+}
+
+func (r *thriftReader) structValue() map[int64]interface{} {
+	var (
+		m      = make(map[int64]interface{})
+		lastID int64
+	)
+	for {
+		header := r.bs[r.pos]
+		r.pos++
+		if header == 0 {
+			return m
+		}
+		if delta := int64(header >> 4); delta > 0 {
+			lastID += delta
+		} else {
+			lastID = r.zigzag()
+		}
+		m[lastID] = r.value(header & 0x0f)
+	}
+}
+
+func TestParquetWriter(t *testing.T) {
+	var (
+		buf     bytes.Buffer
+		w       = NewParquetWriter(&buf)
+		created = time.Date(2020, 1, 2, 3, 4, 5, 6000, time.UTC)
+	)
+	w.RowGroupSize = 2
+	writeRows(t, w,
+		[]interface{}{1, "a", 1.5, true, created},
+		[]interface{}{2, nil, nil, false, nil},
+		[]interface{}{3, "c", -2, nil, created},
+	)
+
+	bs := buf.Bytes()
+	assert.EqualValues(t, "PAR1", bs[:4])
+	assert.EqualValues(t, "PAR1", bs[len(bs)-4:])
+	size := int(binary.LittleEndian.Uint32(bs[len(bs)-8:]))
+	footer := thriftReader{bs: bs[len(bs)-8-size : len(bs)-8]}
+	meta := footer.structValue()
+	assert.EqualValues(t, size, footer.pos)
+
+	assert.EqualValues(t, 1, meta[1])
+	assert.EqualValues(t, 3, meta[3])
+	assert.EqualValues(t, "xorm", meta[6])
+
+	schema := meta[2].([]interface{})
+	assert.Len(t, schema, 6)
+	assert.EqualValues(t, "schema", schema[0].(map[int64]interface{})[4])
+	assert.EqualValues(t, 5, schema[0].(map[int64]interface{})[5])
+	for i, col := range testColumns {
+		elem := schema[i+1].(map[int64]interface{})
+		physical, converted := parquetType(col.Type)
+		assert.EqualValues(t, col.Name, elem[4])
+		assert.EqualValues(t, physical, elem[1])
+		assert.EqualValues(t, parquetOptional, elem[3])
+		if converted >= 0 {
+			assert.EqualValues(t, converted, elem[6])
+		}
+	}
+
+	rowGroups := meta[4].([]interface{})
+	if !assert.Len(t, rowGroups, 2) {
+		return
+	}
+	assert.EqualValues(t, 2, rowGroups[0].(map[int64]interface{})[3])
+	assert.EqualValues(t, 1, rowGroups[1].(map[int64]interface{})[3])
+
+	// read the values of the columns of the first row group
+	var expected = [][]interface{}{
+		{int64(1), int64(2)},
+		{"a", nil},
+		{1.5, nil},
+		{true, false},
+		{created.UnixNano() / 1e3, nil},
+	}
+	for i, chunk := range rowGroups[0].(map[int64]interface{})[1].([]interface{}) {
+		colMeta := chunk.(map[int64]interface{})[3].(map[int64]interface{})
+		assert.EqualValues(t, []interface{}{testColumns[i].Name}, colMeta[3])
+		assert.EqualValues(t, 2, colMeta[5])
+
+		offset := int(colMeta[9].(int64))
+		reader := thriftReader{bs: bs[offset:]}
+		pageHeader := reader.structValue()
+		assert.EqualValues(t, 0, pageHeader[1])
+		assert.EqualValues(t, 2, pageHeader[5].(map[int64]interface{})[1])
+		assert.EqualValues(t, colMeta[7], reader.pos+int(pageHeader[3].(int64)))
+
+		page := bs[offset+reader.pos : offset+reader.pos+int(pageHeader[3].(int64))]
+		levelsSize := int(binary.LittleEndian.Uint32(page))
+		// a bit-packed run of a group
+		assert.EqualValues(t, 3, page[4])
+		levels := page[5]
+		values := page[4+levelsSize:]
+
+		var actual []interface{}
+		for j := 0; j < 2; j++ {
+			if levels&(1<<uint(j)) == 0 {
+				actual = append(actual, nil)
+				continue
+			}
+			switch testColumns[i].Type {
+			case Int, Time:
+				actual = append(actual, int64(binary.LittleEndian.Uint64(values)))
+				values = values[8:]
+			case Float:
+				actual = append(actual, math.Float64frombits(binary.LittleEndian.Uint64(values)))
+				values = values[8:]
+			case Bool:
+				actual = append(actual, values[0]&(1<<uint(j)) != 0)
+			case String:
+				n := binary.LittleEndian.Uint32(values)
+				actual = append(actual, string(values[4:4+n]))
+				values = values[4+n:]
+			}
+		}
+		assert.EqualValues(t, expected[i], actual, testColumns[i].Name)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exporters
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// the types of the thrift compact protocol
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the structs of the parquet metadata by the thrift compact protocol
+type thriftWriter struct {
+	buf     bytes.Buffer
+	lastID  int16
+	lastIDs []int16
+}
+
+func (w *thriftWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	w.buf.Write(b[:n])
+}
+
+func (w *thriftWriter) zigzag(v int64) {
+	w.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (w *thriftWriter) fieldHeader(id int16, tp byte) {
+	if delta := id - w.lastID; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | tp)
+	} else {
+		w.buf.WriteByte(tp)
+		w.zigzag(int64(id))
+	}
+	w.lastID = id
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.fieldHeader(id, thriftI32)
+	w.zigzag(int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.fieldHeader(id, thriftI64)
+	w.zigzag(v)
+}
+
+func (w *thriftWriter) binaryValue(v []byte) {
+	w.varint(uint64(len(v)))
+	w.buf.Write(v)
+}
+
+func (w *thriftWriter) str(id int16, v string) {
+	w.fieldHeader(id, thriftBinary)
+	w.binaryValue([]byte(v))
+}
+
+func (w *thriftWriter) listBegin(id int16, elemType byte, size int) {
+	w.fieldHeader(id, thriftList)
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		w.buf.WriteByte(0xf0 | elemType)
+		w.varint(uint64(size))
+	}
+}
+
+// i32List writes a list field of i32 values
+func (w *thriftWriter) i32List(id int16, values ...int32) {
+	w.listBegin(id, thriftI32, len(values))
+	for _, v := range values {
+		w.zigzag(int64(v))
+	}
+}
+
+// strList writes a list field of strings
+func (w *thriftWriter) strList(id int16, values ...string) {
+	w.listBegin(id, thriftBinary, len(values))
+	for _, v := range values {
+		w.binaryValue([]byte(v))
+	}
+}
+
+// structBegin begins a struct field, or an element of a list of structs if id is 0
+func (w *thriftWriter) structBegin(id int16) {
+	if id > 0 {
+		w.fieldHeader(id, thriftStruct)
+	}
+	w.lastIDs = append(w.lastIDs, w.lastID)
+	w.lastID = 0
+}
+
+// structEnd ends a struct with the stop field
+func (w *thriftWriter) structEnd() {
+	w.buf.WriteByte(0)
+	w.lastID = w.lastIDs[len(w.lastIDs)-1]
+	w.lastIDs = w.lastIDs[:len(w.lastIDs)-1]
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exporters
+
+import (
+	"io"
+
+	"github.com/tealeg/xlsx"
+)
+
+// XLSXWriter writes the rows to a sheet of an XLSX file with a header row of the column
+// names, every row is flushed to the underlying io.Writer once it's written
+type XLSXWriter struct {
+	// TimeFormat is the layout of the times, "2006-01-02 15:04:05" by default
+	TimeFormat string
+
+	builder *xlsx.StreamFileBuilder
+	file    *xlsx.StreamFile
+	sheet   string
+	columns []Column
+	cells   []string
+}
+
+// NewXLSXWriter creates a XLSXWriter writing the sheet to w, the sheet is Sheet1 if it's empty
+func NewXLSXWriter(w io.Writer, sheet string) *XLSXWriter {
+	if sheet == "" {
+		sheet = "Sheet1"
+	}
+	return &XLSXWriter{
+		TimeFormat: "2006-01-02 15:04:05",
+		builder:    xlsx.NewStreamFileBuilder(w),
+		sheet:      sheet,
+	}
+}
+
+// WriteHeader implements Writer
+func (w *XLSXWriter) WriteHeader(columns []Column) error {
+	w.columns = columns
+	w.cells = make([]string, len(columns))
+	var headers = make([]string, 0, len(columns))
+	for _, col := range columns {
+		headers = append(headers, col.Name)
+	}
+	if err := w.builder.AddSheet(w.sheet, headers, nil); err != nil {
+		return err
+	}
+	file, err := w.builder.Build()
+	if err != nil {
+		return err
+	}
+	w.file = file
+	return nil
+}
+
+// WriteRow implements Writer
+func (w *XLSXWriter) WriteRow(values []interface{}) error {
+	row, err := normalizeRow(w.columns, values)
+	if err != nil {
+		return err
+	}
+	for i, v := range row {
+		w.cells[i] = formatValue(v, w.TimeFormat)
+	}
+	return w.file.Write(w.cells)
+}
+
+// Close implements Writer
+func (w *XLSXWriter) Close() error {
+	if w.file == nil {
+		if err := w.WriteHeader(nil); err != nil {
+			return err
+		}
+	}
+	return w.file.Close()
+}
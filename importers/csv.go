@@ -0,0 +1,65 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importers
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// CSVReader reads the rows of CSV whose first line is the column names
+type CSVReader struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+// NewCSVReader creates a CSVReader reading from r, comma is the field delimiter which is ','
+// if it's not given
+func NewCSVReader(r io.Reader, comma ...rune) *CSVReader {
+	reader := csv.NewReader(r)
+	if len(comma) > 0 {
+		reader.Comma = comma[0]
+	}
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	return &CSVReader{reader: reader}
+}
+
+// Read implements Reader
+func (r *CSVReader) Read() (*Record, error) {
+	if r.columns == nil {
+		header, err := r.reader.Read()
+		if err == io.EOF {
+			return nil, errors.New("csv has no header")
+		} else if err != nil {
+			return nil, err
+		}
+		r.columns = make([]string, 0, len(header))
+		for i, name := range header {
+			// the byte order mark written by Excel
+			if i == 0 {
+				name = strings.TrimPrefix(name, "\ufeff")
+			}
+			r.columns = append(r.columns, strings.TrimSpace(name))
+		}
+	}
+
+	values, err := r.reader.Read()
+	if parseErr, ok := err.(*csv.ParseError); ok {
+		return nil, &RowError{Line: parseErr.StartLine, Err: parseErr.Err}
+	} else if err != nil {
+		return nil, err
+	}
+	line, _ := r.reader.FieldPos(0)
+	var record = Record{Line: line, Values: make(map[string]string, len(values))}
+	for i, v := range values {
+		if i < len(r.columns) {
+			record.Values[r.columns[i]] = v
+		}
+	}
+	return &record, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package importers reads the rows of CSV, JSON Lines or XLSX data one by one as the
+// texts of their columns, which are mapped to the fields of the beans by ImportData.
+package importers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format represents the format of the imported data
+type Format int
+
+// enumerate all the formats
+const (
+	CSV Format = iota
+	JSONLines
+	XLSX
+)
+
+// String returns the name of the format
+func (f Format) String() string {
+	switch f {
+	case CSV:
+		return "csv"
+	case JSONLines:
+		return "jsonl"
+	case XLSX:
+		return "xlsx"
+	}
+	return fmt.Sprintf("format(%d)", int(f))
+}
+
+// ParseFormat parses the name of a format, which could be the extension of a file
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+	case "csv":
+		return CSV, nil
+	case "jsonl", "json", "ndjson":
+		return JSONLines, nil
+	case "xlsx":
+		return XLSX, nil
+	}
+	return 0, fmt.Errorf("unsupported import format %s", s)
+}
+
+// Record represents a row read by a Reader
+type Record struct {
+	// Line is the line of the row, which is the row number of a sheet for XLSX
+	Line int
+	// Values are the texts of the columns by the names, a NULL column is absent
+	Values map[string]string
+}
+
+// RowError represents an error of a row, the rows after it could still be read
+type RowError struct {
+	Line int
+	Err  error
+}
+
+// Error implements error
+func (err *RowError) Error() string {
+	return fmt.Sprintf("line %d: %v", err.Line, err.Err)
+}
+
+// Reader reads the rows one by one, io.EOF is returned when there is no more row and
+// a *RowError is returned for a malformed row
+type Reader interface {
+	Read() (*Record, error)
+}
+
+// NewReader creates a Reader of the format reading from r
+func NewReader(r io.Reader, format Format) (Reader, error) {
+	switch format {
+	case CSV:
+		return NewCSVReader(r), nil
+	case JSONLines:
+		return NewJSONLinesReader(r), nil
+	case XLSX:
+		return NewXLSXReader(r, "")
+	}
+	return nil, fmt.Errorf("unsupported import format %v", format)
+}
@@ -0,0 +1,112 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importers
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tealeg/xlsx"
+)
+
+func readAll(t *testing.T, r Reader) []*Record {
+	var records []*Record
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records
+		}
+		if !assert.NoError(t, err) {
+			return records
+		}
+		records = append(records, record)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for s, expected := range map[string]Format{
+		"csv":    CSV,
+		".CSV":   CSV,
+		"jsonl":  JSONLines,
+		"ndjson": JSONLines,
+		".xlsx":  XLSX,
+	} {
+		format, err := ParseFormat(s)
+		assert.NoError(t, err)
+		assert.EqualValues(t, expected, format)
+	}
+	_, err := ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestCSVReader(t *testing.T) {
+	records := readAll(t, NewCSVReader(strings.NewReader("\ufeffid, name\n1,\"a\nb\"\n2,\n")))
+	assert.EqualValues(t, []*Record{
+		{Line: 2, Values: map[string]string{"id": "1", "name": "a\nb"}},
+		{Line: 4, Values: map[string]string{"id": "2", "name": ""}},
+	}, records)
+
+	_, err := NewCSVReader(strings.NewReader("")).Read()
+	assert.Error(t, err)
+}
+
+func TestJSONLinesReader(t *testing.T) {
+	records := readAll(t, NewJSONLinesReader(strings.NewReader(
+		`{"id":1,"name":"a\"b","tags":["x"],"score":null,"ok":true}`+"\n\n"+`{"id":2}`)))
+	assert.EqualValues(t, []*Record{
+		{Line: 1, Values: map[string]string{"id": "1", "name": `a"b`, "tags": `["x"]`, "ok": "true"}},
+		{Line: 3, Values: map[string]string{"id": "2"}},
+	}, records)
+
+	// the rows after a malformed one are read
+	r := NewJSONLinesReader(strings.NewReader("{\"id\":1}\n[1]\n{\"id\":3}"))
+	_, err := r.Read()
+	assert.NoError(t, err)
+	_, err = r.Read()
+	if assert.IsType(t, &RowError{}, err) {
+		assert.EqualValues(t, 2, err.(*RowError).Line)
+	}
+	record, err := r.Read()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, record.Line)
+}
+
+func TestXLSXReader(t *testing.T) {
+	file := xlsx.NewFile()
+	sheet, err := file.AddSheet("users")
+	assert.NoError(t, err)
+	header := sheet.AddRow()
+	header.AddCell().SetString("id")
+	header.AddCell().SetString("name")
+	header.AddCell().SetString("created")
+	header.AddCell().SetString("active")
+	row := sheet.AddRow()
+	row.AddCell().SetInt(1)
+	row.AddCell().SetString("a")
+	row.AddCell().SetDateTime(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	row.AddCell().SetBool(true)
+	sheet.AddRow()
+	row = sheet.AddRow()
+	row.AddCell().SetInt(2)
+
+	var buf bytes.Buffer
+	assert.NoError(t, file.Write(&buf))
+
+	reader, err := NewXLSXReader(&buf, "users")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.EqualValues(t, []*Record{
+		{Line: 2, Values: map[string]string{"id": "1", "name": "a", "created": "2020-01-02 03:04:05", "active": "true"}},
+		{Line: 4, Values: map[string]string{"id": "2"}},
+	}, readAll(t, reader))
+
+	_, err = NewXLSXReader(bytes.NewReader(buf.Bytes()), "none")
+	assert.Error(t, err)
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// JSONLinesReader reads a JSON object in every line as a row, the strings are read as
+// their texts and the other values as JSON, the blank lines are skipped
+type JSONLinesReader struct {
+	reader *bufio.Reader
+	line   int
+}
+
+// NewJSONLinesReader creates a JSONLinesReader reading from r
+func NewJSONLinesReader(r io.Reader) *JSONLinesReader {
+	return &JSONLinesReader{reader: bufio.NewReader(r)}
+}
+
+// Read implements Reader
+func (r *JSONLinesReader) Read() (*Record, error) {
+	for {
+		bs, err := r.reader.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(bs) == 0) {
+			return nil, err
+		}
+		r.line++
+		bs = bytes.TrimSpace(bs)
+		if len(bs) == 0 {
+			continue
+		}
+
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(bs, &object); err != nil {
+			return nil, &RowError{Line: r.line, Err: err}
+		}
+		var record = Record{Line: r.line, Values: make(map[string]string, len(object))}
+		for name, raw := range object {
+			switch {
+			case bytes.Equal(raw, []byte("null")):
+			case len(raw) > 0 && raw[0] == '"':
+				var s string
+				if err := json.Unmarshal(raw, &s); err != nil {
+					return nil, &RowError{Line: r.line, Err: err}
+				}
+				record.Values[name] = s
+			default:
+				record.Values[name] = string(raw)
+			}
+		}
+		return &record, nil
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package importers
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/tealeg/xlsx"
+)
+
+// XLSXReader reads the rows of a sheet of an XLSX file whose first row is the column
+// names, the file is read into memory since it's a zip archive. The dates are read as
+// "2006-01-02 15:04:05" and the empty cells are NULL.
+type XLSXReader struct {
+	file    *xlsx.File
+	sheet   *xlsx.Sheet
+	columns []string
+	row     int
+}
+
+// NewXLSXReader creates a XLSXReader reading the sheet from r, the first sheet is read if
+// sheet is empty
+func NewXLSXReader(r io.Reader, sheet string) (*XLSXReader, error) {
+	bs, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	file, err := xlsx.OpenBinary(bs)
+	if err != nil {
+		return nil, err
+	}
+
+	var reader = XLSXReader{file: file}
+	if sheet == "" {
+		if len(file.Sheets) == 0 {
+			return nil, fmt.Errorf("xlsx has no sheet")
+		}
+		reader.sheet = file.Sheets[0]
+	} else if reader.sheet = file.Sheet[sheet]; reader.sheet == nil {
+		return nil, fmt.Errorf("sheet %s is not found", sheet)
+	}
+	if len(reader.sheet.Rows) == 0 {
+		return nil, fmt.Errorf("sheet %s has no header", reader.sheet.Name)
+	}
+	for _, cell := range reader.sheet.Rows[0].Cells {
+		reader.columns = append(reader.columns, strings.TrimSpace(cell.Value))
+	}
+	reader.row = 1
+	return &reader, nil
+}
+
+// Read implements Reader
+func (r *XLSXReader) Read() (*Record, error) {
+	for ; r.row < len(r.sheet.Rows); r.row++ {
+		row := r.sheet.Rows[r.row]
+		if row == nil {
+			continue
+		}
+		var record = Record{Line: r.row + 1, Values: make(map[string]string, len(row.Cells))}
+		for i, cell := range row.Cells {
+			if i >= len(r.columns) || cell == nil || cell.Value == "" {
+				continue
+			}
+			v, err := r.cellValue(cell)
+			if err != nil {
+				r.row++
+				return nil, &RowError{Line: record.Line, Err: err}
+			}
+			record.Values[r.columns[i]] = v
+		}
+		if len(record.Values) == 0 {
+			continue
+		}
+		r.row++
+		return &record, nil
+	}
+	return nil, io.EOF
+}
+
+func (r *XLSXReader) cellValue(cell *xlsx.Cell) (string, error) {
+	switch cell.Type() {
+	case xlsx.CellTypeBool:
+		if cell.Bool() {
+			return "true", nil
+		}
+		return "false", nil
+	case xlsx.CellTypeNumeric, xlsx.CellTypeDate:
+		if isDateFormat(cell.GetNumberFormat()) {
+			t, err := cell.GetTime(r.file.Date1904)
+			if err != nil {
+				return "", err
+			}
+			return t.Format("2006-01-02 15:04:05"), nil
+		}
+	}
+	return cell.Value, nil
+}
+
+// isDateFormat returns true if the number format of a cell is a date or a time
+func isDateFormat(format string) bool {
+	var inQuote, inBracket bool
+	for _, c := range strings.ToLower(format) {
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '[':
+			inBracket = true
+		case c == ']':
+			inBracket = false
+		case inBracket:
+		case strings.ContainsRune("ymdhs", c):
+			return true
+		}
+	}
+	return false
+}
@@ -179,3 +179,54 @@ func TestCacheGet(t *testing.T) {
 
 	testEngine.SetDefaultCacher(oldCacher)
 }
+
+func TestQueryCache(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type QueryCache struct {
+		Id   int64
+		Name string
+	}
+
+	assert.NoError(t, testEngine.Sync2(new(QueryCache)))
+
+	_, err := testEngine.Insert(&QueryCache{Name: "lunny"})
+	assert.NoError(t, err)
+
+	tableName := testEngine.TableName("query_cache", true)
+	query := "select * from " + tableName + " where name = ?"
+	records, err := testEngine.CacheFor(time.Minute, tableName).QueryString(query, "lunny")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(records))
+
+	// a write without xorm is not seen until the cache expires
+	sess := testEngine.NewSession()
+	_, err = sess.DB().Exec("DELETE FROM " + tableName)
+	assert.NoError(t, err)
+	sess.Close()
+	records, err = testEngine.CacheFor(time.Minute, tableName).QueryString(query, "lunny")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(records))
+
+	// a write by xorm clears the cached results
+	_, err = testEngine.Insert(&QueryCache{Name: "xlw"})
+	assert.NoError(t, err)
+	records, err = testEngine.CacheFor(time.Minute, tableName).QueryString(query, "lunny")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, len(records))
+
+	results, err := testEngine.CacheFor(time.Minute, tableName).QueryInterface("select * from " + tableName)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(results))
+
+	session := testEngine.NewSession()
+	defer session.Close()
+	assert.NoError(t, session.Begin())
+	_, err = session.Exec("DELETE FROM " + tableName)
+	assert.NoError(t, err)
+	assert.NoError(t, session.Commit())
+
+	results, err = testEngine.CacheFor(time.Minute, tableName).QueryInterface("select * from " + tableName)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, len(results))
+}
@@ -5,7 +5,10 @@
 package integrations
 
 import (
+	"context"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/xormplus/xorm"
 	"github.com/xormplus/xorm/log"
@@ -33,3 +36,113 @@ func TestEngineGroup(t *testing.T) {
 	eg.SetLogLevel(log.LOG_INFO)
 	eg.ShowSQL(true)
 }
+
+func TestEngineGroupHealthCheck(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	main := testEngine.(*xorm.Engine)
+	healthy, err := xorm.NewEngine(main.DriverName(), main.DataSourceName())
+	assert.NoError(t, err)
+	defer healthy.Close()
+	dead, err := xorm.NewEngine(main.DriverName(), main.DataSourceName())
+	assert.NoError(t, err)
+	assert.NoError(t, dead.Close())
+
+	eg, err := xorm.NewEngineGroup(main, []*xorm.Engine{healthy, dead})
+	assert.NoError(t, err)
+
+	var changes []xorm.SubordinateHealth
+	eg.StartHealthCheck(&xorm.HealthCheckOptions{
+		Interval: time.Hour,
+		OnChange: func(state xorm.SubordinateHealth) {
+			changes = append(changes, state)
+		},
+	})
+	defer eg.StopHealthCheck()
+
+	assert.EqualValues(t, 1, len(changes))
+	assert.True(t, changes[0].Engine == dead)
+	assert.False(t, changes[0].Healthy)
+	assert.Error(t, changes[0].LastError)
+
+	health := eg.Health()
+	assert.EqualValues(t, 2, len(health))
+	assert.True(t, health[0].Healthy)
+	assert.False(t, health[1].Healthy)
+	assert.EqualValues(t, 1, health[1].Failures)
+
+	assert.EqualValues(t, []*xorm.Engine{healthy}, eg.Subordinates())
+	assert.EqualValues(t, 2, len(eg.AllSubordinates()))
+	for i := 0; i < 4; i++ {
+		assert.True(t, eg.Subordinate() == healthy)
+	}
+
+	eg.SetPolicy(xorm.WeightRoundRobinPolicy([]int{1, 5}))
+	for i := 0; i < 4; i++ {
+		assert.True(t, eg.Subordinate() == healthy)
+	}
+
+	eg.StopHealthCheck()
+	assert.EqualValues(t, 2, len(eg.Subordinates()))
+}
+
+func TestEngineGroupStickyMain(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	main := testEngine.(*xorm.Engine)
+	if main.Dialect().URI().DBType != schemas.SQLITE {
+		t.Skip()
+		return
+	}
+
+	// the subordinate is another database so that the reads from it could be told apart
+	subordinate, err := xorm.NewEngine(main.DriverName(), filepath.Join(t.TempDir(), "subordinate.db"))
+	assert.NoError(t, err)
+	defer subordinate.Close()
+
+	type StickyMainUser struct {
+		Id   int64
+		Name string
+	}
+	assert.NoError(t, main.Sync2(new(StickyMainUser)))
+	assert.NoError(t, subordinate.Sync2(new(StickyMainUser)))
+
+	eg, err := xorm.NewEngineGroup(main, []*xorm.Engine{subordinate})
+	assert.NoError(t, err)
+
+	sticky := xorm.NewStickyMain(time.Time{})
+	ctx := xorm.WithStickyMain(context.Background(), sticky)
+
+	cnt, err := eg.Context(ctx).Count(new(StickyMainUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, cnt)
+
+	_, err = eg.Context(ctx).Insert(&StickyMainUser{Name: "lunny"})
+	assert.NoError(t, err)
+	assert.False(t, sticky.LastWrite().IsZero())
+
+	// read from main in the window after the write
+	cnt, err = eg.Context(ctx).Count(new(StickyMainUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	cnt, err = eg.Context(ctx).UseSubordinate().Count(new(StickyMainUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, cnt)
+
+	// the context without StickyMain reads from the subordinate
+	sess := eg.NewSession()
+	defer sess.Close()
+	cnt, err = sess.Count(new(StickyMainUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, cnt)
+
+	cnt, err = sess.UseMain().Count(new(StickyMainUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	eg.SetStickyMainWindow(0)
+	cnt, err = eg.Context(ctx).Count(new(StickyMainUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, cnt)
+}
@@ -8,10 +8,13 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/xormplus/xorm"
+	"github.com/xormplus/xorm/contexts"
+	"github.com/xormplus/xorm/log"
 	"github.com/xormplus/xorm/schemas"
 
 	_ "github.com/denisenkom/go-mssqldb"
@@ -186,3 +189,244 @@ func TestSetSchema(t *testing.T) {
 		assert.EqualValues(t, oldSchema, testEngine.Dialect().URI().Schema)
 	}
 }
+
+type SlowQueryUser struct {
+	Id     int64
+	Name   string `xorm:"index"`
+	Salary int    `xorm:"index sensitive"`
+}
+
+func TestSlowQueryThreshold(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(SlowQueryUser))
+
+	queries := make(chan *xorm.SlowQuery, 16)
+	testEngine.SetSlowQueryHandler(func(query *xorm.SlowQuery) {
+		queries <- query
+	})
+	testEngine.SetSlowQueryThreshold(time.Nanosecond)
+	defer func() {
+		testEngine.SetSlowQueryThreshold(0)
+		testEngine.SetSlowQueryHandler(nil)
+	}()
+
+	var users []SlowQueryUser
+	assert.NoError(t, testEngine.Where("name = ?", "a").Find(&users))
+
+	select {
+	case query := <-queries:
+		assert.Contains(t, query.SQL, "SELECT")
+		assert.EqualValues(t, []interface{}{"a"}, query.Args)
+		assert.True(t, query.Duration > 0)
+		assert.EqualValues(t, "find", query.Operation)
+		assert.EqualValues(t, testEngine.TableName(new(SlowQueryUser), true), query.TableName)
+		switch testEngine.Dialect().URI().DBType {
+		case schemas.SQLITE, schemas.MYSQL, schemas.POSTGRES:
+			assert.NoError(t, query.PlanErr)
+			assert.NotEmpty(t, query.Plan)
+		default:
+			assert.EqualValues(t, xorm.ErrExplainNotSupported, query.PlanErr)
+		}
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "no slow query is reported")
+	}
+
+	// the sensitive args are masked in the report but the EXPLAIN is bound to the real ones
+	assert.NoError(t, testEngine.Where("salary = ?", 1000).Find(&users))
+	select {
+	case query := <-queries:
+		assert.EqualValues(t, []interface{}{log.RedactedValue}, query.Args)
+		switch testEngine.Dialect().URI().DBType {
+		case schemas.SQLITE, schemas.MYSQL, schemas.POSTGRES:
+			assert.NoError(t, query.PlanErr)
+			assert.NotEmpty(t, query.Plan)
+		}
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "no slow query is reported")
+	}
+
+	testEngine.SetSlowQueryThreshold(time.Hour)
+	assert.NoError(t, testEngine.Find(&users))
+	select {
+	case query := <-queries:
+		assert.Fail(t, "unexpected slow query", query.SQL)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+type SensitiveUser struct {
+	Id       int64
+	Name     string
+	Password string `xorm:"sensitive"`
+	ApiToken string
+}
+
+type argsHook struct {
+	args [][]interface{}
+}
+
+func (h *argsHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	return c.Ctx, nil
+}
+
+func (h *argsHook) AfterProcess(c *contexts.ContextHook) error {
+	h.args = append(h.args, c.Args)
+	return nil
+}
+
+func TestSensitiveColumns(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(SensitiveUser))
+	testEngine.AddRedactRules(log.RedactColumns("*_token"))
+
+	hook := &argsHook{}
+	testEngine.AddHook(hook)
+
+	sess := testEngine.NewSession()
+	defer sess.Close()
+	_, err := sess.Insert(&SensitiveUser{Name: "a", Password: "secret", ApiToken: "abc"})
+	assert.NoError(t, err)
+
+	_, args := sess.LastSQL()
+	assert.EqualValues(t, []interface{}{"a", log.RedactedValue, log.RedactedValue}, args)
+	if assert.NotEmpty(t, hook.args) {
+		assert.EqualValues(t, []interface{}{"a", log.RedactedValue, log.RedactedValue}, hook.args[len(hook.args)-1])
+	}
+
+	var user SensitiveUser
+	has, err := testEngine.Where("password = ?", "secret").Get(&user)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "secret", user.Password)
+	assert.EqualValues(t, []interface{}{log.RedactedValue}, hook.args[len(hook.args)-1])
+}
+
+type EncryptedUser struct {
+	Id         int64
+	Email      string `xorm:"encrypt(pii)"`
+	EmailIndex string `xorm:"blindindex(email) index"`
+}
+
+func TestEncryptColumns(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(EncryptedUser))
+	assert.EqualValues(t, xorm.ErrDeterministicCipher, testEngine.RegisterCipher("pii", "v1", &xorm.AesEncrypt{PubKey: "key-v1"}))
+	assert.Error(t, testEngine.RegisterCipher("pii", "v:1", &xorm.AesGcmEncrypt{Key: []byte("0123456789abcdef")}))
+	assert.NoError(t, testEngine.RegisterCipher("pii", "v1", &xorm.AesGcmEncrypt{Key: []byte("0123456789abcdef")}))
+	testEngine.SetBlindIndexKey("pii", []byte("index-key"))
+
+	user := EncryptedUser{Email: "a@b.com"}
+	cnt, err := testEngine.Insert(&user)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	assert.NotEmpty(t, user.EmailIndex)
+
+	results, err := testEngine.Table(new(EncryptedUser)).Cols("email").QueryString()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(results))
+	assert.True(t, strings.HasPrefix(results[0]["email"], "v1:"))
+	assert.NotContains(t, results[0]["email"], "a@b.com")
+
+	var got EncryptedUser
+	has, err := testEngine.Get(&EncryptedUser{Email: "a@b.com"})
+	assert.NoError(t, err)
+	assert.True(t, has)
+
+	index, err := testEngine.BlindIndex("pii", "a@b.com")
+	assert.NoError(t, err)
+	assert.EqualValues(t, user.EmailIndex, index)
+	has, err = testEngine.Where("email_index = ?", index).Get(&got)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "a@b.com", got.Email)
+
+	// rotate the key, the values encrypted by v1 are still readable
+	assert.NoError(t, testEngine.RegisterCipher("pii", "v2", &xorm.AesGcmEncrypt{Key: []byte("fedcba9876543210")}))
+	_, err = testEngine.Insert(&EncryptedUser{Email: "c@d.com"})
+	assert.NoError(t, err)
+
+	var users []EncryptedUser
+	assert.NoError(t, testEngine.Asc("id").Find(&users))
+	assert.EqualValues(t, 2, len(users))
+	assert.EqualValues(t, "a@b.com", users[0].Email)
+	assert.EqualValues(t, "c@d.com", users[1].Email)
+
+	// the equal values are encrypted by random nonces but have the same blind index
+	other := EncryptedUser{Email: "c@d.com"}
+	_, err = testEngine.Insert(&other)
+	assert.NoError(t, err)
+
+	results, err = testEngine.Table(new(EncryptedUser)).Cols("email", "email_index").Asc("id").QueryString()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, len(results))
+	assert.True(t, strings.HasPrefix(results[1]["email"], "v2:"))
+	assert.NotEqual(t, results[1]["email"], results[2]["email"])
+	assert.EqualValues(t, results[1]["email_index"], results[2]["email_index"])
+
+	got = EncryptedUser{Email: "new@b.com"}
+	cnt, err = testEngine.ID(user.Id).Update(&got)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	var updated EncryptedUser
+	has, err = testEngine.Get(&EncryptedUser{Email: "new@b.com"})
+	assert.NoError(t, err)
+	assert.True(t, has)
+	has, err = testEngine.ID(user.Id).Get(&updated)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "new@b.com", updated.Email)
+	assert.EqualValues(t, got.EmailIndex, updated.EmailIndex)
+}
+
+func TestEncryptColumnsMap(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(EncryptedUser))
+	assert.NoError(t, testEngine.RegisterCipher("pii", "v1", &xorm.AesGcmEncrypt{Key: []byte("0123456789abcdef")}))
+	testEngine.SetBlindIndexKey("pii", []byte("index-key"))
+
+	cnt, err := testEngine.Table(new(EncryptedUser)).Insert(map[string]interface{}{
+		"email": "map@b.com",
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	results, err := testEngine.Table(new(EncryptedUser)).Cols("email").QueryString()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(results))
+	assert.True(t, strings.HasPrefix(results[0]["email"], "v1:"))
+
+	var user EncryptedUser
+	has, err := testEngine.Get(&EncryptedUser{Email: "map@b.com"})
+	assert.NoError(t, err)
+	assert.True(t, has)
+	has, err = testEngine.Get(&user)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "map@b.com", user.Email)
+
+	cnt, err = testEngine.Table(new(EncryptedUser)).ID(user.Id).Update(map[string]interface{}{
+		"email": "plain@x.com",
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	results, err = testEngine.Table(new(EncryptedUser)).Cols("email").QueryString()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(results))
+	assert.True(t, strings.HasPrefix(results[0]["email"], "v1:"))
+	assert.NotContains(t, results[0]["email"], "plain@x.com")
+
+	var updated EncryptedUser
+	has, err = testEngine.Get(&EncryptedUser{Email: "plain@x.com"})
+	assert.NoError(t, err)
+	assert.True(t, has)
+	has, err = testEngine.ID(user.Id).Get(&updated)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "plain@x.com", updated.Email)
+
+	index, err := testEngine.BlindIndex("pii", "plain@x.com")
+	assert.NoError(t, err)
+	assert.EqualValues(t, index, updated.EmailIndex)
+}
@@ -0,0 +1,60 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xormplus/xorm/exporters"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ExportUser struct {
+	Id    int64
+	Name  string
+	Score *float64
+	Tags  []string `xorm:"json"`
+}
+
+func TestExport(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(ExportUser))
+
+	score := 1.5
+	_, err := testEngine.Insert([]ExportUser{
+		{Name: "user0", Score: &score, Tags: []string{"a"}},
+		{Name: "user1"},
+	})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = testEngine.Asc("id").Export(exporters.NewCSVWriter(&buf), new(ExportUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "id,name,score,tags\n"+
+		"1,user0,1.5,\"[\"\"a\"\"]\"\n"+
+		"2,user1,,null\n", buf.String())
+
+	buf.Reset()
+	err = testEngine.Cols("name").Where("id = ?", 2).Export(exporters.NewJSONLinesWriter(&buf), new(ExportUser))
+	assert.NoError(t, err)
+	assert.EqualValues(t, `{"name":"user1"}`+"\n", buf.String())
+
+	buf.Reset()
+	var tbName = testEngine.Quote(testEngine.TableName(new(ExportUser), true))
+	err = testEngine.ExportQuery(exporters.NewJSONLinesWriter(&buf), "SELECT id, name FROM "+tbName+" ORDER BY id")
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.EqualValues(t, 2, len(lines))
+	assert.Contains(t, lines[0], `"user0"`)
+
+	buf.Reset()
+	err = testEngine.Table(new(ExportUser)).Select("id, name").ExportQuery(exporters.NewParquetWriter(&buf))
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("PAR1")))
+	assert.True(t, bytes.HasSuffix(buf.Bytes(), []byte("PAR1")))
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xormplus/xorm/importers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ImportUser struct {
+	Id      int64
+	Name    string `xorm:"unique"`
+	Age     int
+	Score   *float64
+	Tags    []string `xorm:"json"`
+	Created time.Time
+}
+
+func TestImportData(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(ImportUser))
+
+	csv := "name,age,Score,created\n" +
+		"user0,18,1.5,2020-01-02 03:04:05\n" +
+		"user1,abc,,2020-01-02 03:04:05\n" +
+		"user2,20,,\n" +
+		"user3,21,2,2020-01-02\n"
+	result, err := testEngine.BufferSize(2).ImportData(new(ImportUser), strings.NewReader(csv), importers.CSV)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, result.Inserted)
+	if assert.EqualValues(t, 1, len(result.Errors)) {
+		assert.EqualValues(t, 3, result.Errors[0].Line)
+	}
+
+	var users []ImportUser
+	assert.NoError(t, testEngine.Asc("id").Find(&users))
+	if assert.EqualValues(t, 3, len(users)) {
+		assert.EqualValues(t, "user0", users[0].Name)
+		assert.EqualValues(t, 18, users[0].Age)
+		assert.EqualValues(t, 1.5, *users[0].Score)
+		assert.EqualValues(t, 2020, users[0].Created.Year())
+		assert.Nil(t, users[1].Score)
+		assert.EqualValues(t, "user3", users[2].Name)
+	}
+
+	// the duplicated name fails the batch, then its other row is inserted alone
+	jsonl := `{"name":"user4","age":22,"tags":["a","b"]}` + "\n" +
+		`{"name":"user0","age":23}` + "\n" +
+		`{"name":"user5","unknown":1}` + "\n" +
+		`not json` + "\n"
+	result, err = testEngine.ImportData(new(ImportUser), strings.NewReader(jsonl), importers.JSONLines)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, result.Inserted)
+	var lines []int
+	for _, rowErr := range result.Errors {
+		lines = append(lines, rowErr.Line)
+	}
+	assert.ElementsMatch(t, []int{2, 3, 4}, lines)
+
+	var user ImportUser
+	has, err := testEngine.Where("name = ?", "user4").Get(&user)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, []string{"a", "b"}, user.Tags)
+}
+
+func TestImportDataInTransaction(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(ImportUser))
+
+	session := testEngine.NewSession()
+	defer session.Close()
+	assert.NoError(t, session.Begin())
+
+	// the failed batch is rolled back to its savepoint, so the transaction is still usable
+	csv := "name,age\n" +
+		"user0,18\n" +
+		"user1,19\n" +
+		"user0,20\n" +
+		"user2,21\n"
+	result, err := session.BufferSize(3).ImportData(new(ImportUser), strings.NewReader(csv), importers.CSV)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, result.Inserted)
+	if assert.EqualValues(t, 1, len(result.Errors)) {
+		assert.EqualValues(t, 4, result.Errors[0].Line)
+	}
+	assert.NoError(t, session.Commit())
+
+	var users []ImportUser
+	assert.NoError(t, testEngine.Asc("id").Find(&users))
+	if assert.EqualValues(t, 3, len(users)) {
+		assert.EqualValues(t, "user0", users[0].Name)
+		assert.EqualValues(t, 18, users[0].Age)
+		assert.EqualValues(t, "user1", users[1].Name)
+		assert.EqualValues(t, "user2", users[2].Name)
+	}
+}
@@ -986,3 +986,80 @@ func TestInsertTwice(t *testing.T) {
 
 	assert.NoError(t, ssn.Commit())
 }
+
+func TestUpsert(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type UpsertStruct struct {
+		Id   int64
+		Name string `xorm:"varchar(100) unique"`
+		Age  int
+		Memo string
+	}
+
+	assertSync(t, new(UpsertStruct))
+
+	_, err := testEngine.Upsert(&UpsertStruct{Name: "lunny", Age: 20, Memo: "first"}, "name")
+	assert.NoError(t, err)
+
+	// the zero Memo will not be updated
+	_, err = testEngine.Upsert(&UpsertStruct{Name: "lunny", Age: 21}, "name")
+	assert.NoError(t, err)
+
+	var records []UpsertStruct
+	assert.NoError(t, testEngine.Find(&records))
+	assert.EqualValues(t, 1, len(records))
+	assert.EqualValues(t, "lunny", records[0].Name)
+	assert.EqualValues(t, 21, records[0].Age)
+	assert.EqualValues(t, "first", records[0].Memo)
+
+	_, err = testEngine.Cols("name", "age", "memo").InsertOrUpdate([]UpsertStruct{
+		{Name: "lunny", Age: 22},
+		{Name: "xlw", Age: 30, Memo: "second"},
+	}, "name")
+	assert.NoError(t, err)
+
+	records = records[:0]
+	assert.NoError(t, testEngine.Asc("name").Find(&records))
+	assert.EqualValues(t, 2, len(records))
+	assert.EqualValues(t, 22, records[0].Age)
+	assert.EqualValues(t, "", records[0].Memo)
+	assert.EqualValues(t, "xlw", records[1].Name)
+	assert.EqualValues(t, 30, records[1].Age)
+}
+
+func TestInsertReturning(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	type InsertReturning struct {
+		Id      int64
+		Name    string
+		Score   int    `xorm:"default 42"`
+		Code    string `xorm:"varchar(20) default 'abc'"`
+		Version int    `xorm:"version"`
+	}
+
+	assertSync(t, new(InsertReturning))
+
+	var record = InsertReturning{Name: "lunny"}
+	cnt, err := testEngine.Omit("score", "code").Returning().Insert(&record)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	assert.True(t, record.Id > 0)
+	assert.EqualValues(t, 42, record.Score)
+	assert.EqualValues(t, "abc", record.Code)
+	assert.EqualValues(t, 1, record.Version)
+
+	record.Score = 50
+	cnt, err = testEngine.ID(record.Id).Cols("score").Returning("code", "version").Update(&record)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	assert.EqualValues(t, 2, record.Version)
+
+	var deleted = InsertReturning{Name: "lunny"}
+	cnt, err = testEngine.Returning().Delete(&deleted)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	assert.EqualValues(t, record.Id, deleted.Id)
+	assert.EqualValues(t, 50, deleted.Score)
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"testing"
+
+	"github.com/xormplus/xorm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type JoinedUser struct {
+	Id   int64
+	Name string
+}
+
+type JoinedOrder struct {
+	Id           int64
+	JoinedUserId int64 `xorm:"index"`
+	Amount       int
+}
+
+type JoinedItem struct {
+	Id            int64
+	JoinedOrderId int64 `xorm:"index"`
+	Sku           string
+}
+
+func TestFindJoined(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(JoinedUser), new(JoinedOrder), new(JoinedItem))
+
+	_, err := testEngine.Insert([]JoinedUser{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+	assert.NoError(t, err)
+	var users []JoinedUser
+	assert.NoError(t, testEngine.Asc("id").Find(&users))
+	assert.EqualValues(t, 3, len(users))
+
+	var orders = []JoinedOrder{
+		{JoinedUserId: users[0].Id, Amount: 10},
+		{JoinedUserId: users[0].Id, Amount: 20},
+		{JoinedUserId: users[1].Id, Amount: 30},
+		{JoinedUserId: 0, Amount: 40},
+	}
+	for i := range orders {
+		_, err = testEngine.Insert(&orders[i])
+		assert.NoError(t, err)
+	}
+	_, err = testEngine.Insert([]JoinedItem{
+		{JoinedOrderId: orders[0].Id, Sku: "x"},
+		{JoinedOrderId: orders[0].Id, Sku: "y"},
+		{JoinedOrderId: orders[2].Id, Sku: "z"},
+	})
+	assert.NoError(t, err)
+
+	type UserOrders struct {
+		JoinedUser
+		Orders []JoinedOrder
+	}
+
+	var userOrders []UserOrders
+	err = testEngine.Join(xorm.LeftJoin, &JoinedOrder{}, "joined_order.joined_user_id = joined_user.id").
+		Asc("joined_user.id", "joined_order.id").
+		FindJoined(&userOrders)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, len(userOrders))
+	assert.EqualValues(t, "a", userOrders[0].Name)
+	assert.EqualValues(t, 2, len(userOrders[0].Orders))
+	assert.EqualValues(t, 20, userOrders[0].Orders[1].Amount)
+	assert.EqualValues(t, 1, len(userOrders[1].Orders))
+	assert.EqualValues(t, 0, len(userOrders[2].Orders))
+
+	type OrderDetail struct {
+		Order JoinedOrder
+		User  *JoinedUser
+		Items []*JoinedItem
+	}
+
+	var details []*OrderDetail
+	err = testEngine.Table(&JoinedOrder{}).Alias("o").
+		Join(xorm.LeftJoin, []interface{}{&JoinedUser{}, "u"}, "u.id = o.joined_user_id").
+		Join(xorm.LeftJoin, &JoinedItem{}, "joined_item.joined_order_id = o.id").
+		Where("o.amount > ?", 5).
+		Asc("o.id", "joined_item.id").
+		FindJoined(&details)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, len(details))
+	assert.EqualValues(t, 10, details[0].Order.Amount)
+	assert.NotNil(t, details[0].User)
+	assert.EqualValues(t, "a", details[0].User.Name)
+	assert.EqualValues(t, 2, len(details[0].Items))
+	assert.EqualValues(t, "y", details[0].Items[1].Sku)
+	assert.EqualValues(t, 0, len(details[1].Items))
+	assert.EqualValues(t, "b", details[2].User.Name)
+	assert.Nil(t, details[3].User)
+
+	// the tables without a field are only joined
+	var matched []JoinedUser
+	err = testEngine.Join(xorm.InnerJoin, &JoinedOrder{}, "joined_order.joined_user_id = joined_user.id").
+		Where("joined_order.amount = ?", 30).
+		FindJoined(&matched)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(matched))
+	assert.EqualValues(t, "b", matched[0].Name)
+}
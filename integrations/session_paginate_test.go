@@ -0,0 +1,93 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/xormplus/xorm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type PaginateUser struct {
+	Id    int64
+	Name  string
+	Score int `xorm:"index"`
+}
+
+func TestPaginate(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(PaginateUser))
+
+	var users []PaginateUser
+	for i := 0; i < 7; i++ {
+		users = append(users, PaginateUser{Name: fmt.Sprintf("user%d", i), Score: i % 3})
+	}
+	_, err := testEngine.Insert(users)
+	assert.NoError(t, err)
+
+	names := func(users []PaginateUser) []string {
+		var names []string
+		for _, user := range users {
+			names = append(names, user.Name)
+		}
+		return names
+	}
+
+	// ordered by the primary key
+	var page1 []PaginateUser
+	page, err := testEngine.Paginate("", 3).Find(&page1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"user0", "user1", "user2"}, names(page1))
+	assert.EqualValues(t, "", page.Prev)
+	assert.NotEqual(t, "", page.Next)
+
+	var page2 []*PaginateUser
+	page, err = testEngine.Paginate(page.Next, 3).Find(&page2)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, len(page2))
+	assert.EqualValues(t, "user3", page2[0].Name)
+	assert.NotEqual(t, "", page.Prev)
+	next := page.Next
+
+	var prev []PaginateUser
+	page, err = testEngine.Paginate(page.Prev, 3).Find(&prev)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"user0", "user1", "user2"}, names(prev))
+	assert.EqualValues(t, "", page.Prev)
+
+	var page3 []PaginateUser
+	page, err = testEngine.Paginate(next, 3).Find(&page3)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"user6"}, names(page3))
+	assert.EqualValues(t, "", page.Next)
+
+	// ordered by score DESC and id with a condition
+	var scored []PaginateUser
+	page, err = testEngine.Where("name <> ?", "user0").Paginate("", 4, "score DESC", "id").Find(&scored)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"user2", "user5", "user1", "user4"}, names(scored))
+	scored = nil
+	_, err = testEngine.Where("name <> ?", "user0").Paginate(page.Next, 4, "score DESC", "id").Find(&scored)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"user3", "user6"}, names(scored))
+
+	// maps of a raw query
+	rows, page, err := testEngine.SQL("SELECT id, name, score FROM paginate_user WHERE score = ?", 1).
+		Paginate("", 1, "id").Query()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(rows))
+	assert.EqualValues(t, "user1", fmt.Sprint(rows[0]["name"]))
+	rows, _, err = testEngine.SQL("SELECT id, name, score FROM paginate_user WHERE score = ?", 1).
+		Paginate(page.Next, 5, "id").Query()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(rows))
+	assert.EqualValues(t, "user4", fmt.Sprint(rows[0]["name"]))
+
+	_, err = testEngine.Paginate("invalid", 3).Find(&page1)
+	assert.EqualValues(t, xorm.ErrInvalidCursor, err)
+}
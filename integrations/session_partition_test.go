@@ -0,0 +1,129 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xormplus/xorm"
+	"github.com/xormplus/xorm/schemas"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type PartitionedEvent struct {
+	Id       int64
+	Name     string
+	Happened time.Time `xorm:"partition(monthly) index"`
+}
+
+func TestPartitions(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	var (
+		tz   = testEngine.GetTZDatabase()
+		aug  = time.Date(2026, 8, 1, 0, 0, 0, 0, tz)
+		sep  = time.Date(2026, 9, 1, 0, 0, 0, 0, tz)
+		oct  = time.Date(2026, 10, 1, 0, 0, 0, 0, tz)
+		nov  = time.Date(2026, 11, 1, 0, 0, 0, 0, tz)
+		dec  = time.Date(2026, 12, 1, 0, 0, 0, 0, tz)
+		now  = time.Now().In(tz)
+		next = schemas.PartitionMonthly.Next(now)
+	)
+	var tableNames = []interface{}{
+		"partitioned_event_202608", "partitioned_event_202609",
+		"partitioned_event_202610", "partitioned_event_202611",
+		schemas.PartitionTableName("partitioned_event", schemas.PartitionMonthly, now),
+		schemas.PartitionTableName("partitioned_event", schemas.PartitionMonthly, next),
+	}
+	assert.NoError(t, testEngine.DropTables(tableNames...))
+	defer testEngine.DropTables(tableNames...)
+
+	assert.NoError(t, testEngine.SyncPartitions(sep, nov, new(PartitionedEvent)))
+	for name, exist := range map[string]bool{
+		"partitioned_event_202608": false,
+		"partitioned_event_202609": true,
+		"partitioned_event_202610": true,
+		"partitioned_event_202611": false,
+	} {
+		has, err := testEngine.IsTableExist(name)
+		assert.NoError(t, err)
+		assert.EqualValues(t, exist, has, name)
+	}
+
+	var events = []PartitionedEvent{
+		{Name: "a", Happened: sep.Add(time.Hour)},
+		{Name: "b", Happened: oct.Add(time.Hour)},
+		{Name: "c", Happened: oct.Add(2 * time.Hour)},
+	}
+	cnt, err := testEngine.Insert(&events)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, cnt)
+
+	_, err = testEngine.Insert(&PartitionedEvent{Name: "d", Happened: nov.Add(time.Hour)})
+	assert.Error(t, err)
+
+	cnt, err = testEngine.Table("partitioned_event_202610").Count(new(PartitionedEvent))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+
+	// the missing partitions of August and November are skipped
+	cnt, err = testEngine.Partitions(aug, dec).Count(new(PartitionedEvent))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, cnt)
+
+	cnt, err = testEngine.Partitions(sep, oct.Add(90*time.Minute)).Count(new(PartitionedEvent))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+
+	var found []PartitionedEvent
+	assert.NoError(t, testEngine.Partitions(aug, dec).Desc("name").Find(&found))
+	assert.EqualValues(t, 3, len(found))
+	assert.EqualValues(t, "c", found[0].Name)
+	assert.EqualValues(t, "a", found[2].Name)
+
+	found = nil
+	assert.NoError(t, testEngine.Partitions(oct, nov).Where("name = ?", "b").Find(&found))
+	assert.EqualValues(t, 1, len(found))
+
+	var event PartitionedEvent
+	has, err := testEngine.Partitions(sep, oct).Get(&event)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, "a", event.Name)
+
+	cnt, err = testEngine.Where("name = ?", "b").Cols("name").Update(&PartitionedEvent{Name: "e", Happened: oct})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	cnt, err = testEngine.Partitions(oct, nov).Where("name = ?", "e").Count(new(PartitionedEvent))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	cnt, err = testEngine.Delete(&PartitionedEvent{Name: "a", Happened: events[0].Happened})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	cnt, err = testEngine.Partitions(aug, dec).Count(new(PartitionedEvent))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+
+	// the open or empty ranges are rejected
+	for _, rng := range [][2]time.Time{{time.Time{}, dec}, {aug, time.Time{}}, {dec, aug}, {oct, oct}} {
+		_, err = testEngine.Partitions(rng[0], rng[1]).Count(new(PartitionedEvent))
+		assert.EqualValues(t, xorm.ErrPartitionsRange, err)
+		err = testEngine.Partitions(rng[0], rng[1]).Find(&found)
+		assert.EqualValues(t, xorm.ErrPartitionsRange, err)
+	}
+
+	// Sync2 creates the partition of now and the next one
+	assert.NoError(t, testEngine.Sync2(new(PartitionedEvent)))
+	for _, tm := range []time.Time{now, next} {
+		has, err := testEngine.IsTableExist(schemas.PartitionTableName("partitioned_event", schemas.PartitionMonthly, tm))
+		assert.NoError(t, err)
+		assert.True(t, has)
+	}
+}
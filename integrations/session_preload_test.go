@@ -0,0 +1,113 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type PreloadUser struct {
+	Id     int64
+	Name   string
+	Orders []PreloadOrder `xorm:"has_many"`
+	Roles  []*PreloadRole `xorm:"many2many(preload_user_role)"`
+}
+
+type PreloadOrder struct {
+	Id            int64
+	PreloadUserId int64 `xorm:"index"`
+	Amount        int
+	User          *PreloadUser   `xorm:"belongs_to(preload_user_id)"`
+	Items         []*PreloadItem `xorm:"has_many"`
+}
+
+type PreloadItem struct {
+	Id             int64
+	PreloadOrderId int64 `xorm:"index"`
+	Sku            string
+}
+
+type PreloadRole struct {
+	Id   int64
+	Name string
+}
+
+type PreloadUserRole struct {
+	PreloadUserId int64 `xorm:"pk"`
+	PreloadRoleId int64 `xorm:"pk"`
+}
+
+func TestPreload(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(PreloadUser), new(PreloadOrder), new(PreloadItem), new(PreloadRole), new(PreloadUserRole))
+
+	var users = []PreloadUser{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	for i := range users {
+		_, err := testEngine.Insert(&users[i])
+		assert.NoError(t, err)
+	}
+	var orders = []PreloadOrder{
+		{PreloadUserId: users[0].Id, Amount: 10},
+		{PreloadUserId: users[0].Id, Amount: 20},
+		{PreloadUserId: users[1].Id, Amount: 30},
+	}
+	for i := range orders {
+		_, err := testEngine.Insert(&orders[i])
+		assert.NoError(t, err)
+	}
+	_, err := testEngine.Insert([]PreloadItem{
+		{PreloadOrderId: orders[0].Id, Sku: "x"},
+		{PreloadOrderId: orders[0].Id, Sku: "y"},
+		{PreloadOrderId: orders[2].Id, Sku: "z"},
+	})
+	assert.NoError(t, err)
+	var roles = []PreloadRole{{Name: "admin"}, {Name: "guest"}}
+	for i := range roles {
+		_, err := testEngine.Insert(&roles[i])
+		assert.NoError(t, err)
+	}
+	_, err = testEngine.Insert([]PreloadUserRole{
+		{PreloadUserId: users[0].Id, PreloadRoleId: roles[0].Id},
+		{PreloadUserId: users[0].Id, PreloadRoleId: roles[1].Id},
+		{PreloadUserId: users[1].Id, PreloadRoleId: roles[1].Id},
+	})
+	assert.NoError(t, err)
+
+	var found []PreloadUser
+	err = testEngine.Preload("Orders.Items", "Roles").Asc("id").Find(&found)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, len(found))
+	assert.EqualValues(t, 2, len(found[0].Orders))
+	assert.EqualValues(t, 10, found[0].Orders[0].Amount)
+	assert.EqualValues(t, 2, len(found[0].Orders[0].Items))
+	assert.EqualValues(t, 0, len(found[0].Orders[1].Items))
+	assert.EqualValues(t, 2, len(found[0].Roles))
+	assert.EqualValues(t, 1, len(found[1].Orders))
+	assert.EqualValues(t, "z", found[1].Orders[0].Items[0].Sku)
+	assert.EqualValues(t, 1, len(found[1].Roles))
+	assert.EqualValues(t, "guest", found[1].Roles[0].Name)
+	assert.EqualValues(t, 0, len(found[2].Orders))
+	assert.EqualValues(t, 0, len(found[2].Roles))
+
+	var order PreloadOrder
+	has, err := testEngine.Preload("User.Roles").ID(orders[2].Id).Get(&order)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.NotNil(t, order.User)
+	assert.EqualValues(t, "b", order.User.Name)
+	assert.EqualValues(t, 1, len(order.User.Roles))
+
+	var orderMap = make(map[int64]PreloadOrder)
+	cnt, err := testEngine.Preload("User").Where("amount > ?", 15).FindAndCount(&orderMap)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+	assert.EqualValues(t, "a", orderMap[orders[1].Id].User.Name)
+	assert.EqualValues(t, "b", orderMap[orders[2].Id].User.Name)
+
+	err = testEngine.Preload("Unknown").Find(&found)
+	assert.Error(t, err)
+}
@@ -5,11 +5,16 @@
 package integrations
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/xormplus/xorm"
+	"github.com/xormplus/xorm/contexts"
+	"github.com/xormplus/xorm/schemas"
 )
 
 func TestStoreEngine(t *testing.T) {
@@ -247,6 +252,73 @@ func TestMetaInfo(t *testing.T) {
 	assert.Contains(t, tableNames, "index_or_unique")
 }
 
+type SyncFKUser struct {
+	Id   int64
+	Name string
+}
+
+type SyncFKOrder struct {
+	Id     int64
+	UserId int64 `xorm:"fk(sync_f_k_user.id) ondelete(cascade)"`
+}
+
+func TestSyncForeignKey(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assert.NoError(t, testEngine.Sync2(new(SyncFKUser), new(SyncFKOrder)))
+	// sync again should find the foreign key and change nothing
+	assert.NoError(t, testEngine.Sync2(new(SyncFKUser), new(SyncFKOrder)))
+
+	tables, err := testEngine.DBMetas()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(tables))
+
+	var orderTable *schemas.Table
+	for _, table := range tables {
+		if table.Name == "sync_f_k_order" {
+			orderTable = table
+		}
+	}
+	assert.NotNil(t, orderTable)
+	assert.EqualValues(t, 1, len(orderTable.ForeignKeys))
+	for _, fk := range orderTable.ForeignKeys {
+		assert.EqualValues(t, "sync_f_k_user", fk.RefTable)
+		assert.EqualValues(t, []string{"user_id"}, fk.Cols)
+		assert.EqualValues(t, []string{"id"}, fk.RefCols)
+		assert.EqualValues(t, schemas.FKCascade, fk.OnDelete)
+	}
+}
+
+type createTableHook struct {
+	tables []string
+}
+
+func (h *createTableHook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	return c.Ctx, nil
+}
+
+func (h *createTableHook) AfterProcess(c *contexts.ContextHook) error {
+	if !strings.HasPrefix(c.SQL, "CREATE TABLE ") {
+		return nil
+	}
+	sql := strings.TrimPrefix(strings.TrimPrefix(c.SQL, "CREATE TABLE "), "IF NOT EXISTS ")
+	if fields := strings.Fields(sql); len(fields) > 0 {
+		h.tables = append(h.tables, strings.Trim(fields[0], "`\"[]"))
+	}
+	return nil
+}
+
+func TestSyncForeignKeyOrder(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assert.NoError(t, testEngine.DropTables(new(SyncFKOrder), new(SyncFKUser)))
+
+	hook := &createTableHook{}
+	testEngine.AddHook(hook)
+
+	// the referenced table is created first whatever the order of the beans is
+	assert.NoError(t, testEngine.Sync2(new(SyncFKOrder), new(SyncFKUser)))
+	assert.EqualValues(t, []string{"sync_f_k_user", "sync_f_k_order"}, hook.tables)
+}
+
 func TestCharst(t *testing.T) {
 	assert.NoError(t, PrepareEngine())
 
@@ -330,3 +402,70 @@ func TestSync2_Default(t *testing.T) {
 	assertSync(t, new(TestSync2Default))
 	assert.NoError(t, testEngine.Sync2(new(TestSync2Default)))
 }
+
+func TestSchemaDiff(t *testing.T) {
+	type TestSchemaDiff struct {
+		Id       int64
+		UserId   int64  `xorm:"index default(1)"`
+		IsMember bool   `xorm:"default(true)"`
+		Name     string `xorm:"varchar(50) default('my_name')"`
+	}
+
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(TestSchemaDiff))
+
+	diff, err := testEngine.SchemaDiff(new(TestSchemaDiff))
+	assert.NoError(t, err)
+	assert.True(t, diff.IsEmpty(), diff.String())
+
+	type TestSchemaDiff2 struct {
+		Id       int64
+		UserId   int64  `xorm:"index default(1)"`
+		IsMember bool   `xorm:"default(true)"`
+		Name     string `xorm:"varchar(50) default('my_name')"`
+		Age      int    `xorm:"index"`
+	}
+
+	diff, err = testEngine.Table("test_schema_diff").SchemaDiff(new(TestSchemaDiff2))
+	assert.NoError(t, err)
+	assert.Len(t, diff.Changes, 2)
+	assert.EqualValues(t, xorm.SchemaAddColumn, diff.Changes[0].Type)
+	assert.EqualValues(t, "age", diff.Changes[0].Column.Name)
+	assert.EqualValues(t, xorm.SchemaAddIndex, diff.Changes[1].Type)
+
+	assert.NoError(t, testEngine.ApplySchemaDiff(diff))
+	diff, err = testEngine.Table("test_schema_diff").SchemaDiff(new(TestSchemaDiff2))
+	assert.NoError(t, err)
+	assert.True(t, diff.IsEmpty(), diff.String())
+
+	// dropping the column is reported but not supported by sqlite3 older than 3.35.0, so it's
+	// skipped by ApplySchemaDiff
+	var supported = true
+	if testEngine.Dialect().URI().DBType == schemas.SQLITE {
+		var version string
+		has, err := testEngine.SQL("SELECT sqlite_version()").Get(&version)
+		assert.NoError(t, err)
+		assert.True(t, has)
+		var major, minor int
+		_, err = fmt.Sscanf(version, "%d.%d", &major, &minor)
+		assert.NoError(t, err)
+		supported = major > 3 || (major == 3 && minor >= 35)
+	}
+
+	diff, err = testEngine.Table("test_schema_diff").SchemaDiff(new(TestSchemaDiff))
+	assert.NoError(t, err)
+	var dropped bool
+	for _, change := range diff.Changes {
+		if change.Type == xorm.SchemaDropColumn {
+			dropped = true
+			assert.EqualValues(t, "age", change.Column.Name)
+			assert.EqualValues(t, supported, change.IsSupported(), change.String())
+		}
+	}
+	assert.True(t, dropped, diff.String())
+	assert.NoError(t, testEngine.ApplySchemaDiff(diff))
+
+	diff, err = testEngine.Table("test_schema_diff").SchemaDiff(new(TestSchemaDiff))
+	assert.NoError(t, err)
+	assert.EqualValues(t, supported, diff.IsEmpty(), diff.String())
+}
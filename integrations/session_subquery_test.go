@@ -0,0 +1,83 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"testing"
+
+	"github.com/xormplus/xorm"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type SubQueryOrder struct {
+	Id     int64
+	UserId int64 `xorm:"index"`
+	Amount int
+}
+
+type SubQueryNode struct {
+	Id       int64
+	ParentId int64
+	Name     string
+}
+
+func TestSubQueries(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(SubQueryOrder), new(SubQueryNode))
+
+	_, err := testEngine.Insert([]SubQueryOrder{
+		{UserId: 1, Amount: 10},
+		{UserId: 1, Amount: 30},
+		{UserId: 2, Amount: 20},
+		{UserId: 2, Amount: 50},
+		{UserId: 3, Amount: 5},
+	})
+	assert.NoError(t, err)
+
+	type UserTotal struct {
+		UserId int64
+		Total  int
+	}
+	var totals []UserTotal
+	sub := testEngine.Table(new(SubQueryOrder)).Select("user_id, SUM(amount) AS total").GroupBy("user_id")
+	err = testEngine.With("totals", sub).Table("totals").Where("total > ?", 10).Asc("user_id").Find(&totals)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []UserTotal{{1, 40}, {2, 70}}, totals)
+
+	// the largest order of every user
+	var orders []SubQueryOrder
+	rn := xorm.RowNumber().PartitionBy("user_id").OrderBy("amount DESC").As("rn")
+	sub = testEngine.Table(new(SubQueryOrder)).Select("*, " + rn.String())
+	err = testEngine.From(sub, "o").Where("rn = ?", 1).Asc("user_id").Find(&orders)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, len(orders))
+	assert.EqualValues(t, []int{30, 50, 5}, []int{orders[0].Amount, orders[1].Amount, orders[2].Amount})
+
+	sub = testEngine.Table(new(SubQueryOrder)).Select("*, " + rn.String())
+	cnt, err := testEngine.From(sub, "o").Where("rn > ?", 1).Count(new(SubQueryOrder))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+
+	var nodes = []SubQueryNode{{Name: "root"}}
+	_, err = testEngine.Insert(&nodes[0])
+	assert.NoError(t, err)
+	var child = SubQueryNode{ParentId: nodes[0].Id, Name: "child"}
+	_, err = testEngine.Insert(&child)
+	assert.NoError(t, err)
+	_, err = testEngine.Insert(&SubQueryNode{ParentId: child.Id, Name: "grandchild"})
+	assert.NoError(t, err)
+	_, err = testEngine.Insert(&SubQueryNode{Name: "other"})
+	assert.NoError(t, err)
+
+	var tree []SubQueryNode
+	err = testEngine.WithRecursive("tree",
+		"SELECT * FROM sub_query_node WHERE id = ? UNION ALL "+
+			"SELECT n.* FROM sub_query_node n INNER JOIN tree ON n.parent_id = tree.id", nodes[0].Id).
+		Table("tree").Asc("id").Find(&tree)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, len(tree))
+	assert.EqualValues(t, "grandchild", tree[2].Name)
+}
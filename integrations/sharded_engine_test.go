@@ -0,0 +1,126 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/xormplus/builder"
+	"github.com/xormplus/xorm"
+	"github.com/xormplus/xorm/schemas"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ShardedOrder struct {
+	Id       int64
+	TenantId int64 `xorm:"shard index"`
+	Amount   int
+}
+
+func TestShardedEngine(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+
+	main := testEngine.(*xorm.Engine)
+	if main.Dialect().URI().DBType != schemas.SQLITE {
+		t.Skip()
+		return
+	}
+
+	var engines []*xorm.Engine
+	for _, name := range []string{"shard0.db", "shard1.db"} {
+		engine, err := xorm.NewEngine(main.DriverName(), filepath.Join(t.TempDir(), name))
+		assert.NoError(t, err)
+		engines = append(engines, engine)
+	}
+
+	// the tenants less than 100 are on the first shard
+	se, err := xorm.NewShardedEngine(engines, xorm.RangeShard(100))
+	assert.NoError(t, err)
+	defer se.Close()
+	assert.NoError(t, se.Sync2(new(ShardedOrder)))
+
+	cnt, err := se.Insert([]ShardedOrder{
+		{TenantId: 1, Amount: 10},
+		{TenantId: 101, Amount: 20},
+		{TenantId: 2, Amount: 30},
+		{TenantId: 102, Amount: 40},
+	}, &ShardedOrder{TenantId: 103, Amount: 50})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, cnt)
+
+	_, err = se.Insert(&ShardedOrder{Amount: 60})
+	assert.EqualValues(t, xorm.ErrShardKeyRequired, err)
+
+	cnt, err = engines[0].Count(new(ShardedOrder))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+	cnt, err = se.Count(new(ShardedOrder))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, cnt)
+	cnt, err = se.Count(&ShardedOrder{TenantId: 101})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	var orders []ShardedOrder
+	assert.NoError(t, se.Desc("amount").Limit(3, 1).Find(&orders))
+	assert.EqualValues(t, 3, len(orders))
+	assert.EqualValues(t, []int{40, 30, 20}, []int{orders[0].Amount, orders[1].Amount, orders[2].Amount})
+
+	orders = nil
+	assert.NoError(t, se.Where(builder.Eq{"tenant_id": 2}).Find(&orders))
+	assert.EqualValues(t, 1, len(orders))
+	assert.EqualValues(t, 30, orders[0].Amount)
+
+	var amounts []int
+	err = se.Asc("amount").Limit(4).Iterate(new(ShardedOrder), func(idx int, bean interface{}) error {
+		amounts = append(amounts, bean.(*ShardedOrder).Amount)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []int{10, 20, 30, 40}, amounts)
+
+	var order = ShardedOrder{TenantId: 102}
+	has, err := se.Get(&order)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, 40, order.Amount)
+
+	order = ShardedOrder{}
+	has, err = se.Where("amount = ?", 50).Get(&order)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	assert.EqualValues(t, 103, order.TenantId)
+
+	cnt, err = se.ShardKey(1).Cols("amount").Update(&ShardedOrder{Amount: 11}, &ShardedOrder{TenantId: 1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	// the conditions are routed before the new key in bean, and the caller's condiBean is kept
+	condiBeans := make([]interface{}, 1, 2)
+	condiBeans[0] = &ShardedOrder{TenantId: 2}
+	cnt, err = se.Cols("amount").Update(&ShardedOrder{TenantId: 102, Amount: 31}, condiBeans...)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	assert.EqualValues(t, 1, len(condiBeans))
+	cnt, err = se.Where(builder.Eq{"tenant_id": 2}).Cols("amount").Update(&ShardedOrder{TenantId: 102, Amount: 32})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	cnt, err = se.Where("tenant_id = ?", 2).Cols("amount").Update(&ShardedOrder{TenantId: 102, Amount: 33})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+	cnt, err = engines[0].Where("tenant_id = ?", 2).And("amount = ?", 33).Count(new(ShardedOrder))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cnt)
+
+	cnt, err = se.Where("amount > ?", 25).Delete(new(ShardedOrder))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, cnt)
+
+	cnt, err = se.Count(new(ShardedOrder))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, cnt)
+}
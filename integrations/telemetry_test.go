@@ -0,0 +1,80 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"testing"
+
+	"github.com/xormplus/xorm/contexts"
+	"github.com/xormplus/xorm/telemetry"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TelemetryUser struct {
+	Id   int64
+	Name string
+}
+
+func TestTelemetryHook(t *testing.T) {
+	assert.NoError(t, PrepareEngine())
+	assertSync(t, new(TelemetryUser))
+
+	tracer := telemetry.NewMemoryTracer()
+	meter := telemetry.NewMemoryMeter()
+	testEngine.AddHook(telemetry.NewHook(string(testEngine.Dialect().URI().DBType), tracer, meter))
+
+	tableName := testEngine.TableName(new(TelemetryUser), true)
+	findSpan := func(op contexts.Operation) *telemetry.MemorySpan {
+		for _, span := range tracer.Spans() {
+			if span.Attributes[telemetry.KeyDBOperation] == string(op) {
+				return span
+			}
+		}
+		return nil
+	}
+
+	_, err := testEngine.Insert(&TelemetryUser{Name: "a"})
+	assert.NoError(t, err)
+	span := findSpan(contexts.OperationInsert)
+	if assert.NotNil(t, span) {
+		assert.EqualValues(t, tableName, span.Attributes[telemetry.KeyDBTable])
+		assert.EqualValues(t, false, span.Attributes[telemetry.KeyInTx])
+		assert.NotEmpty(t, span.Attributes[telemetry.KeySessionID])
+	}
+
+	var users []TelemetryUser
+	assert.NoError(t, testEngine.Find(&users))
+	span = findSpan(contexts.OperationFind)
+	if assert.NotNil(t, span) {
+		assert.EqualValues(t, tableName, span.Attributes[telemetry.KeyDBTable])
+	}
+
+	tracer.Reset()
+	session := testEngine.NewSession()
+	defer session.Close()
+	assert.NoError(t, session.Begin())
+	_, err = session.ID(1).Update(&TelemetryUser{Name: "b"})
+	assert.NoError(t, err)
+	assert.NoError(t, session.Commit())
+
+	update := findSpan(contexts.OperationUpdate)
+	commit := findSpan(contexts.OperationCommit)
+	if assert.NotNil(t, update) && assert.NotNil(t, commit) {
+		assert.EqualValues(t, true, update.Attributes[telemetry.KeyInTx])
+		assert.EqualValues(t, int64(1), update.Attributes[telemetry.KeyRowsAffected])
+		assert.EqualValues(t, update.Attributes[telemetry.KeyTxID], commit.Attributes[telemetry.KeyTxID])
+		assert.EqualValues(t, update.Attributes[telemetry.KeySessionID], commit.Attributes[telemetry.KeySessionID])
+	}
+
+	_, err = testEngine.Exec("SELECT * FROM not_exist_table")
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, meter.CounterValue(telemetry.MetricErrors,
+		telemetry.Attribute{Key: telemetry.KeyDBSystem, Value: string(testEngine.Dialect().URI().DBType)},
+		telemetry.Attribute{Key: telemetry.KeyDBOperation, Value: string(contexts.OperationExec)},
+		telemetry.Attribute{Key: telemetry.KeyDBTable, Value: ""},
+		telemetry.Attribute{Key: telemetry.KeyInTx, Value: false},
+	))
+}
@@ -7,12 +7,15 @@ package xorm
 import (
 	"context"
 	"database/sql"
+	"io"
 	"reflect"
 	"time"
 
 	"github.com/xormplus/xorm/caches"
 	"github.com/xormplus/xorm/contexts"
 	"github.com/xormplus/xorm/dialects"
+	"github.com/xormplus/xorm/exporters"
+	"github.com/xormplus/xorm/importers"
 	"github.com/xormplus/xorm/log"
 	"github.com/xormplus/xorm/names"
 	"github.com/xormplus/xorm/schemas"
@@ -24,6 +27,7 @@ type Interface interface {
 	Alias(alias string) *Session
 	Asc(colNames ...string) *Session
 	BufferSize(size int) *Session
+	CacheFor(ttl time.Duration, tables ...string) *Session
 	Cols(columns ...string) *Session
 	Count(...interface{}) (int64, error)
 	CreateIndexes(bean interface{}) error
@@ -35,15 +39,22 @@ type Interface interface {
 	DropIndexes(bean interface{}) error
 	Exec(sqlOrArgs ...interface{}) (sql.Result, error)
 	Exist(bean ...interface{}) (bool, error)
+	Export(w exporters.Writer, bean interface{}) error
+	ExportQuery(w exporters.Writer, sqlOrArgs ...interface{}) error
 	Find(interface{}, ...interface{}) error
 	FindAndCount(interface{}, ...interface{}) (int64, error)
+	FindJoined(interface{}) error
+	From(query interface{}, alias string, args ...interface{}) *Session
 	Get(interface{}) (bool, error)
 	GroupBy(keys string) *Session
 	ID(interface{}) *Session
+	ImportData(bean interface{}, r io.Reader, format importers.Format) (*ImportResult, error)
+	ImportReader(bean interface{}, reader importers.Reader) (*ImportResult, error)
 	In(string, ...interface{}) *Session
 	Incr(column string, arg ...interface{}) *Session
 	Insert(...interface{}) (int64, error)
 	InsertOne(interface{}) (int64, error)
+	InsertOrUpdate(bean interface{}, conflictCols ...string) (int64, error)
 	IsTableEmpty(bean interface{}) (bool, error)
 	IsTableExist(beanOrTableName interface{}) (bool, error)
 	Iterate(interface{}, IterFunc) error
@@ -54,12 +65,16 @@ type Interface interface {
 	Join(joinOperator string, tablename interface{}, condition string, args ...interface{}) *Session
 	Omit(columns ...string) *Session
 	OrderBy(order string) *Session
+	Paginate(cursor string, pageSize int, orderCols ...string) *Paginator
+	Partitions(from, to time.Time) *Session
+	Preload(paths ...string) *Session
 	Ping() error
 	QueryBytes(sqlOrArgs ...interface{}) (resultsSlice []map[string][]byte, err error)
 	QueryInterface(sqlOrArgs ...interface{}) ([]map[string]interface{}, error)
 	QueryString(sqlOrArgs ...interface{}) ([]map[string]string, error)
 	QueryValue(sqlOrArgs ...interface{}) ([]map[string]Value, error)
 	QueryResult(sqlOrArgs ...interface{}) (result *ResultValue)
+	Returning(columns ...string) *Session
 	Rows(bean interface{}) (*Rows, error)
 	SetExpr(string, interface{}) *Session
 	Select(string) *Session
@@ -71,8 +86,13 @@ type Interface interface {
 	Table(tableNameOrBean interface{}) *Session
 	Unscoped() *Session
 	Update(bean interface{}, condiBeans ...interface{}) (int64, error)
+	Upsert(bean interface{}, conflictCols ...string) (int64, error)
 	UseBool(...string) *Session
+	UseMain() *Session
+	UseSubordinate() *Session
 	Where(interface{}, ...interface{}) *Session
+	With(name string, query interface{}, args ...interface{}) *Session
+	WithRecursive(name string, query interface{}, args ...interface{}) *Session
 }
 
 // EngineInterface defines the interface which Engine, EngineGroup will implementate.
@@ -92,6 +112,8 @@ type EngineInterface interface {
 	GetCacher(string) caches.Cacher
 	GetColumnMapper() names.Mapper
 	GetDefaultCacher() caches.Cacher
+	CacheStats() map[string]*caches.TableCacheStats
+	GetQueryCacher() caches.QueryCacher
 	GetTableMapper() names.Mapper
 	GetTZDatabase() *time.Location
 	GetTZLocation() *time.Location
@@ -104,6 +126,7 @@ type EngineInterface interface {
 	SetConnMaxLifetime(time.Duration)
 	SetColumnMapper(names.Mapper)
 	SetDefaultCacher(caches.Cacher)
+	SetQueryCacher(caches.QueryCacher)
 	SetLogger(logger interface{})
 	SetLogLevel(log.LogLevel)
 	SetMapper(names.Mapper)
@@ -111,13 +134,22 @@ type EngineInterface interface {
 	SetMaxIdleConns(int)
 	SetQuotePolicy(dialects.QuotePolicy)
 	SetSchema(string)
+	SetSlowQueryHandler(func(*SlowQuery))
+	SetSlowQueryThreshold(time.Duration)
 	SetTableMapper(names.Mapper)
 	SetTZDatabase(tz *time.Location)
 	SetTZLocation(tz *time.Location)
 	AddHook(hook contexts.Hook)
+	AddRedactRules(rules ...log.RedactRule)
+	BlindIndex(keyName, value string) (string, error)
+	RegisterCipher(keyName, keyID string, cipher Cipher) error
+	SetBlindIndexKey(keyName string, indexKey []byte)
 	ShowSQL(show ...bool)
 	Sync(...interface{}) error
 	Sync2(...interface{}) error
+	SyncPartitions(from, to time.Time, beans ...interface{}) error
+	SchemaDiff(...interface{}) (*SchemaDiff, error)
+	ApplySchemaDiff(*SchemaDiff) error
 	StoreEngine(storeEngine string) *Session
 	TableInfo(bean interface{}) (*schemas.Table, error)
 	TableName(interface{}, ...bool) string
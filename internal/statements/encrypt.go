@@ -0,0 +1,186 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/xormplus/xorm/internal/utils"
+	"github.com/xormplus/xorm/schemas"
+)
+
+// ErrNoEncrypter represents there is no encrypter for the columns tagged encrypt
+var ErrNoEncrypter = errors.New("no encrypter for the encrypted columns")
+
+// Encrypter encrypts the values of the columns tagged encrypt and creates their blind indexes
+type Encrypter interface {
+	Encrypt(keyName, plaintext string) (string, error)
+	BlindIndex(keyName, plaintext string) (string, error)
+}
+
+// SetEncrypter sets the encrypter of the columns tagged encrypt
+func (statement *Statement) SetEncrypter(encrypter Encrypter) {
+	statement.encrypter = encrypter
+}
+
+// plaintext returns the text of a value converted by value2Interface
+func plaintext(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	}
+	return fmt.Sprint(v)
+}
+
+// encryptValue encrypts a value of the column, nil is not encrypted
+func (statement *Statement) encryptValue(col *schemas.Column, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if statement.encrypter == nil {
+		return nil, ErrNoEncrypter
+	}
+	return statement.encrypter.Encrypt(col.EncryptKey, plaintext(v))
+}
+
+// blindIndex returns the blind index of the encrypted column of col in bean, it's empty if
+// the value of the encrypted column is zero
+func (statement *Statement) blindIndex(table *schemas.Table, col *schemas.Column, bean interface{}) (string, error) {
+	source := table.GetColumn(col.BlindIndexOf)
+	if source == nil || source.EncryptKey == "" {
+		return "", fmt.Errorf("the column %s of the blind index %s is not encrypted", col.BlindIndexOf, col.Name)
+	}
+	fieldValue, err := source.ValueOf(bean)
+	if err != nil {
+		return "", err
+	}
+	if !fieldValue.IsValid() || utils.IsZero(fieldValue.Interface()) {
+		return "", nil
+	}
+	v, err := statement.value2Interface(source, *fieldValue)
+	if err != nil || v == nil {
+		return "", err
+	}
+	if statement.encrypter == nil {
+		return "", ErrNoEncrypter
+	}
+	return statement.encrypter.BlindIndex(source.EncryptKey, plaintext(v))
+}
+
+// SetBlindIndexes sets the fields of the blind indexes of bean by its encrypted columns
+func (statement *Statement) SetBlindIndexes(table *schemas.Table, bean interface{}) error {
+	for _, col := range table.Columns() {
+		if col.BlindIndexOf == "" {
+			continue
+		}
+		index, err := statement.blindIndex(table, col, bean)
+		if err != nil {
+			return err
+		}
+		fieldValue, err := col.ValueOf(bean)
+		if err != nil {
+			return err
+		}
+		if !fieldValue.CanSet() {
+			return fmt.Errorf("the blind index %s could not be set, the bean should be a pointer", col.Name)
+		}
+		if fieldValue.Kind() == reflect.Ptr {
+			if index == "" {
+				fieldValue.Set(reflect.Zero(fieldValue.Type()))
+				continue
+			}
+			if fieldValue.IsNil() {
+				fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+			}
+			fieldValue.Elem().SetString(index)
+			continue
+		}
+		fieldValue.SetString(index)
+	}
+	return nil
+}
+
+// mapPlaintext returns the plaintext of a value of a map, nil is returned for nil and the nil
+// pointers
+func mapPlaintext(v interface{}) (string, bool) {
+	if v == nil {
+		return "", false
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "", false
+		}
+		rv = rv.Elem()
+	}
+	return plaintext(rv.Interface()), true
+}
+
+// EncryptMapValues encrypts the values of the encrypted columns of table in place, columns are
+// the keys of a map and values are their values. The blind indexes of the encrypted columns
+// are recomputed, the ones not in columns are appended.
+func (statement *Statement) EncryptMapValues(table *schemas.Table, columns []string, values []interface{}) ([]string, []interface{}, error) {
+	var plaintexts = make(map[string]*string)
+	for i, colName := range columns {
+		col := table.GetColumn(colName)
+		if col == nil || col.EncryptKey == "" {
+			continue
+		}
+		text, ok := mapPlaintext(values[i])
+		if !ok {
+			values[i] = nil
+			plaintexts[col.Name] = nil
+			continue
+		}
+		if statement.encrypter == nil {
+			return nil, nil, ErrNoEncrypter
+		}
+		encrypted, err := statement.encrypter.Encrypt(col.EncryptKey, text)
+		if err != nil {
+			return nil, nil, err
+		}
+		values[i] = encrypted
+		plaintexts[col.Name] = &text
+	}
+	if len(plaintexts) == 0 {
+		return columns, values, nil
+	}
+
+	for _, col := range table.Columns() {
+		text, ok := plaintexts[col.BlindIndexOf]
+		if col.BlindIndexOf == "" || !ok {
+			continue
+		}
+		// the zero value has an empty blind index like the beans
+		var index interface{}
+		if text != nil && *text == "" {
+			index = ""
+		} else if text != nil {
+			source := table.GetColumn(col.BlindIndexOf)
+			idx, err := statement.encrypter.BlindIndex(source.EncryptKey, *text)
+			if err != nil {
+				return nil, nil, err
+			}
+			index = idx
+		}
+
+		var found bool
+		for i, colName := range columns {
+			if strings.EqualFold(colName, col.Name) {
+				values[i], found = index, true
+			}
+		}
+		if !found {
+			columns = append(columns, col.Name)
+			values = append(values, index)
+		}
+	}
+	return columns, values, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testEncrypter struct{}
+
+func (testEncrypter) Encrypt(keyName, plaintext string) (string, error) {
+	return keyName + ":" + plaintext, nil
+}
+
+func (testEncrypter) BlindIndex(keyName, plaintext string) (string, error) {
+	return "index(" + keyName + ":" + plaintext + ")", nil
+}
+
+type EncryptStruct struct {
+	Id         int64
+	Email      string  `xorm:"encrypt(pii)"`
+	EmailIndex *string `xorm:"blindindex(email)"`
+}
+
+func TestEncryptValue(t *testing.T) {
+	statement := NewStatement(dialect, tagParser, time.Local)
+	bean := &EncryptStruct{Email: "a@b.com"}
+	table, err := tagParser.ParseWithCache(reflect.ValueOf(bean))
+	assert.NoError(t, err)
+	col := table.GetColumn("email")
+
+	_, err = statement.Value2Interface(col, reflect.ValueOf(bean.Email))
+	assert.EqualValues(t, ErrNoEncrypter, err)
+
+	statement.SetEncrypter(testEncrypter{})
+	v, err := statement.Value2Interface(col, reflect.ValueOf(bean.Email))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "pii:a@b.com", v)
+
+	assert.NoError(t, statement.SetBlindIndexes(table, bean))
+	assert.NotNil(t, bean.EmailIndex)
+	assert.EqualValues(t, "index(pii:a@b.com)", *bean.EmailIndex)
+
+	bean.Email = ""
+	assert.NoError(t, statement.SetBlindIndexes(table, bean))
+	assert.Nil(t, bean.EmailIndex)
+
+	assert.Error(t, statement.SetBlindIndexes(table, EncryptStruct{Email: "a@b.com"}))
+}
+
+func TestEncryptMapValues(t *testing.T) {
+	statement := NewStatement(dialect, tagParser, time.Local)
+	table, err := tagParser.ParseWithCache(reflect.ValueOf(new(EncryptStruct)))
+	assert.NoError(t, err)
+
+	_, _, err = statement.EncryptMapValues(table, []string{"email"}, []interface{}{"a@b.com"})
+	assert.EqualValues(t, ErrNoEncrypter, err)
+
+	statement.SetEncrypter(testEncrypter{})
+	columns, values, err := statement.EncryptMapValues(table, []string{"id", "email"}, []interface{}{1, "a@b.com"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"id", "email", "email_index"}, columns)
+	assert.EqualValues(t, []interface{}{1, "pii:a@b.com", "index(pii:a@b.com)"}, values)
+
+	columns, values, err = statement.EncryptMapValues(table, []string{"email_index", "email"}, []interface{}{"stale", nil})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"email_index", "email"}, columns)
+	assert.EqualValues(t, []interface{}{nil, nil}, values)
+
+	columns, values, err = statement.EncryptMapValues(table, []string{"id"}, []interface{}{1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"id"}, columns)
+	assert.EqualValues(t, []interface{}{1}, values)
+}
@@ -13,6 +13,10 @@ import (
 )
 
 func (statement *Statement) writeInsertOutput(buf *strings.Builder, table *schemas.Table) error {
+	if statement.IsReturning {
+		_, err := buf.WriteString(statement.GenOutputSQL("INSERTED"))
+		return err
+	}
 	if statement.dialect.URI().DBType == schemas.MSSQL && len(table.AutoIncrement) > 0 {
 		if _, err := buf.WriteString(" OUTPUT Inserted."); err != nil {
 			return err
@@ -128,7 +132,11 @@ func (statement *Statement) GenInsertSQL(colNames []string, args []interface{})
 		}
 	}
 
-	if len(table.AutoIncrement) > 0 && statement.dialect.URI().DBType == schemas.POSTGRES {
+	if statement.IsReturning {
+		if _, err := buf.WriteString(statement.GenReturningSQL()); err != nil {
+			return "", nil, err
+		}
+	} else if len(table.AutoIncrement) > 0 && statement.dialect.URI().DBType == schemas.POSTGRES {
 		if _, err := buf.WriteString(" RETURNING "); err != nil {
 			return "", nil, err
 		}
@@ -0,0 +1,60 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/xormplus/xorm/dialects"
+	"github.com/xormplus/xorm/internal/utils"
+	"github.com/xormplus/xorm/schemas"
+)
+
+// JoinTable represents a table joined by a bean
+type JoinTable struct {
+	Table *schemas.Table
+	// Name is the alias of the table or its name, which qualifies its columns
+	Name string
+}
+
+// JoinTables returns the tables joined by beans in order
+func (statement *Statement) JoinTables() []JoinTable {
+	return statement.joinTables
+}
+
+// joinTableOf returns the table if tablename of Join is a bean or a bean and its alias,
+// otherwise nil
+func (statement *Statement) joinTableOf(tablename interface{}) (*JoinTable, error) {
+	var (
+		bean  = tablename
+		alias string
+	)
+	if t, ok := tablename.([]interface{}); ok {
+		if len(t) == 0 {
+			return nil, nil
+		}
+		bean = t[0]
+		if len(t) > 1 {
+			alias = fmt.Sprintf("%v", t[1])
+		}
+	}
+	if _, ok := bean.(string); ok {
+		return nil, nil
+	}
+
+	v := utils.ReflectValue(bean)
+	if v.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	table, err := statement.tagParser.ParseWithCache(v)
+	if err != nil {
+		return nil, err
+	}
+	if alias == "" {
+		alias = dialects.FullTableName(statement.dialect, statement.tagParser.GetTableMapper(), bean, true)
+	}
+	return &JoinTable{Table: table, Name: alias}, nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xormplus/builder"
+)
+
+// ErrInvalidCursor represents an error when a cursor of keyset pagination could not be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// KeysetOrder represents an ordering column of keyset pagination
+type KeysetOrder struct {
+	Column string
+	Desc   bool
+}
+
+// Name returns the column name without the table and the quotes
+func (order KeysetOrder) Name() string {
+	name := order.Column
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		name = name[idx+1:]
+	}
+	return strings.Trim(name, "`\"[]")
+}
+
+// ParseKeysetOrders parses the ordering columns, i.e. "created DESC" and "id"
+func ParseKeysetOrders(orderCols []string) ([]KeysetOrder, error) {
+	var orders = make([]KeysetOrder, 0, len(orderCols))
+	for _, orderCol := range orderCols {
+		fields := strings.Fields(orderCol)
+		switch {
+		case len(fields) == 1:
+			orders = append(orders, KeysetOrder{Column: fields[0]})
+		case len(fields) == 2 && strings.EqualFold(fields[1], "ASC"):
+			orders = append(orders, KeysetOrder{Column: fields[0]})
+		case len(fields) == 2 && strings.EqualFold(fields[1], "DESC"):
+			orders = append(orders, KeysetOrder{Column: fields[0], Desc: true})
+		default:
+			return nil, fmt.Errorf("unsupported keyset order %s", orderCol)
+		}
+	}
+	return orders, nil
+}
+
+// Cursor represents the position of keyset pagination, which is the values of the ordering
+// columns of a row, the rows after it are read, or the ones before it if Backward is true
+type Cursor struct {
+	Values   []interface{}
+	Backward bool
+}
+
+// cursorValue is a typed value of an encoded cursor which keeps the type of the value
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v,omitempty"`
+}
+
+type cursorToken struct {
+	Backward bool          `json:"b,omitempty"`
+	Values   []cursorValue `json:"v"`
+}
+
+// EncodeCursor encodes the cursor to an opaque token
+func EncodeCursor(cursor Cursor) (string, error) {
+	var token = cursorToken{Backward: cursor.Backward, Values: make([]cursorValue, 0, len(cursor.Values))}
+	for _, v := range cursor.Values {
+		var value cursorValue
+		switch t := v.(type) {
+		case nil:
+			value.Type = "n"
+		case bool:
+			value = cursorValue{"b", strconv.FormatBool(t)}
+		case int, int8, int16, int32, int64:
+			value = cursorValue{"i", fmt.Sprint(t)}
+		case uint, uint8, uint16, uint32, uint64:
+			value = cursorValue{"u", fmt.Sprint(t)}
+		case float32:
+			value = cursorValue{"f", strconv.FormatFloat(float64(t), 'g', -1, 32)}
+		case float64:
+			value = cursorValue{"f", strconv.FormatFloat(t, 'g', -1, 64)}
+		case string:
+			value = cursorValue{"s", t}
+		case []byte:
+			value = cursorValue{"s", string(t)}
+		case time.Time:
+			value = cursorValue{"t", t.Format(time.RFC3339Nano)}
+		default:
+			return "", fmt.Errorf("unsupported cursor value type %T", v)
+		}
+		token.Values = append(token.Values, value)
+	}
+
+	bs, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bs), nil
+}
+
+// DecodeCursor decodes a token encoded by EncodeCursor, an empty token is decoded to nil
+// which means the first page
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	bs, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var token cursorToken
+	if err := json.Unmarshal(bs, &token); err != nil || len(token.Values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor = Cursor{Backward: token.Backward, Values: make([]interface{}, 0, len(token.Values))}
+	for _, value := range token.Values {
+		var (
+			v   interface{}
+			err error
+		)
+		switch value.Type {
+		case "n":
+		case "b":
+			v, err = strconv.ParseBool(value.Value)
+		case "i":
+			v, err = strconv.ParseInt(value.Value, 10, 64)
+		case "u":
+			v, err = strconv.ParseUint(value.Value, 10, 64)
+		case "f":
+			v, err = strconv.ParseFloat(value.Value, 64)
+		case "s":
+			v = value.Value
+		case "t":
+			v, err = time.Parse(time.RFC3339Nano, value.Value)
+		default:
+			err = ErrInvalidCursor
+		}
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		cursor.Values = append(cursor.Values, v)
+	}
+	return &cursor, nil
+}
+
+// Keyset orders the statement by the ordering columns, which are reversed if the cursor is
+// backward, limits it to limit rows and adds the condition of the rows after the cursor.
+// The ordering columns should identify a row and should not be NULL.
+func (statement *Statement) Keyset(orders []KeysetOrder, cursor *Cursor, limit int) error {
+	if len(orders) == 0 {
+		return errors.New("keyset pagination needs ordering columns")
+	}
+	if cursor != nil && len(cursor.Values) != len(orders) {
+		return ErrInvalidCursor
+	}
+	var backward = cursor != nil && cursor.Backward
+
+	statement.OrderStr = ""
+	for _, order := range orders {
+		if order.Desc != backward {
+			statement.Desc(order.Column)
+		} else {
+			statement.Asc(order.Column)
+		}
+	}
+	statement.Limit(limit)
+
+	if cursor == nil {
+		return nil
+	}
+	// (a > ?) OR (a = ? AND b > ?) OR (a = ? AND b = ? AND c > ?)
+	var conds = make([]builder.Cond, 0, len(orders))
+	for i, order := range orders {
+		var cond = builder.NewCond()
+		for j := 0; j < i; j++ {
+			cond = cond.And(builder.Eq{statement.quote(orders[j].Column): cursor.Values[j]})
+		}
+		colName := statement.quote(order.Column)
+		if order.Desc != backward {
+			cond = cond.And(builder.Lt{colName: cursor.Values[i]})
+		} else {
+			cond = cond.And(builder.Gt{colName: cursor.Values[i]})
+		}
+		conds = append(conds, cond)
+	}
+	if len(conds) == 1 {
+		statement.cond = statement.cond.And(conds[0])
+	} else {
+		statement.cond = statement.cond.And(builder.Or(conds...))
+	}
+	return nil
+}
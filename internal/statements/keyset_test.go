@@ -0,0 +1,76 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xormplus/xorm/schemas"
+)
+
+func TestParseKeysetOrders(t *testing.T) {
+	orders, err := ParseKeysetOrders([]string{"created DESC", "u.id", "name asc"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []KeysetOrder{{"created", true}, {"u.id", false}, {"name", false}}, orders)
+	assert.EqualValues(t, "id", orders[1].Name())
+
+	_, err = ParseKeysetOrders([]string{"id DESC NULLS LAST"})
+	assert.Error(t, err)
+}
+
+func TestCursor(t *testing.T) {
+	created := time.Date(2026, 10, 18, 8, 30, 0, 123, time.UTC)
+	var cursor = Cursor{Values: []interface{}{int64(1) << 60, uint8(3), 1.5, "a b", []byte("c"), true, created, nil}, Backward: true}
+	token, err := EncodeCursor(cursor)
+	assert.NoError(t, err)
+
+	decoded, err := DecodeCursor(token)
+	assert.NoError(t, err)
+	assert.True(t, decoded.Backward)
+	assert.EqualValues(t, []interface{}{int64(1) << 60, uint64(3), 1.5, "a b", "c", true, created, nil}, decoded.Values)
+
+	decoded, err = DecodeCursor("")
+	assert.NoError(t, err)
+	assert.Nil(t, decoded)
+
+	_, err = DecodeCursor("not a cursor")
+	assert.EqualValues(t, ErrInvalidCursor, err)
+
+	_, err = EncodeCursor(Cursor{Values: []interface{}{struct{}{}}})
+	assert.Error(t, err)
+}
+
+func TestKeyset(t *testing.T) {
+	orders := []KeysetOrder{{Column: "age", Desc: true}, {Column: "id"}}
+
+	statement := createUpsertStatement(t, schemas.SQLITE)
+	assert.NoError(t, statement.Keyset(orders, nil, 11))
+	sqlStr, args, err := statement.GenCountSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT count(*) FROM `upsert_user`", sqlStr)
+	assert.EqualValues(t, 0, len(args))
+	assert.EqualValues(t, "`age` DESC, `id` ASC", statement.OrderStr)
+	assert.EqualValues(t, 11, *statement.LimitN)
+
+	statement = createUpsertStatement(t, schemas.SQLITE)
+	statement.Where("name = ?", "a")
+	assert.NoError(t, statement.Keyset(orders, &Cursor{Values: []interface{}{18, 5}}, 11))
+	sqlStr, args, err = statement.GenCountSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT count(*) FROM `upsert_user` WHERE (name = ?) AND ((`age`<?) OR (`age`=? AND `id`>?))", sqlStr)
+	assert.EqualValues(t, []interface{}{"a", 18, 18, 5}, args)
+
+	statement = createUpsertStatement(t, schemas.SQLITE)
+	assert.NoError(t, statement.Keyset(orders, &Cursor{Values: []interface{}{18, 5}, Backward: true}, 11))
+	sqlStr, _, err = statement.GenCountSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT count(*) FROM `upsert_user` WHERE ((`age`>?) OR (`age`=? AND `id`<?))", sqlStr)
+	assert.EqualValues(t, "`age` ASC, `id` DESC", statement.OrderStr)
+
+	statement = createUpsertStatement(t, schemas.SQLITE)
+	assert.EqualValues(t, ErrInvalidCursor, statement.Keyset(orders, &Cursor{Values: []interface{}{18}}, 11))
+}
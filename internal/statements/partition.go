@@ -0,0 +1,153 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/xormplus/builder"
+	"github.com/xormplus/xorm/dialects"
+	"github.com/xormplus/xorm/schemas"
+)
+
+// ErrPartitionsRange represents the range of the partitions is not a non-empty range
+// with both bounds
+var ErrPartitionsRange = errors.New("partitions need both bounds and from before to")
+
+// checkPartitionsRange returns ErrPartitionsRange unless both bounds are given and from is
+// before to, the partitions of an open range are not enumerated
+func checkPartitionsRange(from, to time.Time) error {
+	if from.IsZero() || to.IsZero() || !from.Before(to) {
+		return ErrPartitionsRange
+	}
+	return nil
+}
+
+// Partitions limits the reads to the partitions of the partitioned table holding the
+// records in [from, to). The names of the partitions are passed to filter which returns
+// the existing ones, it could be nil. Both bounds are needed and from should be before to.
+func (statement *Statement) Partitions(from, to time.Time, filter func([]string) ([]string, error)) *Statement {
+	statement.partitionFrom = from
+	statement.partitionTo = to
+	statement.partitionFilter = filter
+	if err := checkPartitionsRange(from, to); err != nil {
+		statement.LastError = err
+	}
+	return statement
+}
+
+// HasPartitions returns true if the reads are limited to the partitions in a range
+func (statement *Statement) HasPartitions() bool {
+	return !statement.partitionFrom.IsZero() || !statement.partitionTo.IsZero()
+}
+
+// partitionTime converts t to the time zone the partition column is stored in
+func (statement *Statement) partitionTime(col *schemas.Column, t time.Time) time.Time {
+	if col.TimeZone != nil {
+		return t.In(col.TimeZone)
+	}
+	if statement.defaultTimeZone != nil {
+		return t.In(statement.defaultTimeZone)
+	}
+	return t
+}
+
+// PartitionTableNames returns the names of the partitions of table holding the records
+// in [from, to), the names of a table which is not partitioned is itself
+func (statement *Statement) PartitionTableNames(table *schemas.Table, from, to time.Time) []string {
+	col := table.PartitionColumn()
+	if col == nil {
+		return []string{table.Name}
+	}
+	return schemas.PartitionTableNames(table.Name, col.PartitionPeriod,
+		statement.partitionTime(col, from), statement.partitionTime(col, to))
+}
+
+// RoutePartition sets the table of the statement to the partition holding t if the table
+// is partitioned, a zero t or a table given by Table is kept
+func (statement *Statement) RoutePartition(t time.Time) {
+	if statement.RefTable == nil || statement.AltTableName != "" || t.IsZero() {
+		return
+	}
+	col := statement.RefTable.PartitionColumn()
+	if col == nil {
+		return
+	}
+	tableName := schemas.PartitionTableName(statement.RefTable.Name, col.PartitionPeriod, statement.partitionTime(col, t))
+	statement.tableName = dialects.TableNameWithSchema(statement.dialect, tableName)
+}
+
+// routePartitionV routes the statement by the partition column of the struct value
+func (statement *Statement) routePartitionV(v reflect.Value) error {
+	col := statement.RefTable.PartitionColumn()
+	if col == nil || v.Kind() != reflect.Struct {
+		return nil
+	}
+	fieldValue, err := col.ValueOfV(&v)
+	if err != nil {
+		return err
+	}
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			return nil
+		}
+		*fieldValue = fieldValue.Elem()
+	}
+	if !fieldValue.Type().ConvertibleTo(schemas.TimeType) {
+		return nil
+	}
+	statement.RoutePartition(fieldValue.Convert(schemas.TimeType).Interface().(time.Time))
+	return nil
+}
+
+// genPartitionsFrom generates the existing partitions in the range as one table and the
+// range condition
+func (statement *Statement) genPartitionsFrom() (string, builder.Cond, error) {
+	if statement.RefTable == nil || statement.RefTable.PartitionColumn() == nil {
+		return "", nil, errors.New("partitions need a table with a partition column")
+	}
+	if err := checkPartitionsRange(statement.partitionFrom, statement.partitionTo); err != nil {
+		return "", nil, err
+	}
+	var (
+		table = statement.RefTable
+		col   = table.PartitionColumn()
+		from  = statement.partitionFrom
+		to    = statement.partitionTo
+		names = statement.PartitionTableNames(table, from, to)
+		err   error
+	)
+	if statement.partitionFilter != nil {
+		names, err = statement.partitionFilter(names)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	if len(names) == 0 {
+		return "", nil, fmt.Errorf("no partition of %s exists between %v and %v", table.Name, from, to)
+	}
+
+	var fromStr string
+	if len(names) == 1 {
+		fromStr = statement.quote(dialects.TableNameWithSchema(statement.dialect, names[0]))
+	} else {
+		var selects = make([]string, 0, len(names))
+		for _, name := range names {
+			selects = append(selects, "SELECT * FROM "+statement.quote(dialects.TableNameWithSchema(statement.dialect, name)))
+		}
+		fromStr = "(" + strings.Join(selects, " UNION ALL ") + ")"
+	}
+
+	colName := statement.colName(col, table.Name)
+	cond := builder.And(
+		builder.Gte{colName: dialects.FormatColumnTime(statement.dialect, statement.defaultTimeZone, col, from)},
+		builder.Lt{colName: dialects.FormatColumnTime(statement.dialect, statement.defaultTimeZone, col, to)},
+	)
+	return fromStr, cond, nil
+}
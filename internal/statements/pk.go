@@ -73,6 +73,11 @@ func (statement *Statement) ID(id interface{}) *Statement {
 	return statement
 }
 
+// IDParam returns the primary key values given by ID
+func (statement *Statement) IDParam() schemas.PK {
+	return statement.idParam
+}
+
 // ProcessIDParam handles the process of id condition
 func (statement *Statement) ProcessIDParam() error {
 	if statement.idParam == nil {
@@ -150,17 +150,10 @@ func (statement *Statement) GenQuerySQL(sqlOrArgs ...interface{}) (string, []int
 		return "", nil, err
 	}
 
-	sqlStr, condArgs, err := statement.genSelectSQL(columnStr, true, true)
+	sqlStr, args, err := statement.genSelectSQL(columnStr, true, true)
 	if err != nil {
 		return "", nil, err
 	}
-	args := append(statement.joinArgs, condArgs...)
-
-	// for mssql and use limit
-	qs := strings.Count(sqlStr, "?")
-	if len(args)*2 == qs {
-		args = append(args, args...)
-	}
 
 	return sqlStr, args, nil
 }
@@ -187,12 +180,7 @@ func (statement *Statement) GenSumSQL(bean interface{}, columns ...string) (stri
 		return "", nil, err
 	}
 
-	sqlStr, condArgs, err := statement.genSelectSQL(sumSelect, true, true)
-	if err != nil {
-		return "", nil, err
-	}
-
-	return sqlStr, append(statement.joinArgs, condArgs...), nil
+	return statement.genSelectSQL(sumSelect, true, true)
 }
 
 func (statement *Statement) GenGetSQL(bean interface{}) (string, []interface{}, error) {
@@ -238,12 +226,7 @@ func (statement *Statement) GenGetSQL(bean interface{}) (string, []interface{},
 		}
 	}
 
-	sqlStr, condArgs, err := statement.genSelectSQL(columnStr, true, true)
-	if err != nil {
-		return "", nil, err
-	}
-
-	return sqlStr, append(statement.joinArgs, condArgs...), nil
+	return statement.genSelectSQL(columnStr, true, true)
 }
 
 // GenCountSQL generates the SQL for counting
@@ -252,8 +235,6 @@ func (statement *Statement) GenCountSQL(beans ...interface{}) (string, []interfa
 		return statement.GenRawSQL(), statement.RawParams, nil
 	}
 
-	var condArgs []interface{}
-	var err error
 	if len(beans) > 0 {
 		statement.SetRefBean(beans[0])
 		if err := statement.mergeConds(beans[0]); err != nil {
@@ -271,12 +252,7 @@ func (statement *Statement) GenCountSQL(beans ...interface{}) (string, []interfa
 			selectSQL = "count(*)"
 		}
 	}
-	sqlStr, condArgs, err := statement.genSelectSQL(selectSQL, false, false)
-	if err != nil {
-		return "", nil, err
-	}
-
-	return sqlStr, append(statement.joinArgs, condArgs...), nil
+	return statement.genSelectSQL(selectSQL, false, false)
 }
 
 func (statement *Statement) genSelectSQL(columnStr string, needLimit, needOrderBy bool) (string, []interface{}, error) {
@@ -286,12 +262,35 @@ func (statement *Statement) genSelectSQL(columnStr string, needLimit, needOrderB
 		quote                     = statement.quote
 		fromStr                   = " FROM "
 		top, mssqlCondi, whereStr string
+		cond                      = statement.cond
+		tableAlias                = statement.TableAlias
 	)
 	if statement.IsDistinct && !strings.HasPrefix(columnStr, "count") {
 		distinct = "DISTINCT "
 	}
 
-	condSQL, condArgs, err := statement.GenCondSQL(statement.cond)
+	if statement.fromSQL != "" {
+		fromStr += "(" + statement.fromSQL + ")"
+		if tableAlias == "" {
+			tableAlias = statement.TableName()
+		}
+	} else if statement.HasPartitions() {
+		partitionsStr, partitionCond, err := statement.genPartitionsFrom()
+		if err != nil {
+			return "", nil, err
+		}
+		fromStr += partitionsStr
+		if tableAlias == "" {
+			tableAlias = statement.RefTable.Name
+		}
+		cond = cond.And(partitionCond)
+	} else if dialect.URI().DBType == schemas.MSSQL && strings.Contains(statement.TableName(), "..") {
+		fromStr += statement.TableName()
+	} else {
+		fromStr += quote(statement.TableName())
+	}
+
+	condSQL, condArgs, err := statement.GenCondSQL(cond)
 	if err != nil {
 		return "", nil, err
 	}
@@ -299,17 +298,11 @@ func (statement *Statement) genSelectSQL(columnStr string, needLimit, needOrderB
 		whereStr = " WHERE " + condSQL
 	}
 
-	if dialect.URI().DBType == schemas.MSSQL && strings.Contains(statement.TableName(), "..") {
-		fromStr += statement.TableName()
-	} else {
-		fromStr += quote(statement.TableName())
-	}
-
-	if statement.TableAlias != "" {
+	if tableAlias != "" {
 		if dialect.URI().DBType == schemas.ORACLE {
-			fromStr += " " + quote(statement.TableAlias)
+			fromStr += " " + quote(tableAlias)
 		} else {
-			fromStr += " AS " + quote(statement.TableAlias)
+			fromStr += " AS " + quote(tableAlias)
 		}
 	}
 	if statement.JoinStr != "" {
@@ -402,11 +395,25 @@ func (statement *Statement) genSelectSQL(columnStr string, needLimit, needOrderB
 			}
 		}
 	}
+
+	// the args follow the order of the WITH clause, the sub query of FROM, JOIN and WHERE
+	withStr, args := statement.genWithSQL()
+	var selectArgs = make([]interface{}, 0, len(statement.fromArgs)+len(statement.joinArgs)+len(condArgs))
+	selectArgs = append(selectArgs, statement.fromArgs...)
+	selectArgs = append(selectArgs, statement.joinArgs...)
+	selectArgs = append(selectArgs, condArgs...)
+	args = append(args, selectArgs...)
+	// the sub query of mssql offset repeats FROM, JOIN and WHERE but not the WITH clause
+	if len(mssqlCondi) > 0 {
+		args = append(args, selectArgs...)
+	}
+
+	sqlStr := withStr + buf.String()
 	if statement.IsForUpdate {
-		return dialect.ForUpdateSQL(buf.String()), condArgs, nil
+		return dialect.ForUpdateSQL(sqlStr), args, nil
 	}
 
-	return buf.String(), condArgs, nil
+	return sqlStr, args, nil
 }
 
 func (statement *Statement) GenExistSQL(bean ...interface{}) (string, []interface{}, error) {
@@ -519,16 +526,10 @@ func (statement *Statement) GenFindSQL(autoCond builder.Cond) (string, []interfa
 
 	statement.cond = statement.cond.And(autoCond)
 
-	sqlStr, condArgs, err := statement.genSelectSQL(columnStr, true, true)
+	sqlStr, args, err = statement.genSelectSQL(columnStr, true, true)
 	if err != nil {
 		return "", nil, err
 	}
-	args = append(statement.joinArgs, condArgs...)
-	// for mssql and use limit
-	qs := strings.Count(sqlStr, "?")
-	if len(args)*2 == qs {
-		args = append(args, args...)
-	}
 
 	return sqlStr, args, nil
 }
@@ -0,0 +1,102 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"strings"
+
+	"github.com/xormplus/xorm/dialects"
+	"github.com/xormplus/xorm/schemas"
+)
+
+// Returning asks insert, update and delete to fill the beans with the given columns
+// of the affected rows, or all the readable columns if none is given
+func (statement *Statement) Returning(columns ...string) *Statement {
+	statement.IsReturning = true
+	statement.ReturningColumns = append(statement.ReturningColumns, col2NewCols(columns...)...)
+	return statement
+}
+
+// SupportReturning returns true if the dialect could return the affected rows in the same
+// statement, otherwise the rows have to be selected again by the primary keys, i.e. on mysql
+// and sqlite3 older than 3.35.0
+func (statement *Statement) SupportReturning() bool {
+	if statement.dialect.URI().DBType == schemas.MSSQL {
+		return true
+	}
+	return dialects.SupportReturning(statement.dialect)
+}
+
+// ReturningColumnNames returns the names of the columns which will be returned. The
+// autoincrement column is always returned so that it can be filled after insert.
+func (statement *Statement) ReturningColumnNames() []string {
+	var (
+		table = statement.RefTable
+		cols  = make([]string, 0, len(table.ColumnsSeq()))
+	)
+	if len(statement.ReturningColumns) > 0 {
+		for _, colName := range statement.ReturningColumns {
+			if col := table.GetColumn(colName); col != nil {
+				colName = col.Name
+			}
+			cols = append(cols, colName)
+		}
+	} else {
+		for _, col := range table.Columns() {
+			if col.MapType != schemas.ONLYTODB {
+				cols = append(cols, col.Name)
+			}
+		}
+	}
+
+	if table.AutoIncrement != "" && !columnMap(cols).Contain(table.AutoIncrement) {
+		cols = append([]string{table.AutoIncrement}, cols...)
+	}
+	return cols
+}
+
+// GenReturningSQL generates the RETURNING clause which should be appended to the end
+// of the SQL on postgres, sqlite3 3.35.0 or later and oracle. On oracle the returned values
+// are bound to the out parameters, one for each column of ReturningColumnNames.
+func (statement *Statement) GenReturningSQL() string {
+	if !statement.IsReturning || !statement.SupportReturning() {
+		return ""
+	}
+
+	var (
+		quoter = statement.dialect.Quoter()
+		cols   = statement.ReturningColumnNames()
+	)
+	switch statement.dialect.URI().DBType {
+	case schemas.POSTGRES, schemas.SQLITE:
+		return " RETURNING " + quoter.Join(cols, ",")
+	case schemas.ORACLE:
+		return " RETURNING " + quoter.Join(cols, ",") + " INTO " +
+			strings.TrimSuffix(strings.Repeat("?,", len(cols)), ",")
+	}
+	return ""
+}
+
+// GenOutputSQL generates the OUTPUT clause of mssql, prefix should be INSERTED or DELETED
+func (statement *Statement) GenOutputSQL(prefix string) string {
+	if !statement.IsReturning || statement.dialect.URI().DBType != schemas.MSSQL {
+		return ""
+	}
+
+	var (
+		quoter = statement.dialect.Quoter()
+		buf    strings.Builder
+	)
+	buf.WriteString(" OUTPUT ")
+	for i, col := range statement.ReturningColumnNames() {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString(prefix)
+		buf.WriteString(".")
+		buf.WriteString(quoter.Quote(col))
+	}
+	return buf.String()
+}
@@ -0,0 +1,47 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xormplus/xorm/schemas"
+)
+
+func TestReturningColumnNames(t *testing.T) {
+	statement := createUpsertStatement(t, schemas.POSTGRES)
+	statement.Returning()
+	assert.EqualValues(t, []string{"id", "name", "age", "created", "version"}, statement.ReturningColumnNames())
+
+	statement = createUpsertStatement(t, schemas.POSTGRES)
+	statement.Returning("`age`, created")
+	assert.EqualValues(t, []string{"id", "age", "created"}, statement.ReturningColumnNames())
+}
+
+func TestGenReturningSQL(t *testing.T) {
+	var kases = []struct {
+		dbType    schemas.DBType
+		returning string
+		output    string
+	}{
+		{schemas.MYSQL, "", ""},
+		{schemas.SQLITE, " RETURNING `id`,`age`", ""},
+		{schemas.POSTGRES, ` RETURNING "id","age"`, ""},
+		{schemas.MSSQL, "", " OUTPUT DELETED.[id],DELETED.[age]"},
+		{schemas.ORACLE, ` RETURNING "id","age" INTO ?,?`, ""},
+	}
+
+	for _, kase := range kases {
+		statement := createUpsertStatement(t, kase.dbType)
+		assert.EqualValues(t, "", statement.GenReturningSQL())
+		assert.EqualValues(t, "", statement.GenOutputSQL("DELETED"))
+
+		statement.Returning("age")
+		assert.EqualValues(t, kase.dbType != schemas.MYSQL, statement.SupportReturning())
+		assert.EqualValues(t, kase.returning, statement.GenReturningSQL())
+		assert.EqualValues(t, kase.output, statement.GenOutputSQL("DELETED"))
+	}
+}
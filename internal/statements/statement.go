@@ -39,12 +39,18 @@ type Statement struct {
 	dialect          dialects.Dialect
 	defaultTimeZone  *time.Location
 	tagParser        *tags.Parser
+	encrypter        Encrypter
 	Start            int
 	LimitN           *int
 	idParam          schemas.PK
 	OrderStr         string
 	JoinStr          string
 	joinArgs         []interface{}
+	joinTables       []JoinTable
+	commonTables     []commonTable
+	recursiveWith    bool
+	fromSQL          string
+	fromArgs         []interface{}
 	GroupByStr       string
 	HavingStr        string
 	SelectStr        string
@@ -55,13 +61,22 @@ type Statement struct {
 	RawParams        []interface{}
 	UseCascade       bool
 	UseAutoJoin      bool
+	Preloads         []string
 	StoreEngine      string
 	Charset          string
 	UseCache         bool
+	UseQueryCache    bool
+	QueryCacheTTL    time.Duration
+	QueryCacheTables []string
+	partitionFrom    time.Time
+	partitionTo      time.Time
+	partitionFilter  func([]string) ([]string, error)
 	UseAutoTime      bool
 	NoAutoCondition  bool
 	IsDistinct       bool
 	IsForUpdate      bool
+	IsReturning      bool
+	ReturningColumns []string
 	TableAlias       string
 	allUseBool       bool
 	CheckVersion     bool
@@ -131,8 +146,14 @@ func (statement *Statement) Reset() {
 	statement.LimitN = nil
 	statement.OrderStr = ""
 	statement.UseCascade = true
+	statement.Preloads = nil
 	statement.JoinStr = ""
 	statement.joinArgs = make([]interface{}, 0)
+	statement.joinTables = nil
+	statement.commonTables = nil
+	statement.recursiveWith = false
+	statement.fromSQL = ""
+	statement.fromArgs = nil
 	statement.GroupByStr = ""
 	statement.HavingStr = ""
 	statement.ColumnMap = columnMap{}
@@ -144,10 +165,18 @@ func (statement *Statement) Reset() {
 	statement.RawSQL = ""
 	statement.RawParams = make([]interface{}, 0)
 	statement.UseCache = true
+	statement.UseQueryCache = false
+	statement.QueryCacheTTL = 0
+	statement.QueryCacheTables = nil
+	statement.partitionFrom = time.Time{}
+	statement.partitionTo = time.Time{}
+	statement.partitionFilter = nil
 	statement.UseAutoTime = true
 	statement.NoAutoCondition = false
 	statement.IsDistinct = false
 	statement.IsForUpdate = false
+	statement.IsReturning = false
+	statement.ReturningColumns = nil
 	statement.TableAlias = ""
 	statement.SelectStr = ""
 	statement.allUseBool = false
@@ -289,7 +318,7 @@ func (statement *Statement) SetRefValue(v reflect.Value) error {
 		return err
 	}
 	statement.tableName = dialects.FullTableName(statement.dialect, statement.tagParser.GetTableMapper(), v, true)
-	return nil
+	return statement.routePartitionV(reflect.Indirect(v))
 }
 
 func rValue(bean interface{}) reflect.Value {
@@ -303,7 +332,7 @@ func (statement *Statement) SetRefBean(bean interface{}) error {
 		return err
 	}
 	statement.tableName = dialects.FullTableName(statement.dialect, statement.tagParser.GetTableMapper(), bean, true)
-	return nil
+	return statement.routePartitionV(rValue(bean))
 }
 
 func (statement *Statement) needTableName() bool {
@@ -568,6 +597,15 @@ func (statement *Statement) Join(joinOP string, tablename interface{}, condition
 			tbName = buf.String()
 		}
 		fmt.Fprintf(&buf, "%s ON %v", tbName, statement.ReplaceQuote(condition))
+
+		joinTable, err := statement.joinTableOf(tablename)
+		if err != nil {
+			statement.LastError = err
+			return statement
+		}
+		if joinTable != nil {
+			statement.joinTables = append(statement.joinTables, *joinTable)
+		}
 	}
 
 	statement.JoinStr = buf.String()
@@ -715,7 +753,7 @@ func (statement *Statement) buildConds2(table *schemas.Table, bean interface{},
 			col.SQLType.IsBlob() || col.SQLType.Name == schemas.TimeStampz) {
 			continue
 		}
-		if col.SQLType.IsJson() {
+		if col.SQLType.IsJson() || col.EncryptKey != "" {
 			continue
 		}
 
@@ -733,6 +771,17 @@ func (statement *Statement) buildConds2(table *schemas.Table, bean interface{},
 			continue
 		}
 
+		if col.BlindIndexOf != "" {
+			index, err := statement.blindIndex(table, col, bean)
+			if err != nil {
+				return nil, err
+			}
+			if index != "" {
+				conds = append(conds, builder.Eq{colName: index})
+				continue
+			}
+		}
+
 		fieldValuePtr, err := col.ValueOf(bean)
 		if err != nil {
 			if !strings.Contains(err.Error(), "is not valid") {
@@ -0,0 +1,122 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xormplus/builder"
+	"github.com/xormplus/xorm/schemas"
+)
+
+// commonTable represents a common table expression of the WITH clause
+type commonTable struct {
+	name string
+	sql  string
+	args []interface{}
+}
+
+// subQuerySQL returns the SQL and the args of a sub query, which is a SQL string with
+// args or a builder
+func (statement *Statement) subQuerySQL(query interface{}, args []interface{}) (string, []interface{}, error) {
+	switch tp := query.(type) {
+	case string:
+		return statement.ReplaceQuote(tp), args, nil
+	case *builder.Builder:
+		sql, args, err := tp.ToSQL()
+		if err != nil {
+			return "", nil, err
+		}
+		return statement.ReplaceQuote(sql), args, nil
+	case builder.Builder:
+		sql, args, err := tp.ToSQL()
+		if err != nil {
+			return "", nil, err
+		}
+		return statement.ReplaceQuote(sql), args, nil
+	}
+	return "", nil, fmt.Errorf("unsupported sub query type %T", query)
+}
+
+// With adds a common table expression named by name to the WITH clause, name could have
+// the columns, i.e. tree(id, parent_id). The query is a SQL string with args or a builder.
+// The WITH clause is RECURSIVE if recursive is true for any of the expressions.
+func (statement *Statement) With(name string, recursive bool, query interface{}, args ...interface{}) *Statement {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		statement.LastError = errors.New("common table expression needs a name")
+		return statement
+	}
+	sql, args, err := statement.subQuerySQL(query, args)
+	if err != nil {
+		statement.LastError = err
+		return statement
+	}
+
+	if idx := strings.Index(name, "("); idx > 0 {
+		name = statement.quote(strings.TrimSpace(name[:idx])) + " " + statement.ReplaceQuote(name[idx:])
+	} else {
+		name = statement.quote(name)
+	}
+	statement.commonTables = append(statement.commonTables, commonTable{name: name, sql: sql, args: args})
+	statement.recursiveWith = statement.recursiveWith || recursive
+	return statement
+}
+
+// From selects from the sub query instead of the table, the sub query is a SQL string with
+// args or a builder, and alias is the name of it which qualifies its columns.
+func (statement *Statement) From(query interface{}, alias string, args ...interface{}) *Statement {
+	alias = strings.TrimSpace(alias)
+	if alias == "" {
+		statement.LastError = errors.New("sub query of FROM needs an alias")
+		return statement
+	}
+	sql, args, err := statement.subQuerySQL(query, args)
+	if err != nil {
+		statement.LastError = err
+		return statement
+	}
+	statement.fromSQL = sql
+	statement.fromArgs = args
+	statement.AltTableName = alias
+	return statement
+}
+
+// HasSubQueries returns true if the statement has a WITH clause or selects from a sub query
+func (statement *Statement) HasSubQueries() bool {
+	return len(statement.commonTables) > 0 || statement.fromSQL != ""
+}
+
+// genWithSQL generates the WITH clause and its args, the RECURSIVE keyword is omitted by
+// SQL Server and Oracle whose common table expressions are recursive as they need
+func (statement *Statement) genWithSQL() (string, []interface{}) {
+	if len(statement.commonTables) == 0 {
+		return "", nil
+	}
+
+	var (
+		buf  strings.Builder
+		args []interface{}
+	)
+	buf.WriteString("WITH ")
+	if statement.recursiveWith {
+		switch statement.dialect.URI().DBType {
+		case schemas.MSSQL, schemas.ORACLE:
+		default:
+			buf.WriteString("RECURSIVE ")
+		}
+	}
+	for i, table := range statement.commonTables {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "%s AS (%s)", table.name, table.sql)
+		args = append(args, table.args...)
+	}
+	buf.WriteString(" ")
+	return buf.String(), args
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xormplus/builder"
+	"github.com/xormplus/xorm/schemas"
+)
+
+func TestGenWithSQL(t *testing.T) {
+	var kases = []struct {
+		dbType schemas.DBType
+		output string
+	}{
+		{schemas.SQLITE, "WITH RECURSIVE `tree` (`id`, `parent_id`) AS (SELECT id, parent_id FROM node WHERE id = ?), `big` AS (SELECT * FROM upsert_user WHERE age>?) SELECT count(*) FROM `big` WHERE (name = ?)"},
+		{schemas.POSTGRES, `WITH RECURSIVE "tree" ("id", "parent_id") AS (SELECT id, parent_id FROM node WHERE id = ?), "big" AS (SELECT * FROM upsert_user WHERE age>?) SELECT count(*) FROM "big" WHERE (name = ?)`},
+		{schemas.MSSQL, "WITH [tree] ([id], [parent_id]) AS (SELECT id, parent_id FROM node WHERE id = ?), [big] AS (SELECT * FROM upsert_user WHERE age>?) SELECT count(*) FROM [big] WHERE (name = ?)"},
+	}
+
+	for _, kase := range kases {
+		statement := createUpsertStatement(t, kase.dbType)
+		statement.With("tree(`id`, `parent_id`)", true, "SELECT id, parent_id FROM node WHERE id = ?", 1)
+		statement.With("big", false, builder.Select("*").From("upsert_user").Where(builder.Gt{"age": 18}))
+		assert.NoError(t, statement.LastError)
+		assert.True(t, statement.HasSubQueries())
+		assert.NoError(t, statement.SetTable("big"))
+		statement.Where("name = ?", "a")
+
+		sqlStr, args, err := statement.GenCountSQL()
+		assert.NoError(t, err)
+		assert.EqualValues(t, kase.output, sqlStr)
+		assert.EqualValues(t, []interface{}{1, 18, "a"}, args)
+	}
+}
+
+func TestGenWithSQLMssqlOffset(t *testing.T) {
+	statement := createUpsertStatement(t, schemas.MSSQL)
+	statement.With("big", false, "SELECT * FROM upsert_user WHERE age > ?", 18)
+	assert.NoError(t, statement.LastError)
+	assert.NoError(t, statement.SetTable("big"))
+	statement.Where("name = ?", "a")
+	statement.Limit(10, 5)
+
+	sqlStr, args, err := statement.GenFindSQL(nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, strings.Count(sqlStr, "?"), len(args))
+	assert.EqualValues(t, []interface{}{18, "a", "a"}, args)
+}
+
+func TestGenFromSQL(t *testing.T) {
+	statement := createUpsertStatement(t, schemas.SQLITE)
+	statement.From("SELECT * FROM upsert_user WHERE age > ?", "u", 18)
+	assert.NoError(t, statement.LastError)
+	statement.Join("INNER", "team", "team.id = u.id AND team.name = ?", "x")
+	statement.Where("u.name = ?", "a")
+	statement.Limit(10)
+
+	sqlStr, args, err := statement.GenCountSQL()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "SELECT count(*) FROM (SELECT * FROM upsert_user WHERE age > ?) AS `u` INNER JOIN `team` ON team.id = u.id AND team.name = ? WHERE (u.name = ?)", sqlStr)
+	assert.EqualValues(t, []interface{}{18, "x", "a"}, args)
+
+	statement = createUpsertStatement(t, schemas.SQLITE)
+	statement.From("SELECT 1", "")
+	assert.Error(t, statement.LastError)
+}
@@ -286,6 +286,11 @@ func (statement *Statement) BuildUpdates(tableValue reflect.Value,
 		}
 
 	APPEND:
+		if col.EncryptKey != "" {
+			if val, err = statement.encryptValue(col, val); err != nil {
+				return nil, nil, err
+			}
+		}
 		args = append(args, val)
 		colNames = append(colNames, fmt.Sprintf("%v = ?", statement.quote(col.Name)))
 	}
@@ -0,0 +1,216 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xormplus/xorm/schemas"
+)
+
+// ErrNeedConflictColumns represents an error when the dialect needs the conflict
+// columns of an upsert but they cannot be found
+var ErrNeedConflictColumns = errors.New("upsert needs conflict columns which are inserted")
+
+// UpsertConflictColumns returns the columns which will be used to detect the conflict,
+// the primary keys are used if no columns are given
+func (statement *Statement) UpsertConflictColumns(colNames []string, conflictCols []string) ([]string, error) {
+	if len(conflictCols) == 0 {
+		conflictCols = statement.RefTable.PrimaryKeys
+	}
+
+	var inserted = columnMap(colNames)
+	for _, col := range conflictCols {
+		if !inserted.Contain(col) {
+			// mysql uses all the unique keys of the table, so it doesn't care
+			if statement.dialect.URI().DBType == schemas.MYSQL {
+				return nil, nil
+			}
+			return nil, ErrNeedConflictColumns
+		}
+	}
+	if len(conflictCols) == 0 && statement.dialect.URI().DBType != schemas.MYSQL {
+		return nil, ErrNeedConflictColumns
+	}
+	return conflictCols, nil
+}
+
+// GenUpsertSQL generates insert or update SQL according the dialect. Every row of rows
+// contains the values of colNames, conflictCols are the columns of the primary or
+// unique key and updateCols the columns which will be updated when the row exists.
+func (statement *Statement) GenUpsertSQL(colNames []string, rows [][]interface{}, conflictCols, updateCols []string) (string, []interface{}, error) {
+	if len(colNames) == 0 || len(rows) == 0 {
+		return "", nil, errors.New("no columns or rows to upsert")
+	}
+
+	var (
+		tableName = statement.TableName()
+		quoter    = statement.dialect.Quoter()
+		buf       strings.Builder
+		args      = make([]interface{}, 0, len(colNames)*len(rows))
+	)
+	for _, row := range rows {
+		args = append(args, row...)
+	}
+
+	// refer the existing row with the table name without schema
+	target := tableName
+	if idx := strings.LastIndex(target, "."); idx > -1 {
+		target = target[idx+1:]
+	}
+
+	var placeholders = strings.TrimSuffix(strings.Repeat("?,", len(colNames)), ",")
+	var writeValues = func() {
+		for i := range rows {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("(")
+			buf.WriteString(placeholders)
+			buf.WriteString(")")
+		}
+	}
+
+	var isVersion = func(col string) bool {
+		return statement.CheckVersion && statement.RefTable.Version != "" &&
+			strings.EqualFold(col, statement.RefTable.Version)
+	}
+
+	switch statement.dialect.URI().DBType {
+	case schemas.MYSQL:
+		fmt.Fprintf(&buf, "INSERT INTO %s (%s) VALUES ", quoter.Quote(tableName), quoter.Join(colNames, ","))
+		writeValues()
+		buf.WriteString(" ON DUPLICATE KEY UPDATE ")
+		if len(updateCols) == 0 {
+			// keep the existing row untouched
+			fmt.Fprintf(&buf, "%s = %s", quoter.Quote(colNames[0]), quoter.Quote(colNames[0]))
+		}
+		for i, col := range updateCols {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if isVersion(col) {
+				fmt.Fprintf(&buf, "%s = %s + 1", quoter.Quote(col), quoter.Quote(col))
+			} else {
+				fmt.Fprintf(&buf, "%s = VALUES(%s)", quoter.Quote(col), quoter.Quote(col))
+			}
+		}
+	case schemas.POSTGRES, schemas.SQLITE:
+		fmt.Fprintf(&buf, "INSERT INTO %s (%s) VALUES ", quoter.Quote(tableName), quoter.Join(colNames, ","))
+		writeValues()
+		fmt.Fprintf(&buf, " ON CONFLICT (%s) ", quoter.Join(conflictCols, ","))
+		if len(updateCols) == 0 {
+			buf.WriteString("DO NOTHING")
+			break
+		}
+		buf.WriteString("DO UPDATE SET ")
+		for i, col := range updateCols {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			if isVersion(col) {
+				fmt.Fprintf(&buf, "%s = %s.%s + 1", quoter.Quote(col), quoter.Quote(target), quoter.Quote(col))
+			} else {
+				fmt.Fprintf(&buf, "%s = excluded.%s", quoter.Quote(col), quoter.Quote(col))
+			}
+		}
+	case schemas.MSSQL, schemas.ORACLE:
+		var isOracle = statement.dialect.URI().DBType == schemas.ORACLE
+		fmt.Fprintf(&buf, "MERGE INTO %s ", quoter.Quote(tableName))
+		if !isOracle {
+			buf.WriteString("AS ")
+		}
+		buf.WriteString("xorm_target USING (")
+		if isOracle {
+			for i := range rows {
+				if i > 0 {
+					buf.WriteString(" UNION ALL ")
+				}
+				buf.WriteString("SELECT ")
+				for j, col := range colNames {
+					if j > 0 {
+						buf.WriteString(", ")
+					}
+					buf.WriteString("? ")
+					buf.WriteString(quoter.Quote(col))
+				}
+				buf.WriteString(" FROM DUAL")
+			}
+			buf.WriteString(") xorm_source")
+		} else {
+			buf.WriteString("VALUES ")
+			writeValues()
+			fmt.Fprintf(&buf, ") AS xorm_source (%s)", quoter.Join(colNames, ","))
+		}
+
+		buf.WriteString(" ON (")
+		for i, col := range conflictCols {
+			if i > 0 {
+				buf.WriteString(" AND ")
+			}
+			fmt.Fprintf(&buf, "xorm_target.%s = xorm_source.%s", quoter.Quote(col), quoter.Quote(col))
+		}
+		buf.WriteString(")")
+
+		if len(updateCols) > 0 {
+			buf.WriteString(" WHEN MATCHED THEN UPDATE SET ")
+			for i, col := range updateCols {
+				if i > 0 {
+					buf.WriteString(", ")
+				}
+				if isVersion(col) {
+					fmt.Fprintf(&buf, "xorm_target.%s = xorm_target.%s + 1", quoter.Quote(col), quoter.Quote(col))
+				} else {
+					fmt.Fprintf(&buf, "xorm_target.%s = xorm_source.%s", quoter.Quote(col), quoter.Quote(col))
+				}
+			}
+		}
+
+		fmt.Fprintf(&buf, " WHEN NOT MATCHED THEN INSERT (%s) VALUES (", quoter.Join(colNames, ","))
+		for i, col := range colNames {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("xorm_source.")
+			buf.WriteString(quoter.Quote(col))
+		}
+		buf.WriteString(")")
+		if !isOracle {
+			// MERGE must be terminated by a semicolon on SQL Server
+			buf.WriteString(";")
+		}
+	default:
+		return "", nil, fmt.Errorf("upsert is not supported on %s", statement.dialect.URI().DBType)
+	}
+
+	return buf.String(), args, nil
+}
+
+// UpsertUpdateColumns returns the inserted columns which will be updated when the row exists.
+// Like Update, zero columns are ignored unless AllCols, Cols or MustCols is used; isZero reports
+// whether the column is zero on all the upserted rows.
+func (statement *Statement) UpsertUpdateColumns(colNames, conflictCols []string, isZero func(colName string) bool) []string {
+	var (
+		conflicts  = columnMap(conflictCols)
+		updateCols = make([]string, 0, len(colNames))
+	)
+	for _, colName := range colNames {
+		col := statement.RefTable.GetColumn(colName)
+		if col == nil || conflicts.Contain(colName) || col.IsPrimaryKey || col.IsAutoIncrement {
+			continue
+		}
+		if col.IsCreated && !statement.ColumnMap.Contain(colName) {
+			continue
+		}
+		if col.IsUpdated || (col.IsVersion && statement.CheckVersion) ||
+			statement.useAllCols || len(statement.ColumnMap) > 0 ||
+			statement.MustColumnMap[strings.ToLower(colName)] || !isZero(colName) {
+			updateCols = append(updateCols, colName)
+		}
+	}
+	return updateCols
+}
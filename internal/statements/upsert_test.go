@@ -0,0 +1,120 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package statements
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xormplus/xorm/dialects"
+	"github.com/xormplus/xorm/schemas"
+)
+
+type UpsertUser struct {
+	Id      int64
+	Name    string `xorm:"unique"`
+	Age     int
+	Created time.Time `xorm:"created"`
+	Version int       `xorm:"version"`
+}
+
+func createUpsertStatement(t *testing.T, dbType schemas.DBType) *Statement {
+	dialect := dialects.QueryDialect(dbType)
+	assert.NoError(t, dialect.Init(&dialects.URI{DBType: dbType}))
+
+	statement := NewStatement(dialect, tagParser, time.Local)
+	assert.NoError(t, statement.SetRefValue(reflect.ValueOf(UpsertUser{})))
+	return statement
+}
+
+func TestUpsertConflictColumns(t *testing.T) {
+	statement := createUpsertStatement(t, schemas.POSTGRES)
+	cols, err := statement.UpsertConflictColumns([]string{"id", "name"}, nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"id"}, cols)
+
+	_, err = statement.UpsertConflictColumns([]string{"name"}, nil)
+	assert.EqualValues(t, ErrNeedConflictColumns, err)
+
+	cols, err = statement.UpsertConflictColumns([]string{"name"}, []string{"name"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"name"}, cols)
+
+	statement = createUpsertStatement(t, schemas.MYSQL)
+	cols, err = statement.UpsertConflictColumns([]string{"name"}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, cols)
+}
+
+func TestUpsertUpdateColumns(t *testing.T) {
+	statement := createUpsertStatement(t, schemas.POSTGRES)
+	statement.CheckVersion = true
+	colNames := []string{"name", "age", "created", "version"}
+	isZero := func(colName string) bool {
+		return colName == "age"
+	}
+
+	assert.EqualValues(t, []string{"version"},
+		statement.UpsertUpdateColumns(colNames, []string{"name"}, isZero))
+
+	statement.MustCols("age")
+	assert.EqualValues(t, []string{"age", "version"},
+		statement.UpsertUpdateColumns(colNames, []string{"name"}, isZero))
+}
+
+func TestGenUpsertSQL(t *testing.T) {
+	var (
+		colNames   = []string{"name", "age", "version"}
+		rows       = [][]interface{}{{"a", 1, 1}, {"b", 2, 1}}
+		conflicts  = []string{"name"}
+		updateCols = []string{"age", "version"}
+	)
+
+	var kases = []struct {
+		dbType   schemas.DBType
+		expected string
+	}{
+		{
+			schemas.MYSQL,
+			"INSERT INTO `upsert_user` (`name`,`age`,`version`) VALUES (?,?,?),(?,?,?) ON DUPLICATE KEY UPDATE `age` = VALUES(`age`), `version` = `version` + 1",
+		},
+		{
+			schemas.SQLITE,
+			"INSERT INTO `upsert_user` (`name`,`age`,`version`) VALUES (?,?,?),(?,?,?) ON CONFLICT (`name`) DO UPDATE SET `age` = excluded.`age`, `version` = `upsert_user`.`version` + 1",
+		},
+		{
+			schemas.POSTGRES,
+			`INSERT INTO "upsert_user" ("name","age","version") VALUES (?,?,?),(?,?,?) ON CONFLICT ("name") DO UPDATE SET "age" = excluded."age", "version" = "upsert_user"."version" + 1`,
+		},
+		{
+			schemas.MSSQL,
+			"MERGE INTO [upsert_user] AS xorm_target USING (VALUES (?,?,?),(?,?,?)) AS xorm_source ([name],[age],[version]) ON (xorm_target.[name] = xorm_source.[name]) " +
+				"WHEN MATCHED THEN UPDATE SET xorm_target.[age] = xorm_source.[age], xorm_target.[version] = xorm_target.[version] + 1 " +
+				"WHEN NOT MATCHED THEN INSERT ([name],[age],[version]) VALUES (xorm_source.[name],xorm_source.[age],xorm_source.[version]);",
+		},
+		{
+			schemas.ORACLE,
+			`MERGE INTO "upsert_user" xorm_target USING (SELECT ? "name", ? "age", ? "version" FROM DUAL UNION ALL SELECT ? "name", ? "age", ? "version" FROM DUAL) xorm_source ON (xorm_target."name" = xorm_source."name") ` +
+				`WHEN MATCHED THEN UPDATE SET xorm_target."age" = xorm_source."age", xorm_target."version" = xorm_target."version" + 1 ` +
+				`WHEN NOT MATCHED THEN INSERT ("name","age","version") VALUES (xorm_source."name",xorm_source."age",xorm_source."version")`,
+		},
+	}
+
+	for _, kase := range kases {
+		statement := createUpsertStatement(t, kase.dbType)
+		statement.CheckVersion = true
+		sqlStr, args, err := statement.GenUpsertSQL(colNames, rows, conflicts, updateCols)
+		assert.NoError(t, err)
+		assert.EqualValues(t, kase.expected, sqlStr, string(kase.dbType))
+		assert.EqualValues(t, []interface{}{"a", 1, 1, "b", 2, 1}, args)
+	}
+
+	statement := createUpsertStatement(t, schemas.SQLITE)
+	sqlStr, _, err := statement.GenUpsertSQL(colNames, rows[:1], conflicts, nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "INSERT INTO `upsert_user` (`name`,`age`,`version`) VALUES (?,?,?) ON CONFLICT (`name`) DO NOTHING", sqlStr)
+}
@@ -21,8 +21,17 @@ var (
 	nullFloatType = reflect.TypeOf(sql.NullFloat64{})
 )
 
-// Value2Interface convert a field value of a struct to interface for puting into database
+// Value2Interface convert a field value of a struct to interface for puting into database,
+// the values of the columns tagged encrypt are encrypted
 func (statement *Statement) Value2Interface(col *schemas.Column, fieldValue reflect.Value) (interface{}, error) {
+	v, err := statement.value2Interface(col, fieldValue)
+	if err != nil || col.EncryptKey == "" {
+		return v, err
+	}
+	return statement.encryptValue(col, v)
+}
+
+func (statement *Statement) value2Interface(col *schemas.Column, fieldValue reflect.Value) (interface{}, error) {
 	if fieldValue.CanAddr() {
 		if fieldConvert, ok := fieldValue.Addr().Interface().(convert.Conversion); ok {
 			data, err := fieldConvert.ToDB()
@@ -5,6 +5,7 @@
 package utils
 
 import (
+	"regexp"
 	"strings"
 )
 
@@ -17,3 +18,42 @@ func IsSubQuery(tbName string) bool {
 	return strings.EqualFold(tbName[:len(selStr)], selStr) ||
 		strings.EqualFold(tbName[:len(selStr)+1], "("+selStr)
 }
+
+const tableNamePart = "(?:\"[^\"]+\"|`[^`]+`|\\[[^\\]]+\\]|[^\\s(),;.\"`\\[]+)"
+
+var tableNamePartRegexp = regexp.MustCompile(tableNamePart)
+
+var writtenTableRegexp = regexp.MustCompile(`(?is)(?:^|;)\s*(?:` +
+	`INSERT\s+(?:IGNORE\s+)?INTO|REPLACE\s+INTO|MERGE\s+INTO|UPDATE(?:\s+ONLY)?|DELETE\s+FROM|` +
+	`TRUNCATE(?:\s+TABLE)?|ALTER\s+TABLE|DROP\s+TABLE(?:\s+IF\s+EXISTS)?)\s+` +
+	`(` + tableNamePart + `(?:\.` + tableNamePart + `)*)`)
+
+// WrittenTables returns the tables written by the INSERT, REPLACE, MERGE, UPDATE, DELETE,
+// TRUNCATE, ALTER TABLE or DROP TABLE statements in sqlStr, the names are normalized by
+// BareTableName. A table written in a CTE or a subquery is not recognized.
+func WrittenTables(sqlStr string) []string {
+	var tables []string
+	for _, matches := range writtenTableRegexp.FindAllStringSubmatch(sqlStr, -1) {
+		name := BareTableName(matches[1])
+		var found bool
+		for _, table := range tables {
+			if table == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			tables = append(tables, name)
+		}
+	}
+	return tables
+}
+
+// BareTableName removes the quotes and the schema of a table name and converts it to lower case
+func BareTableName(name string) string {
+	parts := tableNamePartRegexp.FindAllString(name, -1)
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.ToLower(strings.Trim(parts[len(parts)-1], "\"`[]"))
+}
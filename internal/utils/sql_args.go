@@ -0,0 +1,238 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+type sqlTokenKind int
+
+const (
+	sqlIdent sqlTokenKind = iota
+	sqlKeyword
+	sqlPlaceholder
+	sqlOperator
+	sqlPunct
+	sqlOther
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// the keywords which are not recognized as the columns
+var sqlKeywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "AND": true, "OR": true, "NOT": true,
+	"IN": true, "LIKE": true, "ILIKE": true, "BETWEEN": true, "IS": true, "NULL": true,
+	"INSERT": true, "INTO": true, "VALUES": true, "UPDATE": true, "SET": true, "DELETE": true,
+	"ON": true, "DUPLICATE": true, "KEY": true, "CONFLICT": true, "DO": true, "RETURNING": true,
+	"OUTPUT": true, "ORDER": true, "GROUP": true, "BY": true, "HAVING": true, "LIMIT": true,
+	"OFFSET": true, "JOIN": true, "LEFT": true, "RIGHT": true, "INNER": true, "OUTER": true,
+	"AS": true, "CASE": true, "WHEN": true, "THEN": true, "ELSE": true, "END": true,
+	"EXISTS": true, "ANY": true, "ALL": true, "UNION": true, "WITH": true, "DISTINCT": true,
+}
+
+// readIdent reads a possibly quoted and qualified identifier from i, it returns the last
+// part of the identifier and the position after it
+func readIdent(s string, i int) (string, int) {
+	var part string
+	for i < len(s) {
+		switch c := s[i]; c {
+		case '"', '`', '[':
+			end := byte(c)
+			if c == '[' {
+				end = ']'
+			}
+			j := strings.IndexByte(s[i+1:], end)
+			if j < 0 {
+				return s[i+1:], len(s)
+			}
+			part = s[i+1 : i+1+j]
+			i += j + 2
+		default:
+			j := i
+			for j < len(s) && isIdentChar(s[j]) {
+				j++
+			}
+			part = s[i:j]
+			i = j
+		}
+		if i < len(s) && s[i] == '.' && i+1 < len(s) && (isIdentChar(s[i+1]) || strings.IndexByte("\"`[", s[i+1]) >= 0) {
+			i++
+			continue
+		}
+		return part, i
+	}
+	return part, i
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// placeholderIndex returns the index of the arg of a numbered placeholder, i.e. $1, :1 and
+// @p1, or -1 if it's not one
+func placeholderIndex(s string, i int) (int, int) {
+	j := i + 1
+	if s[i] == '@' && j < len(s) && (s[j] == 'p' || s[j] == 'P') {
+		j++
+	}
+	k := j
+	for k < len(s) && s[k] >= '0' && s[k] <= '9' {
+		k++
+	}
+	if k == j {
+		return -1, i
+	}
+	n, _ := strconv.Atoi(s[j:k])
+	return n - 1, k
+}
+
+// ArgColumns returns the names of the columns which the n args of sqlStr are bound to, the
+// name is empty if it's unknown. The args of INSERT values, of comparisons with a column,
+// i.e. name = ?, and of IN and BETWEEN are recognized.
+func ArgColumns(sqlStr string, n int) []string {
+	var (
+		columns    = make([]string, n)
+		tokens     []sqlToken
+		next       int
+		depth      int
+		insertCols []string
+		inCols     bool
+		inValues   bool
+		tupleIdx   int
+		listCol    string
+		listDepth  int
+		betweenCol string
+		betweenN   int
+	)
+	last := func(k int) sqlToken {
+		if len(tokens) < k {
+			return sqlToken{kind: sqlOther}
+		}
+		return tokens[len(tokens)-k]
+	}
+	// columnBefore returns the column before the k-th last token, NOT is skipped
+	columnBefore := func(k int) string {
+		if t := last(k); t.kind == sqlKeyword && t.text == "NOT" {
+			k++
+		}
+		if t := last(k); t.kind == sqlIdent {
+			return t.text
+		}
+		return ""
+	}
+
+	for i := 0; i < len(sqlStr); {
+		c := sqlStr[i]
+		var token sqlToken
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '\'':
+			// skip the string literal, '' is an escaped quote
+			j := i + 1
+			for j < len(sqlStr) {
+				if sqlStr[j] == '\'' {
+					if j+1 < len(sqlStr) && sqlStr[j+1] == '\'' {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			i = j + 1
+			token = sqlToken{kind: sqlOther}
+		case c == '?' || ((c == '$' || c == ':' || c == '@') && i+1 < len(sqlStr)):
+			idx, j := -1, i+1
+			if c == '?' {
+				idx = next
+				next++
+			} else if idx, j = placeholderIndex(sqlStr, i); idx < 0 {
+				i++
+				tokens = append(tokens, sqlToken{kind: sqlOther})
+				continue
+			}
+			i = j
+
+			var col string
+			prev := last(1)
+			switch {
+			case prev.kind == sqlOperator || (prev.kind == sqlKeyword && (prev.text == "LIKE" || prev.text == "ILIKE")):
+				col = columnBefore(2)
+			case inValues && depth == 1 && tupleIdx < len(insertCols):
+				col = insertCols[tupleIdx]
+			case listCol != "" && depth >= listDepth:
+				col = listCol
+			case betweenN > 0:
+				col = betweenCol
+				betweenN--
+			}
+			if idx >= 0 && idx < n {
+				columns[idx] = col
+			}
+			token = sqlToken{kind: sqlPlaceholder}
+		case c == '"' || c == '`' || c == '[' || isIdentChar(c):
+			var text string
+			text, i = readIdent(sqlStr, i)
+			upper := strings.ToUpper(text)
+			if c != '"' && c != '`' && c != '[' && sqlKeywords[upper] {
+				token = sqlToken{kind: sqlKeyword, text: upper}
+			} else {
+				token = sqlToken{kind: sqlIdent, text: text}
+			}
+		case strings.IndexByte("=<>!", c) >= 0:
+			j := i + 1
+			for j < len(sqlStr) && strings.IndexByte("=<>", sqlStr[j]) >= 0 {
+				j++
+			}
+			token = sqlToken{kind: sqlOperator, text: sqlStr[i:j]}
+			i = j
+		case c == '(' || c == ')' || c == ',':
+			token = sqlToken{kind: sqlPunct, text: string(c)}
+			i++
+		default:
+			token = sqlToken{kind: sqlOther, text: string(c)}
+			i++
+		}
+
+		switch {
+		case token.kind == sqlPunct && token.text == "(":
+			depth++
+			if last(1).kind == sqlIdent && last(2).kind == sqlKeyword && last(2).text == "INTO" {
+				inCols, insertCols = true, nil
+			} else if last(1).kind == sqlKeyword && last(1).text == "IN" {
+				listCol, listDepth = columnBefore(2), depth
+			} else if inValues && depth == 1 {
+				tupleIdx = 0
+			}
+		case token.kind == sqlPunct && token.text == ")":
+			if listCol != "" && depth == listDepth {
+				listCol = ""
+			}
+			depth--
+			inCols = false
+		case token.kind == sqlPunct && token.text == ",":
+			if inValues && depth == 1 {
+				tupleIdx++
+			}
+		case token.kind == sqlIdent && inCols:
+			insertCols = append(insertCols, token.text)
+		case token.kind == sqlKeyword && token.text == "VALUES":
+			inValues = true
+		case token.kind == sqlKeyword && token.text == "BETWEEN":
+			betweenCol, betweenN = columnBefore(1), 2
+		case (token.kind == sqlKeyword || token.kind == sqlIdent) && inValues && depth == 0:
+			inValues = false
+		}
+		tokens = append(tokens, token)
+	}
+	return columns
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrittenTables(t *testing.T) {
+	var kases = []struct {
+		sql    string
+		tables []string
+	}{
+		{"SELECT * FROM user", nil},
+		{"INSERT INTO `user` (`name`) VALUES (?)", []string{"user"}},
+		{"insert ignore into user(name) values (?)", []string{"user"}},
+		{`UPDATE "public"."User" SET "name"=$1`, []string{"user"}},
+		{"UPDATE ONLY user SET name=?", []string{"user"}},
+		{"DELETE FROM [dbo].[user] WHERE id=?", []string{"user"}},
+		{"REPLACE INTO user VALUES (?)", []string{"user"}},
+		{"MERGE INTO user USING dual ON (1=1)", []string{"user"}},
+		{"TRUNCATE TABLE user", []string{"user"}},
+		{"DROP TABLE IF EXISTS `my table`", []string{"my table"}},
+		{"ALTER TABLE user ADD age INT", []string{"user"}},
+		{"update user set name=?; delete from article; UPDATE user SET age=1", []string{"user", "article"}},
+		{"SELECT * FROM user WHERE name = 'update article set'", nil},
+	}
+
+	for _, kase := range kases {
+		assert.EqualValues(t, kase.tables, WrittenTables(kase.sql), kase.sql)
+	}
+}
+
+func TestBareTableName(t *testing.T) {
+	assert.EqualValues(t, "user", BareTableName("user"))
+	assert.EqualValues(t, "user", BareTableName("`User`"))
+	assert.EqualValues(t, "user", BareTableName(`"public"."user"`))
+	assert.EqualValues(t, "user", BareTableName("[dbo].[user]"))
+	assert.EqualValues(t, "my.table", BareTableName(`"my.table"`))
+}
+
+func TestArgColumns(t *testing.T) {
+	var kases = []struct {
+		sql     string
+		columns []string
+	}{
+		{"INSERT INTO `user` (`name`,`password`) VALUES (?,?)", []string{"name", "password"}},
+		{"INSERT INTO user (name, password) VALUES (?, ?), (?, ?)", []string{"name", "password", "name", "password"}},
+		{`INSERT INTO "user" ("name","age") VALUES ($1,LOWER($2)) RETURNING "id"`, []string{"name", ""}},
+		{"INSERT INTO user (name, token) VALUES (?, ?) ON DUPLICATE KEY UPDATE token = ?", []string{"name", "token", "token"}},
+		{"UPDATE `user` SET `password`=?,`age`=`age`+? WHERE `id`=?", []string{"password", "", "id"}},
+		{`UPDATE "user" SET "password"=$2 WHERE "user"."id"=$1`, []string{"id", "password"}},
+		{"SELECT * FROM [user] WHERE [email] = @p1 AND [name] NOT LIKE @p2", []string{"email", "name"}},
+		{"SELECT * FROM user WHERE id IN (?,?) AND age NOT BETWEEN ? AND ? AND name<>?", []string{"id", "id", "age", "age", "name"}},
+		{"SELECT * FROM user WHERE name = 'a = ?' AND token = ?", []string{"token"}},
+		{"SELECT * FROM user WHERE id IN (SELECT uid FROM token WHERE value = ?)", []string{"value"}},
+		{`SELECT * FROM "user" WHERE "name" = :1 AND "age" > :2`, []string{"name", "age"}},
+	}
+
+	for _, kase := range kases {
+		assert.EqualValues(t, kase.columns, ArgColumns(kase.sql, len(kase.columns)), kase.sql)
+	}
+}
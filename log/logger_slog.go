@@ -0,0 +1,191 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+// Sampler returns whether a successful SQL should be logged, the failed ones are always
+// logged
+type Sampler func(ctx LogContext) bool
+
+// SampleEvery logs one of every n successful SQLs
+func SampleEvery(n int) Sampler {
+	var count uint64
+	return func(LogContext) bool {
+		if n <= 1 {
+			return true
+		}
+		return (atomic.AddUint64(&count, 1)-1)%uint64(n) == 0
+	}
+}
+
+// SampleRate logs the successful SQLs by the rate between 0 and 1
+func SampleRate(rate float64) Sampler {
+	return func(LogContext) bool {
+		return rand.Float64() < rate
+	}
+}
+
+// SlogLogger is a ContextLogger backed by log/slog, every SQL is logged as a record with the
+// attributes sql, args, duration, session_id, operation, table, tx_id, rows and error
+type SlogLogger struct {
+	logger *slog.Logger
+
+	redactor *Redactor
+
+	mutex   sync.RWMutex
+	level   LogLevel
+	showSQL bool
+	sampler Sampler
+}
+
+var _ ContextLogger = &SlogLogger{}
+
+// NewSlogLogger creates a SlogLogger, slog.Default() is used if logger is nil
+func NewSlogLogger(logger *slog.Logger) *SlogLogger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogLogger{
+		logger:   logger,
+		redactor: NewRedactor(),
+		level:    DEFAULT_LOG_LEVEL,
+	}
+}
+
+// AddRedactRules adds the rules redacting the args, the first rule redacting an arg wins
+func (l *SlogLogger) AddRedactRules(rules ...RedactRule) {
+	l.redactor.AddRules(rules...)
+}
+
+// SetSampler sets the sampler of the successful SQLs, all of them are logged if it's nil
+func (l *SlogLogger) SetSampler(sampler Sampler) {
+	l.mutex.Lock()
+	l.sampler = sampler
+	l.mutex.Unlock()
+}
+
+// BeforeSQL implements ContextLogger
+func (l *SlogLogger) BeforeSQL(ctx LogContext) {}
+
+// AfterSQL implements ContextLogger
+func (l *SlogLogger) AfterSQL(ctx LogContext) {
+	failed := ctx.Err != nil && ctx.Err != sql.ErrNoRows
+	l.mutex.RLock()
+	level, sampler := l.level, l.sampler
+	l.mutex.RUnlock()
+	if failed {
+		if level > LOG_ERR {
+			return
+		}
+	} else if level > LOG_INFO || (sampler != nil && !sampler(ctx)) {
+		return
+	}
+
+	var c = ctx.Ctx
+	if c == nil {
+		c = context.Background()
+	}
+	sessionID, ok := c.Value(SessionIDKey).(string)
+	if !ok {
+		sessionID = ctx.SessionID
+	}
+
+	attrs := []slog.Attr{
+		slog.String("sql", ctx.SQL),
+		slog.Any("args", l.redactor.Redact(ctx.SQL, ctx.Args)),
+		slog.Duration("duration", ctx.ExecuteTime),
+	}
+	if sessionID != "" {
+		attrs = append(attrs, slog.String("session_id", sessionID))
+	}
+	if ctx.Operation != "" {
+		attrs = append(attrs, slog.String("operation", string(ctx.Operation)))
+	}
+	if ctx.TableName != "" {
+		attrs = append(attrs, slog.String("table", ctx.TableName))
+	}
+	if ctx.TxID != "" {
+		attrs = append(attrs, slog.String("tx_id", ctx.TxID))
+	}
+	if ctx.RowsAffected >= 0 {
+		attrs = append(attrs, slog.Int64("rows", ctx.RowsAffected))
+	}
+	if failed {
+		attrs = append(attrs, slog.String("error", ctx.Err.Error()))
+		l.logger.LogAttrs(c, slog.LevelError, "SQL", attrs...)
+		return
+	}
+	l.logger.LogAttrs(c, slog.LevelInfo, "SQL", attrs...)
+}
+
+func (l *SlogLogger) logf(lv LogLevel, slogLevel slog.Level, format string, v ...interface{}) {
+	if l.Level() <= lv {
+		l.logger.Log(context.Background(), slogLevel, fmt.Sprintf(format, v...))
+	}
+}
+
+// Debugf implements ContextLogger
+func (l *SlogLogger) Debugf(format string, v ...interface{}) {
+	l.logf(LOG_DEBUG, slog.LevelDebug, format, v...)
+}
+
+// Errorf implements ContextLogger
+func (l *SlogLogger) Errorf(format string, v ...interface{}) {
+	l.logf(LOG_ERR, slog.LevelError, format, v...)
+}
+
+// Infof implements ContextLogger
+func (l *SlogLogger) Infof(format string, v ...interface{}) {
+	l.logf(LOG_INFO, slog.LevelInfo, format, v...)
+}
+
+// Warnf implements ContextLogger
+func (l *SlogLogger) Warnf(format string, v ...interface{}) {
+	l.logf(LOG_WARNING, slog.LevelWarn, format, v...)
+}
+
+// Level implements ContextLogger
+func (l *SlogLogger) Level() LogLevel {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.level
+}
+
+// SetLevel implements ContextLogger
+func (l *SlogLogger) SetLevel(lv LogLevel) {
+	l.mutex.Lock()
+	l.level = lv
+	l.mutex.Unlock()
+}
+
+// ShowSQL implements ContextLogger
+func (l *SlogLogger) ShowSQL(show ...bool) {
+	l.mutex.Lock()
+	if len(show) == 0 {
+		l.showSQL = true
+	} else {
+		l.showSQL = show[0]
+	}
+	l.mutex.Unlock()
+}
+
+// IsShowSQL implements ContextLogger
+func (l *SlogLogger) IsShowSQL() bool {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.showSQL
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.21
+// +build go1.21
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xormplus/xorm/contexts"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSlogLogger() (*SlogLogger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, nil))), &buf
+}
+
+func records(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var res []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &record))
+		res = append(res, record)
+	}
+	return res
+}
+
+func logContext(ctx context.Context, sqlStr string, args []interface{}, err error) LogContext {
+	c := contexts.NewContextHook(ctx, sqlStr, args)
+	c.ExecuteTime = 2 * time.Millisecond
+	c.Err = err
+	return LogContext(*c)
+}
+
+func TestSlogLogger(t *testing.T) {
+	logger, buf := newTestSlogLogger()
+	logger.AddRedactRules(RedactColumns("password", "*_token"), RedactValues(regexp.MustCompile(`[\w.]+@[\w.]+`)))
+
+	ctx := context.WithValue(context.Background(), SessionIDKey, "s1")
+	ctx = contexts.WithOperation(ctx, &contexts.OperationInfo{
+		Operation: contexts.OperationInsert,
+		TableName: "user",
+		TxID:      "t1",
+	})
+	c := logContext(ctx, "INSERT INTO `user` (`name`,`password`,`api_token`,`note`) VALUES (?,?,?,?)",
+		[]interface{}{"a", "secret", "abc", "mail to a@b.com"}, nil)
+	c.RowsAffected = 1
+	logger.AfterSQL(c)
+	logger.AfterSQL(logContext(context.Background(), "SELECT * FROM not_exist", nil, errors.New("no such table")))
+
+	res := records(t, buf)
+	if assert.EqualValues(t, 2, len(res)) {
+		assert.EqualValues(t, "INFO", res[0]["level"])
+		assert.EqualValues(t, "SQL", res[0]["msg"])
+		assert.EqualValues(t, "INSERT INTO `user` (`name`,`password`,`api_token`,`note`) VALUES (?,?,?,?)", res[0]["sql"])
+		assert.EqualValues(t, []interface{}{"a", RedactedValue, RedactedValue, "mail to " + RedactedValue}, res[0]["args"])
+		assert.EqualValues(t, 2*time.Millisecond, res[0]["duration"])
+		assert.EqualValues(t, "s1", res[0]["session_id"])
+		assert.EqualValues(t, "insert", res[0]["operation"])
+		assert.EqualValues(t, "user", res[0]["table"])
+		assert.EqualValues(t, "t1", res[0]["tx_id"])
+		assert.EqualValues(t, 1, res[0]["rows"])
+		assert.NotContains(t, res[0], "error")
+
+		assert.EqualValues(t, "ERROR", res[1]["level"])
+		assert.EqualValues(t, "no such table", res[1]["error"])
+		assert.NotContains(t, res[1], "rows")
+		assert.NotContains(t, res[1], "session_id")
+	}
+}
+
+func TestSlogLoggerSampling(t *testing.T) {
+	logger, buf := newTestSlogLogger()
+	logger.SetSampler(SampleEvery(3))
+	for i := 0; i < 6; i++ {
+		logger.AfterSQL(logContext(context.Background(), "SELECT 1", nil, nil))
+	}
+	logger.AfterSQL(logContext(context.Background(), "SELECT 1", nil, errors.New("failure")))
+	assert.EqualValues(t, 3, len(records(t, buf)))
+
+	buf.Reset()
+	logger.SetSampler(SampleRate(0))
+	logger.AfterSQL(logContext(context.Background(), "SELECT 1", nil, nil))
+	assert.EqualValues(t, 0, len(records(t, buf)))
+}
+
+func TestSlogLoggerLevel(t *testing.T) {
+	logger, buf := newTestSlogLogger()
+	logger.SetLevel(LOG_WARNING)
+	logger.Infof("info %d", 1)
+	logger.Warnf("warn %d", 2)
+	logger.AfterSQL(logContext(context.Background(), "SELECT 1", nil, nil))
+	logger.AfterSQL(logContext(context.Background(), "SELECT 1", nil, errors.New("failure")))
+
+	res := records(t, buf)
+	if assert.EqualValues(t, 2, len(res)) {
+		assert.EqualValues(t, "WARN", res[0]["level"])
+		assert.EqualValues(t, "warn 2", res[0]["msg"])
+		assert.EqualValues(t, "ERROR", res[1]["level"])
+	}
+
+	assert.False(t, logger.IsShowSQL())
+	logger.ShowSQL()
+	assert.True(t, logger.IsShowSQL())
+}
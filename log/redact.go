@@ -0,0 +1,243 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/xormplus/xorm/internal/utils"
+)
+
+// RedactedValue replaces the redacted args in the logs
+const RedactedValue = "******"
+
+// minRedactErrorLen is the min length of the values masked in the error messages, the shorter
+// ones, i.e. 1 or true, would mask the unrelated parts of the messages
+const minRedactErrorLen = 4
+
+// RedactRule returns the value to be logged instead of the arg bound to the column, ok is
+// false if the arg is not redacted. column is empty if it's unknown.
+type RedactRule func(column string, arg interface{}) (value interface{}, ok bool)
+
+// RedactColumns redacts the args bound to the columns matching any of the patterns, i.e.
+// password or *_token, the matching is case insensitive
+func RedactColumns(patterns ...string) RedactRule {
+	lowered := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		lowered[i] = strings.ToLower(pattern)
+	}
+	return func(column string, arg interface{}) (interface{}, bool) {
+		if column == "" {
+			return nil, false
+		}
+		column = strings.ToLower(column)
+		for _, pattern := range lowered {
+			if matched, _ := path.Match(pattern, column); matched {
+				return RedactedValue, true
+			}
+		}
+		return nil, false
+	}
+}
+
+// RedactValues redacts the string args matching the regexp whatever the columns are, i.e.
+// the emails or the card numbers
+func RedactValues(re *regexp.Regexp) RedactRule {
+	return func(column string, arg interface{}) (interface{}, bool) {
+		var s string
+		switch t := arg.(type) {
+		case string:
+			s = t
+		case []byte:
+			s = string(t)
+		case *string:
+			if t == nil {
+				return nil, false
+			}
+			s = *t
+		default:
+			return nil, false
+		}
+		if !re.MatchString(s) {
+			return nil, false
+		}
+		return re.ReplaceAllString(s, RedactedValue), true
+	}
+}
+
+// Redactor masks the args of the SQLs bound to the sensitive columns or redacted by the
+// rules. The sensitive columns are matched by the names whatever the tables are.
+type Redactor struct {
+	mutex   sync.RWMutex
+	rules   []RedactRule
+	columns map[string]bool
+}
+
+// NewRedactor creates a Redactor with the rules
+func NewRedactor(rules ...RedactRule) *Redactor {
+	return &Redactor{
+		rules:   rules,
+		columns: make(map[string]bool),
+	}
+}
+
+// AddRules adds the rules, the first rule redacting an arg wins
+func (r *Redactor) AddRules(rules ...RedactRule) {
+	r.mutex.Lock()
+	r.rules = append(r.rules, rules...)
+	r.mutex.Unlock()
+}
+
+// AddSensitiveColumns marks the columns as sensitive, the names are case insensitive
+func (r *Redactor) AddSensitiveColumns(columns ...string) {
+	r.mutex.Lock()
+	for _, column := range columns {
+		r.columns[strings.ToLower(column)] = true
+	}
+	r.mutex.Unlock()
+}
+
+// IsSensitive returns true if the column is marked as sensitive
+func (r *Redactor) IsSensitive(column string) bool {
+	if r == nil {
+		return false
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.columns[strings.ToLower(column)]
+}
+
+// redact returns the args with the redacted ones replaced and which of them are redacted,
+// both are nil if there is no rule and no sensitive column
+func (r *Redactor) redact(sqlStr string, args []interface{}) ([]interface{}, []bool) {
+	if r == nil || len(args) == 0 {
+		return nil, nil
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	if len(r.rules) == 0 && len(r.columns) == 0 {
+		return nil, nil
+	}
+
+	columns := utils.ArgColumns(sqlStr, len(args))
+	values := make([]interface{}, len(args))
+	redacted := make([]bool, len(args))
+	for i, arg := range args {
+		values[i] = arg
+		if columns[i] != "" && r.columns[strings.ToLower(columns[i])] {
+			values[i], redacted[i] = RedactedValue, true
+			continue
+		}
+		for _, rule := range r.rules {
+			if v, ok := rule(columns[i], arg); ok {
+				values[i], redacted[i] = v, true
+				break
+			}
+		}
+	}
+	return values, redacted
+}
+
+// Redact returns a copy of args with the redacted ones replaced, args is returned as is if
+// there is no rule and no sensitive column
+func (r *Redactor) Redact(sqlStr string, args []interface{}) []interface{} {
+	values, _ := r.redact(sqlStr, args)
+	if values == nil {
+		return args
+	}
+	return values
+}
+
+// RedactError masks the values of the redacted args of sqlStr in the message of err, i.e.
+// the duplicated values reported by the database. Only the whole quoted values or tokens of
+// the message are masked and the values shorter than 4 bytes are kept, so the names and the
+// numbers of the message are not mangled. The returned error wraps err.
+func (r *Redactor) RedactError(err error, sqlStr string, args []interface{}) error {
+	if err == nil {
+		return nil
+	}
+	_, redacted := r.redact(sqlStr, args)
+	if redacted == nil {
+		return err
+	}
+
+	var msg = err.Error()
+	for i, arg := range args {
+		if !redacted[i] {
+			continue
+		}
+		var s string
+		switch t := arg.(type) {
+		case []byte:
+			s = string(t)
+		case *string:
+			if t != nil {
+				s = *t
+			}
+		default:
+			s = fmt.Sprint(arg)
+		}
+		if len(s) >= minRedactErrorLen {
+			msg = replaceTokens(msg, s, RedactedValue)
+		}
+	}
+	if msg == err.Error() {
+		return err
+	}
+	return &RedactedError{msg: msg, err: err}
+}
+
+// isTokenByte returns true if c could be a part of a token of an error message
+func isTokenByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// replaceTokens replaces the occurrences of old in s which are not a part of a longer token,
+// i.e. which are quoted or separated by spaces or punctuations
+func replaceTokens(s, old, repl string) string {
+	var (
+		buf   strings.Builder
+		start int
+	)
+	for {
+		idx := strings.Index(s[start:], old)
+		if idx < 0 {
+			break
+		}
+		idx += start
+		end := idx + len(old)
+		if (idx > 0 && isTokenByte(s[idx-1]) && isTokenByte(old[0])) ||
+			(end < len(s) && isTokenByte(s[end]) && isTokenByte(old[len(old)-1])) {
+			buf.WriteString(s[start : idx+1])
+			start = idx + 1
+			continue
+		}
+		buf.WriteString(s[start:idx])
+		buf.WriteString(repl)
+		start = end
+	}
+	buf.WriteString(s[start:])
+	return buf.String()
+}
+
+// RedactedError is an error whose message has the sensitive values masked
+type RedactedError struct {
+	msg string
+	err error
+}
+
+// Error implements error
+func (e *RedactedError) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the original error
+func (e *RedactedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactor(t *testing.T) {
+	var nilRedactor *Redactor
+	args := []interface{}{"a", "secret"}
+	assert.EqualValues(t, args, nilRedactor.Redact("UPDATE user SET name = ?, password = ?", args))
+
+	redactor := NewRedactor()
+	assert.EqualValues(t, args, redactor.Redact("UPDATE user SET name = ?, password = ?", args))
+
+	redactor.AddSensitiveColumns("Password")
+	redactor.AddRules(RedactColumns("*_token"), RedactValues(regexp.MustCompile(`\d{4}-\d{4}`)))
+	values := redactor.Redact("INSERT INTO `user` (`name`,`password`,`api_token`,`card`,`data`) VALUES (?,?,?,?,?)",
+		[]interface{}{"a", "secret", []byte("abc"), "card 1234-5678", 1})
+	assert.EqualValues(t, []interface{}{"a", RedactedValue, RedactedValue, "card " + RedactedValue, 1}, values)
+
+	// the args are not changed
+	assert.EqualValues(t, []interface{}{"a", "secret"}, args)
+	assert.EqualValues(t, []interface{}{"a", RedactedValue}, redactor.Redact("UPDATE user SET name = ?, password = ?", args))
+}
+
+func TestRedactColumns(t *testing.T) {
+	patterns := []string{"*_Token", "Password"}
+	rule := RedactColumns(patterns...)
+
+	value, ok := rule("API_TOKEN", "abc")
+	assert.True(t, ok)
+	assert.EqualValues(t, RedactedValue, value)
+	_, ok = rule("password", "secret")
+	assert.True(t, ok)
+	_, ok = rule("name", "a")
+	assert.False(t, ok)
+
+	// the patterns of the caller are not changed
+	assert.EqualValues(t, []string{"*_Token", "Password"}, patterns)
+}
+
+func TestRedactError(t *testing.T) {
+	redactor := NewRedactor()
+	redactor.AddSensitiveColumns("email")
+
+	var sqlStr = "INSERT INTO user (name, email) VALUES (?, ?)"
+	var args = []interface{}{"a", "a@b.com"}
+	origin := errors.New("Duplicate entry 'a@b.com' for key 'email', name a")
+	err := redactor.RedactError(origin, sqlStr, args)
+	assert.EqualValues(t, "Duplicate entry '******' for key 'email', name a", err.Error())
+	assert.True(t, errors.Is(err, origin))
+
+	var redacted *RedactedError
+	assert.True(t, errors.As(err, &redacted))
+
+	// the short values and the values inside the other tokens are kept
+	sqlStr = "UPDATE account SET pin = ?, code = ? WHERE id = ?"
+	args = []interface{}{1, "count", 10}
+	redactor.AddSensitiveColumns("pin", "code")
+	origin = errors.New("constraint failed: account.count_1 check (code != 'count') on 1 row")
+	err = redactor.RedactError(origin, sqlStr, args)
+	assert.EqualValues(t, "constraint failed: account.count_1 check (code != '******') on 1 row", err.Error())
+
+	other := errors.New("no such table")
+	assert.EqualValues(t, other, redactor.RedactError(other, sqlStr, args))
+	assert.Nil(t, redactor.RedactError(nil, sqlStr, args))
+}
@@ -0,0 +1,218 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/xormplus/xorm/dialects"
+	"github.com/xormplus/xorm/schemas"
+)
+
+// DefaultLockTimeout is the timeout of the migration lock if Options.LockTimeout is zero.
+const DefaultLockTimeout = time.Minute
+
+// lockRetryInterval is how often the lock row is checked when it's held by another instance
+var lockRetryInterval = 100 * time.Millisecond
+
+// unlockFunc releases the migration lock
+type unlockFunc func() error
+
+// withLock runs fn with the migration lock held if Options.UseLock is true
+func (m *Migrate) withLock(fn func() error) error {
+	if !m.options.UseLock {
+		return fn()
+	}
+
+	unlock, err := m.lock()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	if err2 := unlock(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+func (m *Migrate) lock() (unlockFunc, error) {
+	timeout := m.options.LockTimeout
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
+	}
+
+	switch m.db.Dialect().URI().DBType {
+	case schemas.POSTGRES:
+		return m.lockPostgres(timeout)
+	case schemas.MYSQL:
+		return m.lockMysql(timeout)
+	case schemas.MSSQL:
+		return m.lockMssql(timeout)
+	}
+	return m.lockTable(timeout)
+}
+
+// lockName returns the name of the advisory lock, the locks of mysql are shared by all
+// the databases on the server so that the database name is included
+func (m *Migrate) lockName() string {
+	return m.db.Dialect().URI().DBName + "." + m.options.TableName
+}
+
+// lockPostgres holds an advisory lock on a dedicated connection, since advisory locks
+// belong to the connection which obtains them
+func (m *Migrate) lockPostgres(timeout time.Duration) (unlockFunc, error) {
+	conn, err := m.db.DB().Conn(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(m.options.TableName))
+	key := int64(h.Sum64())
+
+	// pg_advisory_lock waits until it gets the lock, and it's canceled on timeout
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("SELECT pg_advisory_lock(%d)", key)); err != nil {
+		conn.Close()
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, ErrLockTimeout
+		}
+		return nil, err
+	}
+
+	return func() error {
+		defer conn.Close()
+		_, err := conn.ExecContext(context.Background(), fmt.Sprintf("SELECT pg_advisory_unlock(%d)", key))
+		return err
+	}, nil
+}
+
+func (m *Migrate) lockMysql(timeout time.Duration) (unlockFunc, error) {
+	conn, err := m.db.DB().Conn(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	name := m.lockName()
+	// GET_LOCK returns 1 if the lock was obtained, 0 on timeout and NULL on error
+	var res sql.NullInt64
+	err = conn.QueryRowContext(context.Background(), "SELECT GET_LOCK(?, ?)", name, lockSeconds(timeout)).Scan(&res)
+	if err == nil && !res.Valid {
+		err = fmt.Errorf("GET_LOCK(%s) failed", name)
+	} else if err == nil && res.Int64 != 1 {
+		err = ErrLockTimeout
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return func() error {
+		defer conn.Close()
+		_, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", name)
+		return err
+	}, nil
+}
+
+func (m *Migrate) lockMssql(timeout time.Duration) (unlockFunc, error) {
+	conn, err := m.db.DB().Conn(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	resource := strings.Replace(m.lockName(), "'", "''", -1)
+	// sp_getapplock returns 0 or 1 if the lock was obtained, -1 on timeout and others on error
+	var res int
+	err = conn.QueryRowContext(context.Background(), fmt.Sprintf("DECLARE @result INT; "+
+		"EXEC @result = sp_getapplock @Resource = N'%s', @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = %d; "+
+		"SELECT @result", resource, timeout.Milliseconds())).Scan(&res)
+	if err == nil && res == -1 {
+		err = ErrLockTimeout
+	} else if err == nil && res < 0 {
+		err = fmt.Errorf("sp_getapplock(%s) failed with %d", resource, res)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return func() error {
+		defer conn.Close()
+		_, err := conn.ExecContext(context.Background(),
+			fmt.Sprintf("EXEC sp_releaseapplock @Resource = N'%s', @LockOwner = 'Session'", resource))
+		return err
+	}, nil
+}
+
+// lockTable inserts the only row of the lock table, the insert fails on the primary key
+// while another instance holds the lock
+func (m *Migrate) lockTable(timeout time.Duration) (unlockFunc, error) {
+	tableName := m.options.TableName + "_lock"
+	if err := m.createLockTableIfNotExists(tableName); err != nil {
+		return nil, err
+	}
+
+	var (
+		deadline  = time.Now().Add(timeout)
+		insertSQL = fmt.Sprintf("INSERT INTO %s (id, locked_at) VALUES (?, ?)", tableName)
+		countSQL  = fmt.Sprintf("SELECT COUNT(*) FROM %s", tableName)
+	)
+	for {
+		lockedAt := dialects.FormatTime(m.db.Dialect(), schemas.DateTime, time.Now().In(m.db.DatabaseTZ))
+		_, err := m.db.Exec(insertSQL, 1, lockedAt)
+		if err == nil {
+			break
+		}
+
+		var count int64
+		if _, err2 := m.db.SQL(countSQL).Get(&count); err2 != nil {
+			return nil, err2
+		}
+		if count == 0 {
+			return nil, err
+		}
+
+		if time.Now().After(deadline) {
+			return nil, ErrLockTimeout
+		}
+		time.Sleep(lockRetryInterval)
+	}
+
+	return func() error {
+		_, err := m.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = ?", tableName), 1)
+		return err
+	}, nil
+}
+
+func (m *Migrate) createLockTableIfNotExists(tableName string) error {
+	exists, err := m.db.IsTableExist(tableName)
+	if err != nil || exists {
+		return err
+	}
+
+	col := schemas.NewColumn("locked_at", "", schemas.SQLType{Name: schemas.DateTime}, 0, 0, true)
+	s, err := dialects.ColumnString(m.db.Dialect(), col, false)
+	if err != nil {
+		return err
+	}
+	if _, err := m.db.Exec(fmt.Sprintf("CREATE TABLE %s (id INTEGER PRIMARY KEY, %s)", tableName, s)); err != nil {
+		// another instance may have created the table at the same time
+		if exists, _ := m.db.IsTableExist(tableName); !exists {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockSeconds returns the timeout in seconds which is at least 1
+func lockSeconds(timeout time.Duration) int64 {
+	seconds := int64((timeout + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
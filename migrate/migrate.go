@@ -1,10 +1,16 @@
 package migrate
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/xormplus/xorm"
+	"github.com/xormplus/xorm/dialects"
+	"github.com/xormplus/xorm/schemas"
 )
 
 // MigrateFunc is the func signature for migrating.
@@ -16,12 +22,28 @@ type RollbackFunc func(*xorm.Engine) error
 // InitSchemaFunc is the func signature for initializing the schemas.
 type InitSchemaFunc func(*xorm.Engine) error
 
+// MigrateSessionFunc is the func signature for migrating in a transaction.
+type MigrateSessionFunc func(*xorm.Session) error
+
+// RollbackSessionFunc is the func signature for rollbacking in a transaction.
+type RollbackSessionFunc func(*xorm.Session) error
+
+// InitSchemaSessionFunc is the func signature for initializing the schemas in a transaction.
+type InitSchemaSessionFunc func(*xorm.Session) error
+
 // Options define options for all migrations.
 type Options struct {
 	// TableName is the migration table.
 	TableName string
 	// IDColumnName is the name of column where the migration id will be stored.
 	IDColumnName string
+	// UseLock makes Migrate, MigrateTo, RollbackLast and RollbackTo hold a database lock, so
+	// the application instances started at the same time will not run the migrations twice.
+	// It uses advisory locks on postgres, mysql and mssql, and a row of the table TableName_lock
+	// on the other databases, which has to be deleted manually if the process crashed.
+	UseLock bool
+	// LockTimeout is how long to wait for the lock, DefaultLockTimeout is used if it's zero.
+	LockTimeout time.Duration
 }
 
 // Migration represents a database migration (a modification to be made on the database).
@@ -32,6 +54,75 @@ type Migration struct {
 	Migrate MigrateFunc
 	// Rollback will be executed on rollback. Can be nil.
 	Rollback RollbackFunc
+	// MigrateSession is used instead of Migrate if it's not nil. It's executed in a
+	// transaction with the insert of the migration id, so a failed migration leaves
+	// nothing behind on the databases which support transactional DDL.
+	MigrateSession MigrateSessionFunc
+	// RollbackSession is used instead of Rollback if it's not nil, and it's executed in a
+	// transaction with the delete of the migration id.
+	RollbackSession RollbackSessionFunc
+	// DisableTransaction makes MigrateSession and RollbackSession run without a transaction,
+	// for the statements which cannot run in a transaction, i.e. CREATE INDEX CONCURRENTLY.
+	DisableTransaction bool
+	// Checksum is the checksum of the migration source, i.e. the sha256 of a SQL file.
+	// Migrate refuses to run if an applied migration has a different checksum. Can be empty.
+	Checksum string
+}
+
+// MigrationStatus represents whether a migration has been applied.
+type MigrationStatus struct {
+	ID        string
+	Applied   bool
+	AppliedAt time.Time
+	Duration  time.Duration
+	// Modified is true if the checksum of the migration has been changed after it was applied.
+	Modified bool
+}
+
+// ErrChecksumMismatch is returned when an applied migration has been modified.
+type ErrChecksumMismatch struct {
+	ID string
+}
+
+func (e ErrChecksumMismatch) Error() string {
+	return fmt.Sprintf("Migration %s has been modified after it was applied", e.ID)
+}
+
+// migrationRecord represents a row of the migration table
+type migrationRecord struct {
+	ID        string    `xorm:"'id'"`
+	AppliedAt time.Time `xorm:"'applied_at'"`
+	Checksum  string    `xorm:"'checksum'"`
+	Duration  int64     `xorm:"'duration'"`
+}
+
+// migrationColumns returns the columns of the migration table besides the id column,
+// the duration is stored in milliseconds.
+func migrationColumns() []*schemas.Column {
+	return []*schemas.Column{
+		schemas.NewColumn("applied_at", "", schemas.SQLType{Name: schemas.DateTime}, 0, 0, true),
+		schemas.NewColumn("checksum", "", schemas.SQLType{Name: schemas.Varchar}, 64, 0, true),
+		schemas.NewColumn("duration", "", schemas.SQLType{Name: schemas.BigInt}, 0, 0, true),
+	}
+}
+
+// NewSchemaDiffMigration returns a migration which applies the plan of xorm.Engine.SchemaDiff,
+// and rollbacks it by the reverse SQLs of the plan.
+func NewSchemaDiffMigration(id string, diff *xorm.SchemaDiff) *Migration {
+	return &Migration{
+		ID: id,
+		MigrateSession: func(tx *xorm.Session) error {
+			return tx.ApplySchemaDiff(diff)
+		},
+		RollbackSession: func(tx *xorm.Session) error {
+			for _, sql := range diff.RollbackSQLs() {
+				if _, err := tx.Exec(sql); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
 }
 
 // Migrate represents a collection of all migrations of a database schemas.
@@ -40,6 +131,8 @@ type Migrate struct {
 	options    *Options
 	migrations []*Migration
 	initSchema InitSchemaFunc
+
+	initSchemaSession InitSchemaSessionFunc
 }
 
 var (
@@ -62,6 +155,14 @@ var (
 	// ErrNoRunnedMigration is returned when any runned migration was found while
 	// running RollbackLast
 	ErrNoRunnedMigration = errors.New("Could not find last runned migration")
+
+	// ErrMigrationNotFound is returned when the migration id given to MigrateTo
+	// or RollbackTo is not defined.
+	ErrMigrationNotFound = errors.New("Could not find migration")
+
+	// ErrLockTimeout is returned when the migration lock cannot be obtained in
+	// Options.LockTimeout, which means another instance is migrating.
+	ErrLockTimeout = errors.New("Timeout waiting for the migration lock")
 )
 
 // New returns a new Gormigrate.
@@ -81,39 +182,98 @@ func (m *Migrate) InitSchema(initSchema InitSchemaFunc) {
 	m.initSchema = initSchema
 }
 
+// InitSchemaSession is like InitSchema, but the function is executed in a transaction
+// with the inserts of the migration ids.
+func (m *Migrate) InitSchemaSession(initSchema InitSchemaSessionFunc) {
+	m.initSchemaSession = initSchema
+}
+
 // Migrate executes all migrations that did not run yet.
 func (m *Migrate) Migrate() error {
+	return m.withLock(func() error {
+		return m.migrate("")
+	})
+}
+
+// MigrateTo executes the migrations that did not run yet up to and including the migration id.
+func (m *Migrate) MigrateTo(id string) error {
+	if m.indexOf(id) < 0 {
+		return ErrMigrationNotFound
+	}
+	return m.withLock(func() error {
+		return m.migrate(id)
+	})
+}
+
+func (m *Migrate) migrate(lastID string) error {
 	if err := m.createMigrationTableIfNotExists(); err != nil {
 		return err
 	}
 
-	if m.initSchema != nil && m.isFirstRun() {
+	if (m.initSchema != nil || m.initSchemaSession != nil) && m.isFirstRun() {
 		return m.runInitSchema()
 	}
 
+	records, err := m.getMigrationRecords()
+	if err != nil {
+		return err
+	}
+	for _, migration := range m.migrations {
+		if isModified(migration, records[migration.ID]) {
+			return ErrChecksumMismatch{migration.ID}
+		}
+	}
+
 	for _, migration := range m.migrations {
-		if err := m.runMigration(migration); err != nil {
+		if err := m.runMigration(migration, records); err != nil {
 			return err
 		}
+		if migration.ID == lastID {
+			break
+		}
 	}
 	return nil
 }
 
+// Status returns the status of all migrations in order.
+func (m *Migrate) Status() ([]*MigrationStatus, error) {
+	if err := m.createMigrationTableIfNotExists(); err != nil {
+		return nil, err
+	}
+
+	records, err := m.getMigrationRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := &MigrationStatus{ID: migration.ID}
+		if record, ok := records[migration.ID]; ok {
+			status.Applied = true
+			status.AppliedAt = record.AppliedAt
+			status.Duration = time.Duration(record.Duration) * time.Millisecond
+			status.Modified = isModified(migration, record)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
 // RollbackLast undo the last migration
 func (m *Migrate) RollbackLast() error {
 	if len(m.migrations) == 0 {
 		return ErrNoMigrationDefined
 	}
 
-	lastRunnedMigration, err := m.getLastRunnedMigration()
-	if err != nil {
-		return err
-	}
+	return m.withLock(func() error {
+		lastRunnedMigration, err := m.getLastRunnedMigration()
+		if err != nil {
+			return err
+		}
 
-	if err := m.RollbackMigration(lastRunnedMigration); err != nil {
-		return err
-	}
-	return nil
+		return m.RollbackMigration(lastRunnedMigration)
+	})
 }
 
 func (m *Migrate) getLastRunnedMigration() (*Migration, error) {
@@ -129,8 +289,52 @@ func (m *Migrate) getLastRunnedMigration() (*Migration, error) {
 	return nil, ErrNoRunnedMigration
 }
 
+// RollbackTo undo the applied migrations after the migration id in reverse order,
+// the migration id itself is kept.
+func (m *Migrate) RollbackTo(id string) error {
+	idx := m.indexOf(id)
+	if idx < 0 {
+		return ErrMigrationNotFound
+	}
+
+	return m.withLock(func() error {
+		for i := len(m.migrations) - 1; i > idx; i-- {
+			migration := m.migrations[i]
+			run, err := m.migrationDidRun(migration)
+			if err != nil {
+				return err
+			}
+			if !run {
+				continue
+			}
+			if err := m.RollbackMigration(migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (m *Migrate) indexOf(id string) int {
+	for i, migration := range m.migrations {
+		if migration.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
 // RollbackMigration undo a migration.
 func (m *Migrate) RollbackMigration(mig *Migration) error {
+	if mig.RollbackSession != nil {
+		return m.runInSession(!mig.DisableTransaction, func(session *xorm.Session) error {
+			if err := mig.RollbackSession(session); err != nil {
+				return err
+			}
+			return m.deleteMigration(session, mig.ID)
+		})
+	}
+
 	if mig.Rollback == nil {
 		return ErrRollbackImpossible
 	}
@@ -138,48 +342,75 @@ func (m *Migrate) RollbackMigration(mig *Migration) error {
 	if err := mig.Rollback(m.db); err != nil {
 		return err
 	}
-
-	sql := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", m.options.TableName, m.options.IDColumnName)
-	if _, err := m.db.Exec(sql, mig.ID); err != nil {
-		return err
-	}
-	return nil
+	return m.deleteMigration(m.db, mig.ID)
 }
 
 func (m *Migrate) runInitSchema() error {
-	if err := m.initSchema(m.db); err != nil {
-		return err
+	// InitSchemaFunc uses the engine which cannot join the transaction
+	if m.initSchemaSession == nil {
+		if err := m.initSchema(m.db); err != nil {
+			return err
+		}
 	}
 
-	for _, migration := range m.migrations {
-		if err := m.insertMigration(migration.ID); err != nil {
+	return m.runInSession(true, func(session *xorm.Session) error {
+		if m.initSchemaSession != nil {
+			if err := m.initSchemaSession(session); err != nil {
+				return err
+			}
+		}
+
+		for _, migration := range m.migrations {
+			if err := m.insertMigration(session, migration, 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// runInSession runs fn with a new session, which is in a transaction if useTx is true
+func (m *Migrate) runInSession(useTx bool, fn func(*xorm.Session) error) error {
+	session := m.db.NewSession()
+	defer session.Close()
+
+	if useTx {
+		if err := session.Begin(); err != nil {
 			return err
 		}
 	}
-
+	if err := fn(session); err != nil {
+		return err
+	}
+	if useTx {
+		return session.Commit()
+	}
 	return nil
 }
 
-func (m *Migrate) runMigration(migration *Migration) error {
+func (m *Migrate) runMigration(migration *Migration, records map[string]*migrationRecord) error {
 	if len(migration.ID) == 0 {
 		return ErrMissingID
 	}
 
-	run, err := m.migrationDidRun(migration)
-	if err != nil {
-		return err
+	if _, run := records[migration.ID]; run {
+		return nil
 	}
 
-	if !run {
-		if err := migration.Migrate(m.db); err != nil {
-			return err
-		}
+	start := time.Now()
+	if migration.MigrateSession != nil {
+		return m.runInSession(!migration.DisableTransaction, func(session *xorm.Session) error {
+			if err := migration.MigrateSession(session); err != nil {
+				return err
+			}
+			return m.insertMigration(session, migration, time.Since(start))
+		})
+	}
 
-		if err := m.insertMigration(migration.ID); err != nil {
-			return err
-		}
+	if err := migration.Migrate(m.db); err != nil {
+		return err
 	}
-	return nil
+	return m.insertMigration(m.db, migration, time.Since(start))
 }
 
 func (m *Migrate) createMigrationTableIfNotExists() error {
@@ -188,16 +419,66 @@ func (m *Migrate) createMigrationTableIfNotExists() error {
 		return err
 	}
 	if exists {
-		return nil
+		return m.addMigrationColumnsIfNotExist()
+	}
+
+	var cols = []string{fmt.Sprintf("%s VARCHAR(255) PRIMARY KEY", m.options.IDColumnName)}
+	for _, col := range migrationColumns() {
+		s, err := dialects.ColumnString(m.db.Dialect(), col, false)
+		if err != nil {
+			return err
+		}
+		cols = append(cols, s)
 	}
 
-	sql := fmt.Sprintf("CREATE TABLE %s (%s VARCHAR(255) PRIMARY KEY)", m.options.TableName, m.options.IDColumnName)
+	sql := fmt.Sprintf("CREATE TABLE %s (%s)", m.options.TableName, strings.Join(cols, ", "))
 	if _, err := m.db.Exec(sql); err != nil {
 		return err
 	}
 	return nil
 }
 
+// addMigrationColumnsIfNotExist upgrades the migration table created by the former
+// versions which only has the id column
+func (m *Migrate) addMigrationColumnsIfNotExist() error {
+	dialect := m.db.Dialect()
+	for _, col := range migrationColumns() {
+		exist, err := dialect.IsColumnExist(m.db.DB(), context.Background(), m.options.TableName, col.Name)
+		if err != nil {
+			return err
+		}
+		if exist {
+			continue
+		}
+		if _, err := m.db.Exec(dialect.AddColumnSQL(m.options.TableName, col)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migrate) getMigrationRecords() (map[string]*migrationRecord, error) {
+	var records []*migrationRecord
+	err := m.db.SQL(fmt.Sprintf("SELECT %s AS id, applied_at, checksum, duration FROM %s",
+		m.options.IDColumnName, m.options.TableName)).Find(&records)
+	if err != nil {
+		return nil, err
+	}
+
+	var res = make(map[string]*migrationRecord, len(records))
+	for _, record := range records {
+		res[record.ID] = record
+	}
+	return res, nil
+}
+
+// isModified returns true if both the migration and the record have checksums and they are
+// different, the migrations applied before checksums were recorded are never modified
+func isModified(migration *Migration, record *migrationRecord) bool {
+	return record != nil && migration.Checksum != "" && record.Checksum != "" &&
+		migration.Checksum != record.Checksum
+}
+
 func (m *Migrate) migrationDidRun(mig *Migration) (bool, error) {
 	count, err := m.db.SQL(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = ?", m.options.TableName, m.options.IDColumnName), mig.ID).Count()
 	return count > 0, err
@@ -210,8 +491,21 @@ func (m *Migrate) isFirstRun() bool {
 	return count == 0
 }
 
-func (m *Migrate) insertMigration(id string) error {
-	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (?)", m.options.TableName, m.options.IDColumnName)
-	_, err := m.db.Exec(sql, id)
+// execer is implemented by both xorm.Engine and xorm.Session
+type execer interface {
+	Exec(sqlOrArgs ...interface{}) (sql.Result, error)
+}
+
+func (m *Migrate) insertMigration(db execer, migration *Migration, duration time.Duration) error {
+	sql := fmt.Sprintf("INSERT INTO %s (%s, applied_at, checksum, duration) VALUES (?, ?, ?, ?)",
+		m.options.TableName, m.options.IDColumnName)
+	appliedAt := dialects.FormatTime(m.db.Dialect(), schemas.DateTime, time.Now().In(m.db.DatabaseTZ))
+	_, err := db.Exec(sql, migration.ID, appliedAt, migration.Checksum, duration.Milliseconds())
+	return err
+}
+
+func (m *Migrate) deleteMigration(db execer, id string) error {
+	sql := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", m.options.TableName, m.options.IDColumnName)
+	_, err := db.Exec(sql, id)
 	return err
 }
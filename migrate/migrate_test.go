@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/assert"
@@ -148,3 +150,232 @@ func tableCount(db *xorm.Engine, tableName string) (count int) {
 	row.Scan(&count)
 	return
 }
+
+type PersonV2 struct {
+	ID    int64
+	Name  string
+	Email string `xorm:"index"`
+}
+
+func (PersonV2) TableName() string {
+	return "person"
+}
+
+func TestSchemaDiffMigration(t *testing.T) {
+	os.Remove(dbName)
+
+	db, err := xorm.NewEngine("sqlite3", dbName)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	assert.NoError(t, db.Sync2(&Person{}))
+
+	diff, err := db.SchemaDiff(&PersonV2{})
+	assert.NoError(t, err)
+	assert.Len(t, diff.Changes, 2)
+
+	m := New(db, DefaultOptions, []*Migration{
+		NewSchemaDiffMigration("201608301500", diff),
+	})
+	assert.NoError(t, m.Migrate())
+	tables, err := db.DBMetas()
+	assert.NoError(t, err)
+	assert.Len(t, tables, 2)
+	for _, table := range tables {
+		if table.Name == "person" {
+			assert.NotNil(t, table.GetColumn("email"))
+			assert.Len(t, table.Indexes, 1)
+		}
+	}
+	assert.Equal(t, 1, tableCount(db, "migrations"))
+
+	diff, err = db.SchemaDiff(&PersonV2{})
+	assert.NoError(t, err)
+	assert.True(t, diff.IsEmpty())
+}
+
+var sqlMigrations = fstest.MapFS{
+	"0001_create_book.up.sql": &fstest.MapFile{
+		Data: []byte("CREATE TABLE book (id INTEGER PRIMARY KEY, title VARCHAR(255));\nINSERT INTO book (id, title) VALUES (1, 'a;b');"),
+	},
+	"0001_create_book.down.sql":   &fstest.MapFile{Data: []byte("DROP TABLE book;")},
+	"0002_create_author.up.sql":   &fstest.MapFile{Data: []byte("CREATE TABLE author (id INTEGER PRIMARY KEY);")},
+	"0002_create_author.down.sql": &fstest.MapFile{Data: []byte("DROP TABLE author;")},
+	"0010_add_index.up.sql":       &fstest.MapFile{Data: []byte("CREATE INDEX idx_book_title ON book (title);")},
+	"0010_add_index.down.sql":     &fstest.MapFile{Data: []byte("DROP INDEX idx_book_title;")},
+	"README.md":                   &fstest.MapFile{Data: []byte("migrations")},
+}
+
+func TestLoadSQLMigrations(t *testing.T) {
+	migs, err := LoadSQLMigrations(sqlMigrations)
+	assert.NoError(t, err)
+	assert.Len(t, migs, 3)
+	assert.EqualValues(t, "0001_create_book", migs[0].ID)
+	assert.EqualValues(t, "0002_create_author", migs[1].ID)
+	assert.EqualValues(t, "0010_add_index", migs[2].ID)
+	assert.NotNil(t, migs[0].RollbackSession)
+	assert.Len(t, migs[0].Checksum, 64)
+
+	migs, err = LoadSQLMigrations(fstest.MapFS{
+		"0001_a.up.sql": &fstest.MapFile{Data: []byte("CREATE TABLE a (id INTEGER);")},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, migs, 1)
+	assert.Nil(t, migs[0].RollbackSession)
+
+	_, err = LoadSQLMigrations(fstest.MapFS{
+		"0001_a.down.sql": &fstest.MapFile{Data: []byte("DROP TABLE a;")},
+	})
+	assert.Error(t, err)
+
+	_, err = LoadSQLMigrations(fstest.MapFS{
+		"create_a.up.sql": &fstest.MapFile{Data: []byte("CREATE TABLE a (id INTEGER);")},
+	})
+	assert.Error(t, err)
+}
+
+func TestSQLMigrationStatus(t *testing.T) {
+	os.Remove(dbName)
+
+	db, err := xorm.NewEngine("sqlite3", dbName)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	migs, err := LoadSQLMigrations(sqlMigrations)
+	assert.NoError(t, err)
+
+	m := New(db, DefaultOptions, migs)
+	assert.EqualValues(t, ErrMigrationNotFound, m.MigrateTo("0003"))
+	assert.NoError(t, m.MigrateTo("0002_create_author"))
+	assert.Equal(t, 2, tableCount(db, "migrations"))
+	assert.Equal(t, 1, tableCount(db, "book"))
+
+	statuses, err := m.Status()
+	assert.NoError(t, err)
+	assert.Len(t, statuses, 3)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[0].AppliedAt.IsZero())
+	assert.False(t, statuses[0].Modified)
+	assert.True(t, statuses[1].Applied)
+	assert.False(t, statuses[2].Applied)
+
+	assert.NoError(t, m.Migrate())
+	assert.Equal(t, 3, tableCount(db, "migrations"))
+
+	assert.NoError(t, m.RollbackTo("0001_create_book"))
+	assert.Equal(t, 1, tableCount(db, "migrations"))
+	exists, _ := db.IsTableExist("author")
+	assert.False(t, exists)
+
+	migs[0].Checksum = "modified"
+	statuses, err = m.Status()
+	assert.NoError(t, err)
+	assert.True(t, statuses[0].Modified)
+	assert.EqualValues(t, ErrChecksumMismatch{"0001_create_book"}, m.Migrate())
+}
+
+func TestUpgradeMigrationTable(t *testing.T) {
+	os.Remove(dbName)
+
+	db, err := xorm.NewEngine("sqlite3", dbName)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec("CREATE TABLE migrations (id VARCHAR(255) PRIMARY KEY)")
+	assert.NoError(t, err)
+	_, err = db.Exec("INSERT INTO migrations (id) VALUES (?)", "201608301400")
+	assert.NoError(t, err)
+
+	m := New(db, DefaultOptions, migrations)
+	assert.NoError(t, m.Migrate())
+	assert.Equal(t, 2, tableCount(db, "migrations"))
+
+	statuses, err := m.Status()
+	assert.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.True(t, statuses[0].AppliedAt.IsZero())
+	assert.True(t, statuses[1].Applied)
+	assert.False(t, statuses[1].AppliedAt.IsZero())
+}
+
+func TestMigrationLock(t *testing.T) {
+	os.Remove(dbName)
+
+	db, err := xorm.NewEngine("sqlite3", dbName)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	options := &Options{
+		TableName:    "migrations",
+		IDColumnName: "id",
+		UseLock:      true,
+		LockTimeout:  300 * time.Millisecond,
+	}
+
+	m := New(db, options, migrations)
+	unlock, err := m.lock()
+	assert.NoError(t, err)
+
+	m2 := New(db, options, migrations)
+	assert.EqualValues(t, ErrLockTimeout, m2.Migrate())
+	assert.NoError(t, unlock())
+
+	assert.NoError(t, m2.Migrate())
+	assert.Equal(t, 2, tableCount(db, "migrations"))
+	assert.Equal(t, 0, tableCount(db, "migrations_lock"))
+
+	assert.NoError(t, m.RollbackTo("201608301400"))
+	assert.Equal(t, 1, tableCount(db, "migrations"))
+	assert.Equal(t, 0, tableCount(db, "migrations_lock"))
+}
+
+func TestTransactionalMigration(t *testing.T) {
+	os.Remove(dbName)
+
+	db, err := xorm.NewEngine("sqlite3", dbName)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	failed := func(tx *xorm.Session) error {
+		if _, err := tx.Exec("CREATE TABLE book (id INTEGER PRIMARY KEY)"); err != nil {
+			return err
+		}
+		_, err := tx.Exec("INSERT INTO no_such_table (id) VALUES (1)")
+		return err
+	}
+
+	m := New(db, DefaultOptions, []*Migration{
+		{ID: "201608301600", MigrateSession: failed},
+	})
+	assert.Error(t, m.Migrate())
+	exists, _ := db.IsTableExist("book")
+	assert.False(t, exists)
+	assert.Equal(t, 0, tableCount(db, "migrations"))
+
+	m = New(db, DefaultOptions, []*Migration{
+		{ID: "201608301600", MigrateSession: failed, DisableTransaction: true},
+	})
+	assert.Error(t, m.Migrate())
+	exists, _ = db.IsTableExist("book")
+	assert.True(t, exists)
+	assert.Equal(t, 0, tableCount(db, "migrations"))
+}
+
+func TestInitSchemaSession(t *testing.T) {
+	os.Remove(dbName)
+
+	db, err := xorm.NewEngine("sqlite3", dbName)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	m := New(db, DefaultOptions, migrations)
+	m.InitSchemaSession(func(tx *xorm.Session) error {
+		return tx.CreateTable(&Person{})
+	})
+	assert.NoError(t, m.Migrate())
+	exists, _ := db.IsTableExist(&Person{})
+	assert.True(t, exists)
+	exists, _ = db.IsTableExist(&Pet{})
+	assert.False(t, exists)
+	assert.Equal(t, 2, tableCount(db, "migrations"))
+}
@@ -0,0 +1,114 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xormplus/xorm"
+)
+
+var sqlFileRegexp = regexp.MustCompile(`^(\d+)(_[^.]*)?\.(up|down)\.sql$`)
+
+type sqlFile struct {
+	version uint64
+	up      *string
+	down    *string
+}
+
+// LoadSQLMigrations loads the migrations from the NNNN_name.up.sql and NNNN_name.down.sql
+// files in the root of fsys, ordered by NNNN. NNNN_name is the id of the migration and the
+// sha256 of the up file is its checksum. A down file is optional. The statements in a file
+// are separated by semicolons and executed in a transaction unless DisableTransaction of the
+// migration is set.
+func LoadSQLMigrations(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var files = make(map[string]*sqlFile)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		matches := sqlFileRegexp.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("Migration file %s should be named as NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+		version, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		sql := string(content)
+
+		id := matches[1] + matches[2]
+		file, ok := files[id]
+		if !ok {
+			file = &sqlFile{version: version}
+			files[id] = file
+		}
+		if matches[3] == "up" {
+			file.up = &sql
+		} else {
+			file.down = &sql
+		}
+	}
+
+	var ids = make([]string, 0, len(files))
+	for id, file := range files {
+		if file.up == nil {
+			return nil, fmt.Errorf("Migration %s has no up file", id)
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if files[ids[i]].version != files[ids[j]].version {
+			return files[ids[i]].version < files[ids[j]].version
+		}
+		return ids[i] < ids[j]
+	})
+
+	var migrations = make([]*Migration, 0, len(ids))
+	for i, id := range ids {
+		file := files[id]
+		if i > 0 && files[ids[i-1]].version == file.version {
+			return nil, fmt.Errorf("Migrations %s and %s have the same version", ids[i-1], id)
+		}
+
+		checksum := sha256.Sum256([]byte(*file.up))
+		migration := &Migration{
+			ID:             id,
+			MigrateSession: execSQLFunc(*file.up),
+			Checksum:       hex.EncodeToString(checksum[:]),
+		}
+		if file.down != nil {
+			migration.RollbackSession = execSQLFunc(*file.down)
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+// LoadSQLMigrationsFromDir loads the migrations from the SQL files in dir, see LoadSQLMigrations.
+func LoadSQLMigrationsFromDir(dir string) ([]*Migration, error) {
+	return LoadSQLMigrations(os.DirFS(dir))
+}
+
+func execSQLFunc(sql string) func(*xorm.Session) error {
+	return func(tx *xorm.Session) error {
+		_, err := tx.Import(strings.NewReader(sql))
+		return err
+	}
+}
@@ -0,0 +1,498 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/xormplus/xorm/dialects"
+	"github.com/xormplus/xorm/internal/utils"
+	"github.com/xormplus/xorm/schemas"
+)
+
+// SchemaChangeType represents the kind of a schema change
+type SchemaChangeType int
+
+// enumerate all the schema change types, a migration plan applies them in this order
+const (
+	SchemaDropForeignKey SchemaChangeType = iota + 1
+	SchemaDropIndex
+	SchemaCreateTable
+	SchemaAddColumn
+	SchemaAlterColumn
+	SchemaDropColumn
+	SchemaAddIndex
+	SchemaAddForeignKey
+)
+
+var schemaChangeTypeNames = map[SchemaChangeType]string{
+	SchemaDropForeignKey: "drop foreign key",
+	SchemaDropIndex:      "drop index",
+	SchemaCreateTable:    "create table",
+	SchemaAddColumn:      "add column",
+	SchemaAlterColumn:    "alter column",
+	SchemaDropColumn:     "drop column",
+	SchemaAddIndex:       "add index",
+	SchemaAddForeignKey:  "add foreign key",
+}
+
+func (tp SchemaChangeType) String() string {
+	return schemaChangeTypeNames[tp]
+}
+
+// enumerate what could be altered on a column
+const (
+	AlterColumnType     = "type"
+	AlterColumnNullable = "nullable"
+	AlterColumnDefault  = "default"
+)
+
+// SchemaChange represents one difference between a struct and its database table.
+// SQLs is empty if the change cannot be done by the dialect, i.e. altering a column on sqlite3.
+type SchemaChange struct {
+	Type       SchemaChangeType
+	TableName  string
+	Table      *schemas.Table  // the struct table of create table
+	Column     *schemas.Column // the struct column, or the database column when dropping
+	OldColumn  *schemas.Column // the database column when altering
+	Alters     []string        // what are altered, see AlterColumnType etc.
+	Index      *schemas.Index
+	ForeignKey *schemas.ForeignKey
+
+	SQLs         []string
+	RollbackSQLs []string
+}
+
+// IsSupported returns false if the dialect cannot apply the change
+func (change *SchemaChange) IsSupported() bool {
+	return len(change.SQLs) > 0
+}
+
+// IsDestructive returns true if the change may lose data
+func (change *SchemaChange) IsDestructive() bool {
+	switch change.Type {
+	case SchemaDropColumn:
+		return true
+	case SchemaAlterColumn:
+		for _, alter := range change.Alters {
+			if alter == AlterColumnType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (change *SchemaChange) String() string {
+	switch change.Type {
+	case SchemaCreateTable:
+		return fmt.Sprintf("%s %s", change.Type, change.TableName)
+	case SchemaAddColumn, SchemaDropColumn:
+		return fmt.Sprintf("%s %s.%s", change.Type, change.TableName, change.Column.Name)
+	case SchemaAlterColumn:
+		var details = make([]string, 0, len(change.Alters))
+		for _, alter := range change.Alters {
+			switch alter {
+			case AlterColumnType:
+				details = append(details, fmt.Sprintf("type %s -> %s", change.OldColumn.SQLType.Name, change.Column.SQLType.Name))
+			case AlterColumnNullable:
+				details = append(details, fmt.Sprintf("nullable %v -> %v", change.OldColumn.Nullable, change.Column.Nullable))
+			case AlterColumnDefault:
+				details = append(details, fmt.Sprintf("default %q -> %q", change.OldColumn.Default, change.Column.Default))
+			}
+		}
+		return fmt.Sprintf("%s %s.%s (%s)", change.Type, change.TableName, change.Column.Name, strings.Join(details, ", "))
+	case SchemaAddIndex, SchemaDropIndex:
+		return fmt.Sprintf("%s %s on %s(%s)", change.Type, change.Index.XName(change.TableName),
+			change.TableName, strings.Join(change.Index.Cols, ","))
+	case SchemaAddForeignKey, SchemaDropForeignKey:
+		return fmt.Sprintf("%s %s on %s(%s)", change.Type, change.ForeignKey.XName(change.TableName),
+			change.TableName, strings.Join(change.ForeignKey.Cols, ","))
+	}
+	return change.Type.String()
+}
+
+// SchemaDiff represents a migration plan which changes the database tables to the structs
+type SchemaDiff struct {
+	DBType  schemas.DBType
+	Changes []*SchemaChange
+}
+
+// IsEmpty returns true if the database tables are the same as the structs
+func (diff *SchemaDiff) IsEmpty() bool {
+	return len(diff.Changes) == 0
+}
+
+// SQLs returns all the SQLs of the plan in order
+func (diff *SchemaDiff) SQLs() []string {
+	var sqls []string
+	for _, change := range diff.Changes {
+		sqls = append(sqls, change.SQLs...)
+	}
+	return sqls
+}
+
+// RollbackSQLs returns the SQLs which undo the plan
+func (diff *SchemaDiff) RollbackSQLs() []string {
+	var sqls []string
+	for i := len(diff.Changes) - 1; i >= 0; i-- {
+		sqls = append(sqls, diff.Changes[i].RollbackSQLs...)
+	}
+	return sqls
+}
+
+// WriteTo writes the plan as a SQL script, every change is commented before its SQLs
+func (diff *SchemaDiff) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	for _, change := range diff.Changes {
+		fmt.Fprintf(&buf, "-- %s", change)
+		if change.IsDestructive() {
+			buf.WriteString(" [destructive]")
+		}
+		buf.WriteString("\n")
+		if !change.IsSupported() {
+			fmt.Fprintf(&buf, "-- not supported on %s\n", diff.DBType)
+		}
+		for _, sql := range change.SQLs {
+			buf.WriteString(sql)
+			buf.WriteString(";\n")
+		}
+	}
+	return buf.WriteTo(w)
+}
+
+func (diff *SchemaDiff) String() string {
+	var buf strings.Builder
+	diff.WriteTo(&buf)
+	return buf.String()
+}
+
+// SchemaDiff compares the structs with the database tables and returns a migration plan
+// which adds, drops and alters columns, indexes and foreign keys. Columns which have been
+// renamed are planned as dropping the old one and adding the new one, and tables which
+// are not given are never dropped.
+func (session *Session) SchemaDiff(beans ...interface{}) (*SchemaDiff, error) {
+	engine := session.engine
+
+	if session.isAutoClose {
+		session.isAutoClose = false
+		defer session.Close()
+	}
+
+	tables, err := engine.dialect.GetTables(session.getQueryer(), session.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		creates   = make([]*schemas.Table, 0, len(beans))
+		tbNames   = make(map[*schemas.Table]string, len(beans))
+		changes   = make([]*SchemaChange, 0)
+		newChange = func(tp SchemaChangeType, tbName string) *SchemaChange {
+			change := &SchemaChange{Type: tp, TableName: tbName}
+			changes = append(changes, change)
+			return change
+		}
+	)
+
+	for _, bean := range beans {
+		v := utils.ReflectValue(bean)
+		table, err := engine.tagParser.ParseWithCache(v)
+		if err != nil {
+			return nil, err
+		}
+		var tbName string
+		if len(session.statement.AltTableName) > 0 {
+			tbName = session.statement.AltTableName
+		} else {
+			tbName = engine.TableName(bean)
+		}
+		tbNameWithSchema := engine.tbNameWithSchema(tbName)
+
+		var oriTable *schemas.Table
+		for _, tb := range tables {
+			if strings.EqualFold(engine.tbNameWithSchema(tb.Name), tbNameWithSchema) {
+				oriTable = tb
+				break
+			}
+		}
+
+		if oriTable == nil {
+			creates = append(creates, table)
+			tbNames[table] = tbNameWithSchema
+			continue
+		}
+
+		if err = engine.loadTableInfo(oriTable); err != nil {
+			return nil, err
+		}
+
+		for _, col := range table.Columns() {
+			oriCol := oriTable.GetColumn(col.Name)
+			if oriCol == nil {
+				change := newChange(SchemaAddColumn, tbNameWithSchema)
+				change.Column = col
+				change.SQLs = []string{engine.dialect.AddColumnSQL(tbNameWithSchema, col)}
+				change.RollbackSQLs = nonEmptySQLs(engine.dialect.DropColumnSQL(tbNameWithSchema, col.Name))
+				continue
+			}
+
+			if alters := diffColumn(engine.dialect, col, oriCol); len(alters) > 0 {
+				change := newChange(SchemaAlterColumn, tbNameWithSchema)
+				change.Column, change.OldColumn, change.Alters = col, oriCol, alters
+				change.SQLs = alterColumnSQLs(engine.dialect, tbNameWithSchema, col, alters)
+				change.RollbackSQLs = alterColumnSQLs(engine.dialect, tbNameWithSchema, oriCol, alters)
+			}
+		}
+
+		for _, colName := range oriTable.ColumnsSeq() {
+			if table.GetColumn(colName) == nil {
+				oriCol := oriTable.GetColumn(colName)
+				change := newChange(SchemaDropColumn, tbNameWithSchema)
+				change.Column = oriCol
+				change.SQLs = nonEmptySQLs(engine.dialect.DropColumnSQL(tbNameWithSchema, oriCol.Name))
+				change.RollbackSQLs = []string{engine.dialect.AddColumnSQL(tbNameWithSchema, oriCol)}
+			}
+		}
+
+		var foundIndexNames = make(map[string]bool)
+		for _, name := range sortedIndexNames(table.Indexes) {
+			index := table.Indexes[name]
+			var oriIndex *schemas.Index
+			for name2, index2 := range oriTable.Indexes {
+				if index.Equal(index2) {
+					oriIndex = index2
+					foundIndexNames[name2] = true
+					break
+				}
+			}
+			if oriIndex != nil && oriIndex.Type == index.Type {
+				continue
+			}
+			if oriIndex != nil {
+				change := newChange(SchemaDropIndex, tbNameWithSchema)
+				change.Index = oriIndex
+				change.SQLs = []string{engine.dialect.DropIndexSQL(tbNameWithSchema, oriIndex)}
+				change.RollbackSQLs = []string{engine.dialect.CreateIndexSQL(tbNameWithSchema, oriIndex)}
+			}
+			change := newChange(SchemaAddIndex, tbNameWithSchema)
+			change.Index = index
+			change.SQLs = []string{engine.dialect.CreateIndexSQL(tbNameWithSchema, index)}
+			change.RollbackSQLs = []string{engine.dialect.DropIndexSQL(tbNameWithSchema, index)}
+		}
+		for _, name2 := range sortedIndexNames(oriTable.Indexes) {
+			if foundIndexNames[name2] {
+				continue
+			}
+			index2 := oriTable.Indexes[name2]
+			change := newChange(SchemaDropIndex, tbNameWithSchema)
+			change.Index = index2
+			change.SQLs = []string{engine.dialect.DropIndexSQL(tbNameWithSchema, index2)}
+			change.RollbackSQLs = []string{engine.dialect.CreateIndexSQL(tbNameWithSchema, index2)}
+		}
+
+		var foundFKNames = make(map[string]bool)
+		for _, name := range sortedForeignKeyNames(table.ForeignKeys) {
+			fk := table.ForeignKeys[name]
+			var found bool
+			for name2, fk2 := range oriTable.ForeignKeys {
+				if fk.Equal(fk2) {
+					foundFKNames[name2] = true
+					found = true
+					break
+				}
+			}
+			if !found {
+				change := newChange(SchemaAddForeignKey, tbNameWithSchema)
+				change.ForeignKey = fk
+				change.SQLs = nonEmptySQLs(engine.dialect.CreateForeignKeySQL(tbNameWithSchema, fk))
+				change.RollbackSQLs = nonEmptySQLs(engine.dialect.DropForeignKeySQL(tbNameWithSchema, fk))
+			}
+		}
+		for _, name2 := range sortedForeignKeyNames(oriTable.ForeignKeys) {
+			fk2 := oriTable.ForeignKeys[name2]
+			// the foreign keys which were not created by xorm are left untouched like Sync2
+			if foundFKNames[name2] || !fk2.IsRegular {
+				continue
+			}
+			change := newChange(SchemaDropForeignKey, tbNameWithSchema)
+			change.ForeignKey = fk2
+			change.SQLs = nonEmptySQLs(engine.dialect.DropForeignKeySQL(tbNameWithSchema, fk2))
+			change.RollbackSQLs = nonEmptySQLs(engine.dialect.CreateForeignKeySQL(tbNameWithSchema, fk2))
+		}
+	}
+
+	for _, table := range sortTablesByForeignKeys(creates) {
+		tbName := tbNames[table]
+		change := newChange(SchemaCreateTable, tbName)
+		change.Table = table
+		change.SQLs, _ = engine.dialect.CreateTableSQL(table, tbName)
+		for _, name := range sortedIndexNames(table.Indexes) {
+			change.SQLs = append(change.SQLs, engine.dialect.CreateIndexSQL(tbName, table.Indexes[name]))
+		}
+		dropSQL, _ := engine.dialect.DropTableSQL(tbName)
+		change.RollbackSQLs = []string{dropSQL}
+	}
+
+	// keep the order of tables and columns for the same type of changes
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Type < changes[j].Type
+	})
+
+	return &SchemaDiff{
+		DBType:  engine.dialect.URI().DBType,
+		Changes: changes,
+	}, nil
+}
+
+// ApplySchemaDiff executes the SQLs of the plan, the changes not supported by the
+// dialect are skipped with a warning
+func (session *Session) ApplySchemaDiff(diff *SchemaDiff) error {
+	if session.isAutoClose {
+		defer session.Close()
+	}
+
+	for _, change := range diff.Changes {
+		if !change.IsSupported() {
+			session.engine.logger.Warnf("%s is not supported on %s", change, diff.DBType)
+			continue
+		}
+		for _, sqlStr := range change.SQLs {
+			if _, err := session.exec(sqlStr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// diffColumn returns what should be altered to change the database column to the struct column,
+// the rules are the same as Sync2 which only warns on them
+func diffColumn(dialect dialects.Dialect, col, oriCol *schemas.Column) []string {
+	var alters []string
+
+	expectedType := strings.ToUpper(dialect.SQLType(col))
+	curType := strings.ToUpper(dialect.SQLType(oriCol))
+	if expectedType != curType && !isSameTypeIgnoreLength(expectedType, curType) {
+		alters = append(alters, AlterColumnType)
+	}
+
+	if !col.IsPrimaryKey && col.Nullable != oriCol.Nullable {
+		alters = append(alters, AlterColumnNullable)
+	}
+
+	if !col.IsAutoIncrement && normalizeDefault(col.Default) != normalizeDefault(oriCol.Default) {
+		isBool := col.SQLType.Name == schemas.Bool || col.SQLType.Name == schemas.Boolean
+		if !(isBool && ((strings.EqualFold(col.Default, "true") && oriCol.Default == "1") ||
+			(strings.EqualFold(col.Default, "false") && oriCol.Default == "0"))) {
+			alters = append(alters, AlterColumnDefault)
+		}
+	}
+	return alters
+}
+
+// isSameTypeIgnoreLength returns true if one type has no length and the other has,
+// i.e. INT and INT(11), since databases report the display length differently
+func isSameTypeIgnoreLength(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return !strings.Contains(a, "(") && strings.HasPrefix(b, a) && b[len(a)] == '('
+}
+
+func normalizeDefault(def string) string {
+	def = strings.TrimSpace(def)
+	// postgres reports the default with a cast, i.e. 'abc'::character varying
+	if idx := strings.Index(def, "::"); idx > 0 {
+		def = def[:idx]
+	}
+	// mssql reports the default in parentheses, i.e. ((0))
+	for len(def) > 1 && def[0] == '(' && def[len(def)-1] == ')' {
+		def = strings.TrimSpace(def[1 : len(def)-1])
+	}
+	return strings.ToLower(def)
+}
+
+// alterColumnSQLs returns the SQLs which alter the column to col, nothing is returned
+// if the dialect cannot do that
+func alterColumnSQLs(dialect dialects.Dialect, tableName string, col *schemas.Column, alters []string) []string {
+	var (
+		quoter  = dialect.Quoter()
+		table   = quoter.Quote(tableName)
+		colName = quoter.Quote(col.Name)
+		sqls    []string
+	)
+	switch dialect.URI().DBType {
+	case schemas.MYSQL:
+		sqls = append(sqls, dialect.ModifyColumnSQL(table, col))
+	case schemas.POSTGRES:
+		for _, alter := range alters {
+			switch alter {
+			case AlterColumnType:
+				sqls = append(sqls, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s", table, colName, dialect.SQLType(col)))
+			case AlterColumnNullable:
+				if col.Nullable {
+					sqls = append(sqls, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", table, colName))
+				} else {
+					sqls = append(sqls, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL", table, colName))
+				}
+			case AlterColumnDefault:
+				if col.Default == "" {
+					sqls = append(sqls, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT", table, colName))
+				} else {
+					sqls = append(sqls, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s", table, colName, col.Default))
+				}
+			}
+		}
+	case schemas.MSSQL:
+		// the default of mssql is a named constraint which cannot be altered here
+		for _, alter := range alters {
+			if alter == AlterColumnType || alter == AlterColumnNullable {
+				var nullable = " NOT NULL"
+				if col.Nullable {
+					nullable = " NULL"
+				}
+				sqls = append(sqls, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s%s", table, colName, dialect.SQLType(col), nullable))
+				break
+			}
+		}
+	case schemas.ORACLE:
+		s, _ := dialects.ColumnString(dialect, col, false)
+		sqls = append(sqls, fmt.Sprintf("ALTER TABLE %s MODIFY (%s)", table, strings.TrimSpace(s)))
+	}
+	return sqls
+}
+
+func nonEmptySQLs(sqls ...string) []string {
+	var res = make([]string, 0, len(sqls))
+	for _, sql := range sqls {
+		if sql != "" {
+			res = append(res, sql)
+		}
+	}
+	return res
+}
+
+func sortedIndexNames(indexes map[string]*schemas.Index) []string {
+	var names = make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedForeignKeyNames(fks map[string]*schemas.ForeignKey) []string {
+	var names = make([]string, 0, len(fks))
+	for name := range fks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
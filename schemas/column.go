@@ -39,7 +39,13 @@ type Column struct {
 	IsDeleted       bool
 	IsCascade       bool
 	IsVersion       bool
-	DefaultIsEmpty  bool // false means column has no default set, but not default value is empty
+	IsShardKey      bool
+	IsSensitive     bool            // the values of the column are masked in the logs, the hooks and the errors
+	EncryptKey      string          // the name of the cipher key encrypting the values of the column
+	BlindIndexOf    string          // the encrypted column whose blind index is stored in the column
+	PartitionPeriod PartitionPeriod // the column partitions the table by the period
+	PartitionAhead  int             // the number of the partitions created ahead by Sync2
+	DefaultIsEmpty  bool            // false means column has no default set, but not default value is empty
 	EnumOptions     map[string]int
 	SetOptions      map[string]int
 	DisableTimeZone bool
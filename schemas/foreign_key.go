@@ -0,0 +1,104 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package schemas
+
+import (
+	"fmt"
+	"strings"
+)
+
+// enumerate all referential actions of a foreign key
+const (
+	FKNoAction   = "NO ACTION"
+	FKRestrict   = "RESTRICT"
+	FKCascade    = "CASCADE"
+	FKSetNull    = "SET NULL"
+	FKSetDefault = "SET DEFAULT"
+)
+
+// ForeignKey represents a database foreign key constraint
+type ForeignKey struct {
+	IsRegular bool
+	Name      string
+	Cols      []string
+	RefTable  string
+	RefCols   []string
+	OnDelete  string
+	OnUpdate  string
+}
+
+// NewForeignKey new a foreign key object which references refTable
+func NewForeignKey(name, refTable string) *ForeignKey {
+	return &ForeignKey{
+		IsRegular: true,
+		Name:      name,
+		Cols:      make([]string, 0),
+		RefTable:  refTable,
+		RefCols:   make([]string, 0),
+	}
+}
+
+// XName returns the constraint name which will be created on the database
+func (fk *ForeignKey) XName(tableName string) string {
+	if !fk.IsRegular || strings.HasPrefix(fk.Name, "FK_") {
+		return fk.Name
+	}
+	tableParts := strings.Split(strings.Replace(tableName, `"`, "", -1), ".")
+	tableName = tableParts[len(tableParts)-1]
+	return fmt.Sprintf("FK_%v_%v", tableName, fk.Name)
+}
+
+// AddColumn adds a local column and the column it references
+func (fk *ForeignKey) AddColumn(col, refCol string) {
+	fk.Cols = append(fk.Cols, col)
+	fk.RefCols = append(fk.RefCols, refCol)
+}
+
+// ParseFKAction normalizes a referential action, i.e. set_null or "set null" to SET NULL.
+// An empty action is returned as is.
+func ParseFKAction(action string) (string, error) {
+	action = strings.ToUpper(strings.TrimSpace(strings.Trim(action, `'"`)))
+	action = strings.Join(strings.Fields(strings.Replace(action, "_", " ", -1)), " ")
+	switch action {
+	case "", FKNoAction, FKRestrict, FKCascade, FKSetNull, FKSetDefault:
+		return action, nil
+	}
+	return "", fmt.Errorf("unknown foreign key action %s", action)
+}
+
+func fkActionEqual(a, b string) bool {
+	normalize := func(action string) string {
+		action, _ = ParseFKAction(action)
+		// RESTRICT and NO ACTION only differ on deferred checking, and
+		// most databases report one of them when no action was given.
+		if action == "" || action == FKRestrict {
+			return FKNoAction
+		}
+		return action
+	}
+	return normalize(a) == normalize(b)
+}
+
+// Equal returns true if the two foreign keys reference the same columns with the same actions
+func (fk *ForeignKey) Equal(dst *ForeignKey) bool {
+	if !strings.EqualFold(fk.RefTable, dst.RefTable) {
+		return false
+	}
+	if len(fk.Cols) != len(dst.Cols) || len(fk.RefCols) != len(dst.RefCols) {
+		return false
+	}
+	for i := 0; i < len(fk.Cols); i++ {
+		if !strings.EqualFold(fk.Cols[i], dst.Cols[i]) {
+			return false
+		}
+	}
+	for i := 0; i < len(fk.RefCols); i++ {
+		if !strings.EqualFold(fk.RefCols[i], dst.RefCols[i]) {
+			return false
+		}
+	}
+	return fkActionEqual(fk.OnDelete, dst.OnDelete) &&
+		fkActionEqual(fk.OnUpdate, dst.OnUpdate)
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package schemas
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PartitionPeriod represents the period of the time partitioned tables, a partitioned
+// table is split into the physical tables named by the table name and the period,
+// i.e. event_202610 for the monthly partition of October 2026
+type PartitionPeriod int
+
+// enumerate all the partition periods, PartitionNone means the table is not partitioned
+const (
+	PartitionNone PartitionPeriod = iota
+	PartitionDaily
+	PartitionMonthly
+	PartitionYearly
+)
+
+// ParsePartitionPeriod parses daily, monthly or yearly to a PartitionPeriod
+func ParsePartitionPeriod(period string) (PartitionPeriod, error) {
+	switch strings.ToLower(strings.TrimSpace(strings.Trim(period, `'"`))) {
+	case "daily", "day":
+		return PartitionDaily, nil
+	case "monthly", "month":
+		return PartitionMonthly, nil
+	case "yearly", "year":
+		return PartitionYearly, nil
+	}
+	return PartitionNone, fmt.Errorf("unknown partition period %s", period)
+}
+
+// String returns the name of the period
+func (p PartitionPeriod) String() string {
+	switch p {
+	case PartitionDaily:
+		return "daily"
+	case PartitionMonthly:
+		return "monthly"
+	case PartitionYearly:
+		return "yearly"
+	}
+	return "none"
+}
+
+// Truncate returns the start of the period holding t in the location of t
+func (p PartitionPeriod) Truncate(t time.Time) time.Time {
+	switch p {
+	case PartitionDaily:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case PartitionMonthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case PartitionYearly:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+	}
+	return t
+}
+
+// Next returns the start of the period after the one holding t
+func (p PartitionPeriod) Next(t time.Time) time.Time {
+	t = p.Truncate(t)
+	switch p {
+	case PartitionDaily:
+		return t.AddDate(0, 0, 1)
+	case PartitionMonthly:
+		return t.AddDate(0, 1, 0)
+	case PartitionYearly:
+		return t.AddDate(1, 0, 0)
+	}
+	return t
+}
+
+// Suffix returns the table name suffix of the period holding t
+func (p PartitionPeriod) Suffix(t time.Time) string {
+	switch p {
+	case PartitionDaily:
+		return t.Format("20060102")
+	case PartitionMonthly:
+		return t.Format("200601")
+	case PartitionYearly:
+		return t.Format("2006")
+	}
+	return ""
+}
+
+// PartitionTableName returns the name of the partition of tableName holding t
+func PartitionTableName(tableName string, p PartitionPeriod, t time.Time) string {
+	if p == PartitionNone {
+		return tableName
+	}
+	return tableName + "_" + p.Suffix(t)
+}
+
+// PartitionTableNames returns the names of the partitions of tableName holding the times
+// in [from, to), the name of the partition holding from is returned if to is not after it
+func PartitionTableNames(tableName string, p PartitionPeriod, from, to time.Time) []string {
+	if p == PartitionNone {
+		return []string{tableName}
+	}
+	var names = []string{PartitionTableName(tableName, p, from)}
+	for t := p.Next(from); t.Before(to); t = p.Next(t) {
+		names = append(names, PartitionTableName(tableName, p, t))
+	}
+	return names
+}
@@ -0,0 +1,47 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package schemas
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePartitionPeriod(t *testing.T) {
+	for period, expected := range map[string]PartitionPeriod{
+		"daily":    PartitionDaily,
+		"Monthly":  PartitionMonthly,
+		"'yearly'": PartitionYearly,
+		" month ":  PartitionMonthly,
+	} {
+		p, err := ParsePartitionPeriod(period)
+		assert.NoError(t, err)
+		assert.EqualValues(t, expected, p)
+	}
+
+	_, err := ParsePartitionPeriod("weekly")
+	assert.Error(t, err)
+}
+
+func TestPartitionTableNames(t *testing.T) {
+	var (
+		from = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+		to   = time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	)
+	assert.EqualValues(t, "event_20261018", PartitionTableName("event", PartitionDaily, from))
+	assert.EqualValues(t, "event_202610", PartitionTableName("event", PartitionMonthly, from))
+	assert.EqualValues(t, "event_2026", PartitionTableName("event", PartitionYearly, from))
+	assert.EqualValues(t, "event", PartitionTableName("event", PartitionNone, from))
+
+	assert.EqualValues(t, []string{"event_202610", "event_202611", "event_202612"},
+		PartitionTableNames("event", PartitionMonthly, from, to))
+	assert.EqualValues(t, []string{"event_2026"}, PartitionTableNames("event", PartitionYearly, from, to))
+	assert.EqualValues(t, []string{"event_2026", "event_2027"},
+		PartitionTableNames("event", PartitionYearly, from, to.Add(time.Second)))
+	assert.EqualValues(t, []string{"event_20261018"}, PartitionTableNames("event", PartitionDaily, from, from))
+	assert.EqualValues(t, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), PartitionMonthly.Next(from))
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package schemas
+
+import (
+	"reflect"
+	"strings"
+)
+
+// RelationType represents the type of a relation between two tables
+type RelationType int
+
+// enumerate all the relation types
+const (
+	// BelongsTo means the table has a foreign key column referencing the related table
+	BelongsTo RelationType = iota + 1
+	// HasMany means the related table has a foreign key column referencing the table
+	HasMany
+	// ManyToMany means a join table references both the table and the related table
+	ManyToMany
+)
+
+// String returns the tag name of the relation type
+func (t RelationType) String() string {
+	switch t {
+	case BelongsTo:
+		return "belongs_to"
+	case HasMany:
+		return "has_many"
+	case ManyToMany:
+		return "many2many"
+	}
+	return "unknown"
+}
+
+// Relation represents a struct field which holds the related rows of another table,
+// the field is not a column
+type Relation struct {
+	Type      RelationType
+	FieldName string
+	// ElemType is the struct type of the related rows
+	ElemType reflect.Type
+	// ForeignKey is the column of the table for BelongsTo, and the column of the related
+	// table for HasMany
+	ForeignKey string
+	// JoinTable, JoinForeignKey and JoinReferences are the join table of ManyToMany and
+	// its columns referencing the table and the related table
+	JoinTable      string
+	JoinForeignKey string
+	JoinReferences string
+}
+
+// AddRelation adds a relation of the table
+func (table *Table) AddRelation(relation *Relation) {
+	table.Relations = append(table.Relations, relation)
+}
+
+// GetRelation returns the relation of the field name, case insensitive, or nil
+func (table *Table) GetRelation(fieldName string) *Relation {
+	for _, relation := range table.Relations {
+		if strings.EqualFold(relation.FieldName, fieldName) {
+			return relation
+		}
+	}
+	return nil
+}
@@ -19,12 +19,16 @@ type Table struct {
 	columnsMap    map[string][]*Column
 	columns       []*Column
 	Indexes       map[string]*Index
+	ForeignKeys   map[string]*ForeignKey
+	Relations     []*Relation
 	PrimaryKeys   []string
 	AutoIncrement string
 	Created       map[string]bool
 	Updated       string
 	Deleted       string
 	Version       string
+	ShardKey      string
+	Partition     string
 	StoreEngine   string
 	Charset       string
 	Comment       string
@@ -42,6 +46,7 @@ func NewTable(name string, t reflect.Type) *Table {
 		columns:     make([]*Column, 0),
 		columnsMap:  make(map[string][]*Column),
 		Indexes:     make(map[string]*Index),
+		ForeignKeys: make(map[string]*ForeignKey),
 		Created:     make(map[string]bool),
 		PrimaryKeys: make([]string, 0),
 	}
@@ -111,6 +116,16 @@ func (table *Table) DeletedColumn() *Column {
 	return table.GetColumn(table.Deleted)
 }
 
+// ShardKeyColumn returns the column tagged by shard, or nil
+func (table *Table) ShardKeyColumn() *Column {
+	return table.GetColumn(table.ShardKey)
+}
+
+// PartitionColumn returns the column tagged by partition, or nil
+func (table *Table) PartitionColumn() *Column {
+	return table.GetColumn(table.Partition)
+}
+
 // AddColumn adds a column to table
 func (table *Table) AddColumn(col *Column) {
 	table.columnsSeq = append(table.columnsSeq, col.Name)
@@ -140,6 +155,12 @@ func (table *Table) AddColumn(col *Column) {
 	if col.IsVersion {
 		table.Version = col.Name
 	}
+	if col.IsShardKey {
+		table.ShardKey = col.Name
+	}
+	if col.PartitionPeriod != PartitionNone {
+		table.Partition = col.Name
+	}
 }
 
 // AddIndex adds an index or an unique to table
@@ -147,6 +168,11 @@ func (table *Table) AddIndex(index *Index) {
 	table.Indexes[index.Name] = index
 }
 
+// AddForeignKey adds a foreign key constraint to table
+func (table *Table) AddForeignKey(fk *ForeignKey) {
+	table.ForeignKeys[fk.Name] = fk
+}
+
 // IDOfV get id from one value of struct
 func (table *Table) IDOfV(rv reflect.Value) (PK, error) {
 	v := reflect.Indirect(rv)
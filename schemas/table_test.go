@@ -109,3 +109,24 @@ func BenchmarkGetColumnIdx(b *testing.B) {
 		}
 	}
 }
+
+func TestForeignKeyEqual(t *testing.T) {
+	fk := NewForeignKey("user_id", "user")
+	fk.AddColumn("user_id", "id")
+
+	fk2 := NewForeignKey("FK_order_user_id", "USER")
+	fk2.AddColumn("USER_ID", "ID")
+	fk2.OnDelete = FKRestrict
+	if !fk.Equal(fk2) {
+		t.Error("foreign keys should be equal")
+	}
+
+	fk2.OnDelete = FKCascade
+	if fk.Equal(fk2) {
+		t.Error("foreign keys with different on delete action should not be equal")
+	}
+
+	if action, err := ParseFKAction("set_null"); err != nil || action != FKSetNull {
+		t.Errorf("parse action got %s, %v", action, err)
+	}
+}
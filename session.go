@@ -57,6 +57,9 @@ type Session struct {
 	afterDeleteBeans map[interface{}]*[]func(interface{})
 	// --
 
+	// the tables written by the uncommitted tx, their cached query results are cleared on commit
+	txWrittenTables []string
+
 	beforeClosures  []func(interface{})
 	afterClosures   []func(interface{})
 	afterProcessors []executedProcessor
@@ -71,6 +74,12 @@ type Session struct {
 
 	ctx         context.Context
 	sessionType sessionType
+	routing     sessionRouting
+
+	// operation is the operation running, which is passed to the hooks with the SQLs
+	operation contexts.Operation
+	sessionID string
+	txID      string
 
 	err error
 }
@@ -124,6 +133,7 @@ func newSession(engine *Engine) *Session {
 
 		sessionType: engineSession,
 	}
+	session.statement.SetEncrypter(engine.ciphers)
 	if engine.logSessionID {
 		session.ctx = context.WithValue(session.ctx, log.SessionKey, session)
 	}
@@ -331,6 +341,8 @@ func (session *Session) canCache() bool {
 		session.statement.RawSQL != "" ||
 		!session.statement.UseCache ||
 		session.statement.IsForUpdate ||
+		session.statement.HasPartitions() ||
+		session.statement.HasSubQueries() ||
 		session.tx != nil ||
 		len(session.statement.SelectStr) > 0 {
 		return false
@@ -338,13 +350,51 @@ func (session *Session) canCache() bool {
 	return true
 }
 
+// beginOperation sets the operation running until the returned func is called, the
+// operations run by it, i.e. the Count of FindAndCount, are not changed
+func (session *Session) beginOperation(op contexts.Operation) func() {
+	if session.operation != "" {
+		return func() {}
+	}
+	session.operation = op
+	return func() {
+		session.operation = ""
+	}
+}
+
+// id returns the id of the session, which is the one logged if the engine logs session ids
+func (session *Session) id() string {
+	if session.sessionID == "" {
+		if id, ok := session.ctx.Value(log.SessionIDKey).(string); ok {
+			session.sessionID = id
+		} else {
+			session.sessionID = newSessionID()
+		}
+	}
+	return session.sessionID
+}
+
+// operationContext returns the context of the SQL executed, which carries the operation
+// info for the hooks, op is the one if there is no operation running
+func (session *Session) operationContext(op contexts.Operation) context.Context {
+	if session.operation != "" {
+		op = session.operation
+	}
+	return contexts.WithOperation(session.ctx, &contexts.OperationInfo{
+		Operation: op,
+		TableName: session.statement.TableName(),
+		SessionID: session.id(),
+		TxID:      session.txID,
+	})
+}
+
 func (session *Session) doPrepare(db *core.DB, sqlStr string) (stmt *core.Stmt, err error) {
 	crc := crc32.ChecksumIEEE([]byte(sqlStr))
 	// TODO try hash(sqlStr+len(sqlStr))
 	var has bool
 	stmt, has = session.stmtCache[crc]
 	if !has {
-		stmt, err = db.PrepareContext(session.ctx, sqlStr)
+		stmt, err = db.PrepareContext(session.operationContext(contexts.OperationPrepare), sqlStr)
 		if err != nil {
 			return nil, err
 		}
@@ -458,6 +508,18 @@ func (session *Session) slice2Bean(scanResults []interface{}, fields []string, b
 			continue
 		}
 
+		// the encrypted values are decrypted by bytes2Value
+		if col := table.GetColumnIdx(key, idx); col != nil && col.EncryptKey != "" {
+			data, err := value2Bytes(&rawValue)
+			if err != nil {
+				return nil, err
+			}
+			if err := session.bytes2Value(col, fieldValue, data); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		if fieldValue.CanAddr() {
 			if structConvert, ok := fieldValue.Addr().Interface().(convert.Conversion); ok {
 				if data, err := value2Bytes(&rawValue); err == nil {
@@ -868,16 +930,16 @@ func (session *Session) saveLastSQL(sql string, args ...interface{}) {
 func (session *Session) logSQL(sqlStr string, sqlArgs ...interface{}) {
 	if session.showSQL {
 		if len(sqlArgs) > 0 {
-			session.engine.logger.Infof("[SQL] %v %#v", sqlStr, sqlArgs)
+			session.engine.logger.Infof("[SQL] %v %#v", sqlStr, session.engine.db.Redactor.Redact(sqlStr, sqlArgs))
 		} else {
 			session.engine.logger.Infof("[SQL] %v", sqlStr)
 		}
 	}
 }
 
-// LastSQL returns last query information
+// LastSQL returns last query information, the sensitive args are masked
 func (session *Session) LastSQL() (string, []interface{}) {
-	return session.lastSQL, session.lastSQLArgs
+	return session.lastSQL, session.engine.db.Redactor.Redact(session.lastSQL, session.lastSQLArgs)
 }
 
 // Unscoped always disable struct tag "deleted"
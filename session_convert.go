@@ -94,6 +94,14 @@ var (
 
 // convert a db data([]byte) to a field value
 func (session *Session) bytes2Value(col *schemas.Column, fieldValue *reflect.Value, data []byte) error {
+	if col.EncryptKey != "" {
+		decrypted, err := session.engine.ciphers.Decrypt(col.EncryptKey, data)
+		if err != nil {
+			return fmt.Errorf("decrypt column %s: %v", col.Name, err)
+		}
+		data = decrypted
+	}
+
 	if structConvert, ok := fieldValue.Addr().Interface().(convert.Conversion); ok {
 		return structConvert.FromDB(data)
 	}
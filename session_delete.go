@@ -5,11 +5,13 @@
 package xorm
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"strconv"
 
 	"github.com/xormplus/xorm/caches"
+	"github.com/xormplus/xorm/contexts"
 	"github.com/xormplus/xorm/schemas"
 )
 
@@ -76,6 +78,8 @@ func (session *Session) cacheDelete(table *schemas.Table, tableName, sqlStr stri
 
 // Delete records, bean's non-empty fields are conditions
 func (session *Session) Delete(bean interface{}) (int64, error) {
+	defer session.beginOperation(contexts.OperationDelete)()
+
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -146,10 +150,35 @@ func (session *Session) Delete(bean interface{}) (int64, error) {
 		}
 	}
 
+	var (
+		r            = session.newReturning()
+		outputSQL    string
+		returningSQL = session.statement.GenReturningSQL()
+	)
+	if r != nil && !r.supported {
+		// the deleted row cannot be selected after deleting
+		selectSQL := fmt.Sprintf("SELECT %s FROM %v", session.engine.dialect.Quoter().Join(r.cols, ","), tableName)
+		if len(condSQL) > 0 {
+			selectSQL += " WHERE " + condSQL
+		}
+		autoResetStatement := session.autoResetStatement
+		session.autoResetStatement = false
+		err := session.queryReturningRow(r, bean, selectSQL+orderSQL, condArgs...)
+		session.autoResetStatement = autoResetStatement
+		if err != nil {
+			return 0, err
+		}
+	}
+
 	var realSQL string
 	argsForCache := make([]interface{}, 0, len(condArgs)*2)
 	if session.statement.GetUnscoped() || table.DeletedColumn() == nil { // tag "deleted" is disabled
-		realSQL = deleteSQL
+		// mssql doesn't support ORDER BY or LIMIT here, so there is always a WHERE
+		if outputSQL = session.statement.GenOutputSQL("DELETED"); len(outputSQL) > 0 {
+			realSQL = fmt.Sprintf("DELETE FROM %v%v WHERE %v", tableName, outputSQL, condSQL)
+		} else {
+			realSQL = deleteSQL
+		}
 		copy(argsForCache, condArgs)
 		argsForCache = append(condArgs, argsForCache...)
 	} else {
@@ -158,9 +187,10 @@ func (session *Session) Delete(bean interface{}) (int64, error) {
 		argsForCache = append(condArgs, argsForCache...)
 
 		deletedColumn := table.DeletedColumn()
-		realSQL = fmt.Sprintf("UPDATE %v SET %v = ? WHERE %v",
+		realSQL = fmt.Sprintf("UPDATE %v SET %v = ?%v WHERE %v",
 			session.engine.Quote(session.statement.TableName()),
 			session.engine.Quote(deletedColumn.Name),
+			session.statement.GenOutputSQL("INSERTED"),
 			condSQL)
 
 		if len(orderSQL) > 0 {
@@ -206,8 +236,18 @@ func (session *Session) Delete(bean interface{}) (int64, error) {
 		session.cacheDelete(table, tableNameNoQuote, deleteSQL, argsForCache...)
 	}
 
+	realSQL += returningSQL
+
 	session.statement.RefTable = table
-	res, err := session.exec(realSQL, condArgs...)
+	var affected int64
+	if r != nil && r.supported {
+		affected, err = session.execReturning(r, bean, realSQL, condArgs...)
+	} else {
+		var res sql.Result
+		if res, err = session.exec(realSQL, condArgs...); err == nil {
+			affected, err = res.RowsAffected()
+		}
+	}
 	if err != nil {
 		return 0, err
 	}
@@ -239,5 +279,5 @@ func (session *Session) Delete(bean interface{}) (int64, error) {
 	cleanupProcessorsClosures(&session.afterClosures)
 	// --
 
-	return res.RowsAffected()
+	return affected, nil
 }
@@ -4,8 +4,12 @@
 
 package xorm
 
+import "github.com/xormplus/xorm/contexts"
+
 // Exist returns true if the record exist otherwise return false
 func (session *Session) Exist(bean ...interface{}) (bool, error) {
+	defer session.beginOperation(contexts.OperationExist)()
+
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -0,0 +1,192 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql/driver"
+	"reflect"
+
+	"github.com/xormplus/xorm/convert"
+	"github.com/xormplus/xorm/exporters"
+	"github.com/xormplus/xorm/schemas"
+)
+
+// Export writes the records of the table of bean to w row by row like Iterate, bean's
+// non-empty fields are conditions. The columns are the selected ones of the struct in the
+// order of the query, the values of the fields implementing convert.Conversion and the
+// JSON fields are exported as they are stored. w is closed when all the rows are written,
+// i.e.
+//
+//	err := engine.Where("age > ?", 18).Export(exporters.NewCSVWriter(w), new(User))
+func (session *Session) Export(w exporters.Writer, bean interface{}) error {
+	if session.isAutoClose {
+		defer session.Close()
+	}
+
+	if session.statement.LastError != nil {
+		return session.statement.LastError
+	}
+
+	rows, err := session.Rows(bean)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	fields, err := rows.rows.Columns()
+	if err != nil {
+		return err
+	}
+	var (
+		zero    = reflect.New(rows.beanType).Elem()
+		cols    = make([]*schemas.Column, 0, len(fields))
+		columns = make([]exporters.Column, 0, len(fields))
+	)
+	table, err := session.engine.tagParser.ParseWithCache(zero)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		col := table.GetColumn(field)
+		if col == nil {
+			continue
+		}
+		fieldValue, err := col.ValueOfV(&zero)
+		if err != nil {
+			return err
+		}
+		cols = append(cols, col)
+		columns = append(columns, exporters.Column{Name: col.Name, Type: exportType(col, fieldValue.Type())})
+	}
+	if err := w.WriteHeader(columns); err != nil {
+		return err
+	}
+
+	var values = make([]interface{}, len(cols))
+	for rows.Next() {
+		b := reflect.New(rows.beanType)
+		if err := rows.Scan(b.Interface()); err != nil {
+			return err
+		}
+		elem := b.Elem()
+		for i, col := range cols {
+			fieldValue, err := col.ValueOfV(&elem)
+			if err != nil {
+				return err
+			}
+			if values[i], err = session.exportValue(col, *fieldValue); err != nil {
+				return err
+			}
+		}
+		if err := w.WriteRow(values); err != nil {
+			return err
+		}
+	}
+	// rows.Err reports sql.ErrNoRows at the end, so check the error of the driver
+	if err := rows.rows.Err(); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// ExportQuery writes the rows of a raw sql, or of the query built like QueryInterface, to
+// w row by row, the columns are typed by the column types reported by the driver. w is
+// closed when all the rows are written.
+func (session *Session) ExportQuery(w exporters.Writer, sqlOrArgs ...interface{}) error {
+	if session.isAutoClose {
+		defer session.Close()
+	}
+
+	sqlStr, args, err := session.statement.GenQuerySQL(sqlOrArgs...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := session.queryRows(sqlStr, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	var columns = make([]exporters.Column, 0, len(columnTypes))
+	for _, columnType := range columnTypes {
+		columns = append(columns, exporters.Column{
+			Name: columnType.Name(),
+			Type: exporters.TypeOf(columnType.ScanType()),
+		})
+	}
+	if err := w.WriteHeader(columns); err != nil {
+		return err
+	}
+
+	var (
+		values = make([]interface{}, len(columns))
+		dests  = make([]interface{}, len(columns))
+	)
+	for i := range values {
+		dests[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dests...); err != nil {
+			return err
+		}
+		if err := w.WriteRow(values); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+var (
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	convertType = reflect.TypeOf((*convert.Conversion)(nil)).Elem()
+)
+
+// isExportedAsStored returns true if the values of the field type are exported as they
+// are stored, which are the conversions and the JSON values
+func isExportedAsStored(fieldType reflect.Type) bool {
+	if reflect.PtrTo(fieldType).Implements(convertType) || fieldType.Implements(convertType) {
+		return true
+	}
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.ConvertibleTo(schemas.TimeType) || fieldType.Implements(valuerType) {
+		return false
+	}
+	switch fieldType.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Array:
+		return true
+	case reflect.Slice:
+		return fieldType.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+// exportType returns the exported type of a column of a struct field
+func exportType(col *schemas.Column, fieldType reflect.Type) exporters.Type {
+	if isExportedAsStored(fieldType) {
+		if col.SQLType.IsBlob() {
+			return exporters.Bytes
+		}
+		return exporters.String
+	}
+	return exporters.TypeOf(fieldType)
+}
+
+// exportValue returns the exported value of a field
+func (session *Session) exportValue(col *schemas.Column, fieldValue reflect.Value) (interface{}, error) {
+	if isExportedAsStored(fieldValue.Type()) {
+		return session.statement.Value2Interface(col, fieldValue)
+	}
+	return fieldValue.Interface(), nil
+}
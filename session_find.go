@@ -11,6 +11,7 @@ import (
 
 	"github.com/xormplus/builder"
 	"github.com/xormplus/xorm/caches"
+	"github.com/xormplus/xorm/contexts"
 	"github.com/xormplus/xorm/core"
 	"github.com/xormplus/xorm/internal/statements"
 	"github.com/xormplus/xorm/internal/utils"
@@ -26,14 +27,25 @@ const (
 // are conditions. beans could be []Struct, []*Struct, map[int64]Struct
 // map[int64]*Struct
 func (session *Session) Find(rowsSlicePtr interface{}, condiBean ...interface{}) error {
+	defer session.beginOperation(contexts.OperationFind)()
+
 	if session.isAutoClose {
 		defer session.Close()
 	}
-	return session.find(rowsSlicePtr, condiBean...)
+	preloads := session.statement.Preloads
+	if err := session.find(rowsSlicePtr, condiBean...); err != nil {
+		return err
+	}
+	if len(preloads) > 0 {
+		return session.preload(rowsSlicePtr, preloads)
+	}
+	return nil
 }
 
 // FindAndCount find the results and also return the counts
 func (session *Session) FindAndCount(rowsSlicePtr interface{}, condiBean ...interface{}) (int64, error) {
+	defer session.beginOperation(contexts.OperationFind)()
+
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -57,6 +69,7 @@ func (session *Session) FindAndCount(rowsSlicePtr interface{}, condiBean ...inte
 
 	} else {
 		session.autoResetStatement = false
+		preloads := session.statement.Preloads
 		err := session.find(rowsSlicePtr, condiBean...)
 		if err != nil {
 			return 0, err
@@ -89,7 +102,14 @@ func (session *Session) FindAndCount(rowsSlicePtr interface{}, condiBean ...inte
 		}
 
 		// session has stored the conditions so we use `unscoped` to avoid duplicated condition.
-		return session.Unscoped().Count(reflect.New(sliceElementType).Interface())
+		count, err := session.Unscoped().Count(reflect.New(sliceElementType).Interface())
+		if err != nil {
+			return 0, err
+		}
+		if len(preloads) > 0 {
+			return count, session.preload(rowsSlicePtr, preloads)
+		}
+		return count, nil
 	}
 
 }
@@ -385,13 +405,13 @@ func (session *Session) cacheFind(t reflect.Type, sqlStr string, rowsSlicePtr in
 			ids = append(ids, pk)
 		}
 
-		session.engine.logger.Debugf("[cache] cache sql: %v, %v, %v, %v, %v", ids, tableName, sqlStr, newsql, args)
+		session.engine.logger.Debugf("[cache] cache sql: %v, %v, %v, %v, %v", ids, tableName, sqlStr, newsql, session.engine.db.Redactor.Redact(newsql, args))
 		err = caches.PutCacheSql(cacher, ids, tableName, newsql, args)
 		if err != nil {
 			return err
 		}
 	} else {
-		session.engine.logger.Debugf("[cache] cache hit sql: %v, %v, %v, %v", tableName, sqlStr, newsql, args)
+		session.engine.logger.Debugf("[cache] cache hit sql: %v, %v, %v, %v", tableName, sqlStr, newsql, session.engine.db.Redactor.Redact(newsql, args))
 	}
 
 	sliceValue := reflect.Indirect(reflect.ValueOf(rowsSlicePtr))
@@ -423,7 +443,7 @@ func (session *Session) cacheFind(t reflect.Type, sqlStr string, rowsSlicePtr in
 			ides = append(ides, id)
 			ididxes[sid] = idx
 		} else {
-			session.engine.logger.Debugf("[cache] cache hit bean: %v, %v, %v", tableName, id, bean)
+			session.engine.logger.Debugf("[cache] cache hit bean: %v, %v", tableName, id)
 
 			pk, err := table.IDOfV(reflect.ValueOf(bean))
 			if err != nil {
@@ -436,7 +456,7 @@ func (session *Session) cacheFind(t reflect.Type, sqlStr string, rowsSlicePtr in
 			}
 
 			if sid != xid {
-				session.engine.logger.Errorf("[cache] error cache: %v, %v, %v", xid, sid, bean)
+				session.engine.logger.Errorf("[cache] error cache: %v, %v, %v", tableName, xid, sid)
 				return ErrCacheFailed
 			}
 			temps[idx] = bean
@@ -453,6 +473,7 @@ func (session *Session) cacheFind(t reflect.Type, sqlStr string, rowsSlicePtr in
 			session.engine.tagParser,
 			session.engine.DatabaseTZ,
 		)
+		session.statement.SetEncrypter(session.engine.ciphers)
 		if len(table.PrimaryKeys) == 1 {
 			ff := make([]interface{}, 0, len(ides))
 			for _, ie := range ides {
@@ -494,7 +515,7 @@ func (session *Session) cacheFind(t reflect.Type, sqlStr string, rowsSlicePtr in
 
 			bean := rv.Interface()
 			temps[ididxes[sid]] = bean
-			session.engine.logger.Debugf("[cache] cache bean: %v, %v, %v, %v", tableName, id, bean, temps)
+			session.engine.logger.Debugf("[cache] cache bean: %v, %v", tableName, id)
 			cacher.PutBean(tableName, sid, bean)
 		}
 	}
@@ -502,7 +523,7 @@ func (session *Session) cacheFind(t reflect.Type, sqlStr string, rowsSlicePtr in
 	for j := 0; j < len(temps); j++ {
 		bean := temps[j]
 		if bean == nil {
-			session.engine.logger.Warnf("[cache] cache no hit: %v, %v, %v", tableName, ids[j], temps)
+			session.engine.logger.Warnf("[cache] cache no hit: %v, %v", tableName, ids[j])
 			// return errors.New("cache error") // !nashtsai! no need to return error, but continue instead
 			continue
 		}
@@ -12,6 +12,7 @@ import (
 	"strconv"
 
 	"github.com/xormplus/xorm/caches"
+	"github.com/xormplus/xorm/contexts"
 	"github.com/xormplus/xorm/core"
 	"github.com/xormplus/xorm/internal/utils"
 	"github.com/xormplus/xorm/schemas"
@@ -20,10 +21,17 @@ import (
 // Get retrieve one record from database, bean's non-empty fields
 // will be as conditions
 func (session *Session) Get(bean interface{}) (bool, error) {
+	defer session.beginOperation(contexts.OperationGet)()
+
 	if session.isAutoClose {
 		defer session.Close()
 	}
-	return session.get(bean)
+	preloads := session.statement.Preloads
+	has, err := session.get(bean)
+	if err != nil || !has || len(preloads) == 0 {
+		return has, err
+	}
+	return has, session.preload(bean, preloads)
 }
 
 func (session *Session) get(bean interface{}) (bool, error) {
@@ -312,7 +320,7 @@ func (session *Session) cacheGet(bean interface{}, sqlStr string, args ...interf
 	tableName := session.statement.TableName()
 	cacher := session.engine.cacherMgr.GetCacher(tableName)
 
-	session.engine.logger.Debugf("[cache] Get SQL: %s, %v", newsql, args)
+	session.engine.logger.Debugf("[cache] Get SQL: %s, %v", newsql, session.engine.db.Redactor.Redact(newsql, args))
 	table := session.statement.RefTable
 	ids, err := caches.GetCacheSql(cacher, tableName, newsql, args)
 	if err != nil {
@@ -373,10 +381,10 @@ func (session *Session) cacheGet(bean interface{}, sqlStr string, args ...interf
 				return has, err
 			}
 
-			session.engine.logger.Debugf("[cache] cache bean: %s, %v, %v", tableName, id, cacheBean)
+			session.engine.logger.Debugf("[cache] cache bean: %s, %v", tableName, id)
 			cacher.PutBean(tableName, sid, cacheBean)
 		} else {
-			session.engine.logger.Debugf("[cache] cache hit: %s, %v, %v", tableName, id, cacheBean)
+			session.engine.logger.Debugf("[cache] cache hit: %s, %v", tableName, id)
 			has = true
 		}
 		structValue.Set(reflect.Indirect(reflect.ValueOf(cacheBean)))
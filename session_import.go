@@ -0,0 +1,250 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/xormplus/xorm/importers"
+	"github.com/xormplus/xorm/internal/utils"
+	"github.com/xormplus/xorm/schemas"
+)
+
+// defaultImportBatchSize is the number of the rows inserted at once by ImportData
+const defaultImportBatchSize = 500
+
+// ImportResult reports the rows imported by ImportData
+type ImportResult struct {
+	// Inserted is the number of the inserted rows
+	Inserted int64
+	// Errors are the errors of the rows which are not inserted with their line numbers
+	Errors []*importers.RowError
+}
+
+// ImportData reads the rows of r in format and inserts them into the table of bean, i.e.
+//
+//	result, err := engine.ImportData(new(User), file, importers.CSV)
+//
+// The columns of the rows are mapped to the fields of bean by the column names, or by the
+// field names through the column mapper. The rows which could not be read, have unknown
+// columns or values of wrong types are reported by ImportResult.Errors with their line
+// numbers, and the others are inserted by InsertMulti in batches of BufferSize, which is
+// 500 by default. If a batch fails, its rows are inserted one by one to report the failed
+// ones. Every batch is inserted in a transaction, or in a savepoint if the session is
+// in a transaction, so a failed batch is rolled back entirely before its rows are inserted
+// one by one. The error returned is the one of the data which could not be read at all.
+func (session *Session) ImportData(bean interface{}, r io.Reader, format importers.Format) (*ImportResult, error) {
+	if session.isAutoClose {
+		defer session.Close()
+	}
+
+	reader, err := importers.NewReader(r, format)
+	if err != nil {
+		return nil, err
+	}
+	return session.importData(bean, reader)
+}
+
+// ImportReader inserts the rows read by reader into the table of bean like ImportData,
+// which could be used with the options of the readers, i.e. the sheet of XLSX
+func (session *Session) ImportReader(bean interface{}, reader importers.Reader) (*ImportResult, error) {
+	if session.isAutoClose {
+		defer session.Close()
+	}
+	return session.importData(bean, reader)
+}
+
+func (session *Session) importData(bean interface{}, reader importers.Reader) (*ImportResult, error) {
+	defer session.resetStatement()
+	if session.statement.LastError != nil {
+		return nil, session.statement.LastError
+	}
+	// the session is closed by the callers, it should not be closed by the inserts in the
+	// transactions of the batches
+	session.isAutoClose = false
+
+	beanValue := reflect.Indirect(reflect.ValueOf(bean))
+	if beanValue.Kind() != reflect.Struct {
+		return nil, errors.New("needs a pointer to a struct")
+	}
+	table, err := session.engine.tagParser.ParseWithCache(beanValue)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		beanType  = beanValue.Type()
+		tableName = session.statement.AltTableName
+		batchSize = session.statement.BufferSize
+		result    = new(ImportResult)
+	)
+	if batchSize <= 0 {
+		batchSize = defaultImportBatchSize
+	}
+	var (
+		batch = reflect.MakeSlice(reflect.SliceOf(reflect.PtrTo(beanType)), 0, batchSize)
+		lines = make([]int, 0, batchSize)
+	)
+	flush := func() {
+		if batch.Len() == 0 {
+			return
+		}
+		// the batch is inserted atomically, so its rows are inserted one by one only if
+		// none of them is inserted, i.e. when InsertMulti splits it by the partitions
+		insertBatch := session.importInSavePoint
+		if session.isAutoCommit {
+			insertBatch = session.importInTx
+		}
+		affected, err := insertBatch(func() (int64, error) {
+			session.statement.AltTableName = tableName
+			return session.InsertMulti(batch.Interface())
+		})
+		if err == nil {
+			result.Inserted += affected
+		} else {
+			for i := 0; i < batch.Len(); i++ {
+				row := batch.Index(i).Interface()
+				insertRow := func() (int64, error) {
+					session.statement.AltTableName = tableName
+					return session.Insert(row)
+				}
+				var (
+					affected int64
+					err      error
+				)
+				if session.isAutoCommit {
+					affected, err = insertRow()
+				} else {
+					affected, err = session.importInSavePoint(insertRow)
+				}
+				if err != nil {
+					result.Errors = append(result.Errors, &importers.RowError{Line: lines[i], Err: err})
+					continue
+				}
+				result.Inserted += affected
+			}
+		}
+		batch = batch.Slice(0, 0)
+		lines = lines[:0]
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if rowErr, ok := err.(*importers.RowError); ok {
+			result.Errors = append(result.Errors, rowErr)
+			continue
+		} else if err != nil {
+			return result, err
+		}
+
+		row := reflect.New(beanType)
+		if err := session.importRecord(table, row.Elem(), record); err != nil {
+			result.Errors = append(result.Errors, &importers.RowError{Line: record.Line, Err: err})
+			continue
+		}
+		batch = reflect.Append(batch, row)
+		lines = append(lines, record.Line)
+		if batch.Len() >= batchSize {
+			flush()
+		}
+	}
+	flush()
+	return result, nil
+}
+
+// importInTx runs insert in a transaction which is rolled back if insert fails
+func (session *Session) importInTx(insert func() (int64, error)) (int64, error) {
+	if err := session.Begin(); err != nil {
+		return 0, err
+	}
+	affected, err := insert()
+	if err != nil {
+		if err := session.Rollback(); err != nil {
+			return 0, err
+		}
+		return 0, err
+	}
+	if err := session.Commit(); err != nil {
+		return 0, err
+	}
+	return affected, nil
+}
+
+// importInSavePoint runs insert in a savepoint of the transaction of the session, which
+// is rolled back if insert fails, so the transaction could still be used, i.e. on postgres
+func (session *Session) importInSavePoint(insert func() (int64, error)) (int64, error) {
+	var (
+		isMSSQL     = session.engine.Dialect().URI().DBType == schemas.MSSQL
+		saveSQL     string
+		rollbackSQL string
+	)
+	if isMSSQL {
+		savePointID := "xorm" + utils.NewShortUUID().String()
+		saveSQL = "save tran " + savePointID
+		rollbackSQL = "rollback tran " + savePointID
+	} else {
+		savePointID := "xorm" + utils.NewV1().WithoutDashString()
+		saveSQL = "SAVEPOINT " + savePointID
+		rollbackSQL = "ROLLBACK TO SAVEPOINT " + savePointID
+	}
+
+	session.saveLastSQL(saveSQL)
+	if _, err := session.tx.Exec(saveSQL); err != nil {
+		return 0, err
+	}
+	affected, err := insert()
+	if err != nil {
+		session.saveLastSQL(rollbackSQL)
+		if _, err := session.tx.Exec(rollbackSQL); err != nil {
+			return 0, err
+		}
+		return 0, err
+	}
+	// mssql releases the savepoints with the transaction only
+	if !isMSSQL {
+		releaseSQL := "RELEASE " + saveSQL
+		session.saveLastSQL(releaseSQL)
+		if _, err := session.tx.Exec(releaseSQL); err != nil {
+			return 0, err
+		}
+	}
+	return affected, nil
+}
+
+// importRecord sets the values of a record to the fields of a struct, the empty values
+// are NULL except the ones of the string fields
+func (session *Session) importRecord(table *schemas.Table, elem reflect.Value, record *importers.Record) error {
+	mapper := session.engine.GetColumnMapper()
+	for name, text := range record.Values {
+		col := table.GetColumn(name)
+		if col == nil {
+			col = table.GetColumn(mapper.Obj2Table(name))
+		}
+		if col == nil {
+			return fmt.Errorf("unknown column %s", name)
+		}
+		if col.MapType == schemas.ONLYFROMDB {
+			continue
+		}
+
+		fieldValue, err := col.ValueOfV(&elem)
+		if err != nil {
+			return err
+		}
+		if text == "" && fieldValue.Kind() != reflect.String {
+			continue
+		}
+		if err := session.bytes2Value(col, fieldValue, []byte(text)); err != nil {
+			return fmt.Errorf("column %s: %v", col.Name, err)
+		}
+	}
+	return nil
+}
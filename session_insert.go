@@ -5,6 +5,7 @@
 package xorm
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
@@ -13,6 +14,7 @@ import (
 	"strings"
 
 	// "github.com/xormplus/builder"
+	"github.com/xormplus/xorm/contexts"
 	"github.com/xormplus/xorm/internal/utils"
 	"github.com/xormplus/xorm/schemas"
 )
@@ -22,6 +24,8 @@ var ErrNoElementsOnSlice = errors.New("No element on slice when insert")
 
 // Insert insert one or more beans
 func (session *Session) Insert(beans ...interface{}) (int64, error) {
+	defer session.beginOperation(contexts.OperationInsert)()
+
 	var affected int64
 	var err error
 
@@ -75,7 +79,7 @@ func (session *Session) Insert(beans ...interface{}) (int64, error) {
 					return 0, ErrNoElementsOnSlice
 				}
 
-				cnt, err := session.innerInsertMulti(bean)
+				cnt, err := session.insertMultiPartitions(bean)
 				if err != nil {
 					return affected, err
 				}
@@ -142,6 +146,11 @@ func (session *Session) innerInsertMulti(rowsSlicePtr interface{}) (int64, error
 		if processor, ok := interface{}(elemValue).(BeforeInsertProcessor); ok {
 			processor.BeforeInsert()
 		}
+		if vv.CanAddr() {
+			if err := session.statement.SetBlindIndexes(table, vv.Addr().Interface()); err != nil {
+				return 0, err
+			}
+		}
 		// --
 
 		for _, col := range table.Columns() {
@@ -168,6 +177,10 @@ func (session *Session) innerInsertMulti(rowsSlicePtr interface{}) (int64, error
 			if (col.IsCreated || col.IsUpdated) && session.statement.UseAutoTime {
 				val, t := session.engine.nowTime(col)
 				args = append(args, val)
+				if i == 0 && col.PartitionPeriod != schemas.PartitionNone {
+					session.statement.RoutePartition(t)
+					tableName = session.statement.TableName()
+				}
 
 				var colName = col.Name
 				session.afterClosures = append(session.afterClosures, func(bean interface{}) {
@@ -200,10 +213,20 @@ func (session *Session) innerInsertMulti(rowsSlicePtr interface{}) (int64, error
 	}
 	cleanupProcessorsClosures(&session.beforeClosures)
 
+	// oracle cannot return the rows of INSERT ALL, so select them again like mysql
+	r := session.newReturning()
+	isOracle := session.engine.dialect.URI().DBType == schemas.ORACLE
+	if r != nil && (!r.supported || isOracle) {
+		if table.AutoIncrement != "" && session.engine.dialect.URI().DBType != schemas.MYSQL {
+			return 0, fmt.Errorf("returning of multiple inserted rows with autoincrement column is not supported on %s",
+				session.engine.dialect.URI().DBType)
+		}
+	}
+
 	quoter := session.engine.dialect.Quoter()
 	var sql string
 	colStr := quoter.Join(colNames, ",")
-	if session.engine.dialect.URI().DBType == schemas.ORACLE {
+	if isOracle {
 		temp := fmt.Sprintf(") INTO %s (%v) VALUES (",
 			quoter.Quote(tableName),
 			colStr)
@@ -212,15 +235,40 @@ func (session *Session) innerInsertMulti(rowsSlicePtr interface{}) (int64, error
 			colStr,
 			strings.Join(colMultiPlaces, temp))
 	} else {
-		sql = fmt.Sprintf("INSERT INTO %s (%v) VALUES (%v)",
+		sql = fmt.Sprintf("INSERT INTO %s (%v)%s VALUES (%v)%s",
 			quoter.Quote(tableName),
 			colStr,
-			strings.Join(colMultiPlaces, "),("))
+			session.statement.GenOutputSQL("INSERTED"),
+			strings.Join(colMultiPlaces, "),("),
+			session.statement.GenReturningSQL())
 	}
-	res, err := session.exec(sql, args...)
-	if err != nil {
-		session.engine.logger.Errorf("[SQL][%p] %v", session, err)
-		return 0, err
+
+	var affected int64
+	if r != nil && r.supported && !isOracle {
+		var elems = make([]interface{}, 0, size)
+		for i := 0; i < size; i++ {
+			elems = append(elems, reflect.Indirect(sliceValue.Index(i)).Addr().Interface())
+		}
+		var err error
+		if affected, err = session.queryReturning(r, elems, sql, args...); err != nil {
+			session.engine.logger.Errorf("[SQL][%p] %v", session, err)
+			return affected, err
+		}
+	} else {
+		res, err := session.exec(sql, args...)
+		if err != nil {
+			session.engine.logger.Errorf("[SQL][%p] %v", session, err)
+			return 0, err
+		}
+		if affected, err = res.RowsAffected(); err != nil {
+			return 0, err
+		}
+
+		if r != nil {
+			if err := session.reselectMultiReturning(r, sliceValue, res); err != nil {
+				return affected, err
+			}
+		}
 	}
 
 	session.cacheInsert(tableName)
@@ -256,11 +304,50 @@ func (session *Session) innerInsertMulti(rowsSlicePtr interface{}) (int64, error
 	}
 
 	cleanupProcessorsClosures(&session.afterClosures)
-	return res.RowsAffected()
+	return affected, nil
+}
+
+// reselectMultiReturning fills the inserted beans by their primary keys. The autoincrement
+// values are only known on mysql, which allocates consecutive ids from LastInsertId for
+// the rows of one insert statement.
+func (session *Session) reselectMultiReturning(r *returning, sliceValue reflect.Value, res sql.Result) error {
+	var (
+		table = r.table
+		size  = sliceValue.Len()
+		beans = make([]interface{}, 0, size)
+		pks   = make([]schemas.PK, 0, size)
+		id    int64
+	)
+	if table.AutoIncrement != "" {
+		var err error
+		if id, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < size; i++ {
+		bean := reflect.Indirect(sliceValue.Index(i)).Addr().Interface()
+		if table.AutoIncrement != "" && id > 0 {
+			aiValue, err := table.AutoIncrColumn().ValueOf(bean)
+			if err != nil {
+				return err
+			}
+			if aiValue.CanSet() && utils.IsValueZero(*aiValue) {
+				aiValue.Set(int64ToIntValue(id+int64(i), aiValue.Type()))
+			}
+		}
+
+		pk, _ := beanPK(table, bean)
+		beans = append(beans, bean)
+		pks = append(pks, pk)
+	}
+	return session.reselectReturning(r, beans, pks)
 }
 
 // InsertMulti insert multiple records
 func (session *Session) InsertMulti(rowsSlicePtr interface{}) (int64, error) {
+	defer session.beginOperation(contexts.OperationInsert)()
+
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -274,7 +361,7 @@ func (session *Session) InsertMulti(rowsSlicePtr interface{}) (int64, error) {
 		return 0, ErrNoElementsOnSlice
 	}
 
-	return session.innerInsertMulti(rowsSlicePtr)
+	return session.insertMultiPartitions(rowsSlicePtr)
 }
 
 func (session *Session) innerInsert(bean interface{}) (int64, error) {
@@ -295,13 +382,16 @@ func (session *Session) innerInsert(bean interface{}) (int64, error) {
 		processor.BeforeInsert()
 	}
 
-	var tableName = session.statement.TableName()
 	table := session.statement.RefTable
+	if err := session.statement.SetBlindIndexes(table, bean); err != nil {
+		return 0, err
+	}
 
 	colNames, args, err := session.genInsertColumns(bean)
 	if err != nil {
 		return 0, err
 	}
+	var tableName = session.statement.TableName()
 
 	sqlStr, args, err := session.statement.GenInsertSQL(colNames, args)
 	if err != nil {
@@ -336,6 +426,15 @@ func (session *Session) innerInsert(bean interface{}) (int64, error) {
 		cleanupProcessorsClosures(&session.afterClosures) // cleanup after used
 	}
 
+	if r := session.newReturning(); r != nil {
+		affected, err := session.insertReturning(r, bean, sqlStr, args)
+		if err != nil {
+			return affected, err
+		}
+		handleAfterInsertProcessorFunc(bean)
+		return affected, nil
+	}
+
 	// for postgres, many of them didn't implement lastInsertId, so we should
 	// implemented it ourself.
 	if session.engine.dialect.URI().DBType == schemas.ORACLE && len(table.AutoIncrement) > 0 {
@@ -465,10 +564,64 @@ func (session *Session) innerInsert(bean interface{}) (int64, error) {
 	return res.RowsAffected()
 }
 
+// insertReturning executes the insert SQL and fills bean with the returned columns
+func (session *Session) insertReturning(r *returning, bean interface{}, sqlStr string, args []interface{}) (int64, error) {
+	var (
+		table    = r.table
+		affected int64
+	)
+	if r.supported {
+		var err error
+		if affected, err = session.execReturning(r, bean, sqlStr, args...); err != nil {
+			return affected, err
+		}
+	} else {
+		res, err := session.exec(sqlStr, args...)
+		if err != nil {
+			return 0, err
+		}
+		if affected, err = res.RowsAffected(); err != nil {
+			return 0, err
+		}
+
+		if table.AutoIncrement != "" {
+			if id, err := res.LastInsertId(); err == nil && id > 0 {
+				aiValue, err := table.AutoIncrColumn().ValueOf(bean)
+				if err != nil {
+					return affected, err
+				}
+				if aiValue.IsValid() && aiValue.CanSet() {
+					aiValue.Set(int64ToIntValue(id, aiValue.Type()))
+				}
+			}
+		}
+
+		if pk, ok := beanPK(table, bean); ok {
+			if err := session.reselectReturning(r, []interface{}{bean}, []schemas.PK{pk}); err != nil {
+				return affected, err
+			}
+		}
+	}
+
+	session.cacheInsert(r.tableName)
+
+	if table.Version != "" && session.statement.CheckVersion && !r.hasColumn(table.Version) {
+		verValue, err := table.VersionColumn().ValueOf(bean)
+		if err != nil {
+			session.engine.logger.Errorf("%v", err)
+		} else if verValue.IsValid() && verValue.CanSet() {
+			session.incrVersionFieldValue(verValue)
+		}
+	}
+	return affected, nil
+}
+
 // InsertOne insert only one struct into database as a record.
 // The in parameter bean must a struct or a point to struct. The return
 // parameter is inserted and error
 func (session *Session) InsertOne(bean interface{}) (int64, error) {
+	defer session.beginOperation(contexts.OperationInsert)()
+
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -542,6 +695,9 @@ func (session *Session) genInsertColumns(bean interface{}) ([]string, []interfac
 			// if time is non-empty, then set to auto time
 			val, t := session.engine.nowTime(col)
 			args = append(args, val)
+			if col.PartitionPeriod != schemas.PartitionNone {
+				session.statement.RoutePartition(t)
+			}
 
 			var colName = col.Name
 			session.afterClosures = append(session.afterClosures, func(bean interface{}) {
@@ -624,6 +780,13 @@ func (session *Session) insertMap(columns []string, args []interface{}) (int64,
 		return 0, ErrTableNotFound
 	}
 
+	if table := session.statement.RefTable; table != nil {
+		var err error
+		if columns, args, err = session.statement.EncryptMapValues(table, columns, args); err != nil {
+			return 0, err
+		}
+	}
+
 	sql, args, err := session.statement.GenInsertMapSQL(columns, args)
 	if err != nil {
 		return 0, err
@@ -7,6 +7,7 @@ package xorm
 import (
 	"reflect"
 
+	"github.com/xormplus/xorm/contexts"
 	"github.com/xormplus/xorm/internal/utils"
 )
 
@@ -16,6 +17,8 @@ type IterFunc func(idx int, bean interface{}) error
 // Rows return sql.Rows compatible Rows obj, as a forward Iterator object for iterating record by record, bean's non-empty fields
 // are conditions.
 func (session *Session) Rows(bean interface{}) (*Rows, error) {
+	defer session.beginOperation(contexts.OperationIterate)()
+
 	return newRows(session, bean)
 }
 
@@ -23,6 +26,8 @@ func (session *Session) Rows(bean interface{}) (*Rows, error) {
 // are conditions. beans could be []Struct, []*Struct, map[int64]Struct
 // map[int64]*Struct
 func (session *Session) Iterate(bean interface{}, fun IterFunc) error {
+	defer session.beginOperation(contexts.OperationIterate)()
+
 	if session.isAutoClose {
 		defer session.Close()
 	}
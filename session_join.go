@@ -0,0 +1,361 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/xormplus/builder"
+	"github.com/xormplus/xorm/contexts"
+	"github.com/xormplus/xorm/internal/statements"
+	"github.com/xormplus/xorm/schemas"
+)
+
+// the join operators of Join
+const (
+	InnerJoin = "INNER"
+	LeftJoin  = "LEFT"
+	RightJoin = "RIGHT"
+	FullJoin  = "FULL OUTER"
+)
+
+// joinFieldKind represents how a table is mapped to a field of the joined struct
+type joinFieldKind int
+
+const (
+	joinFieldStruct joinFieldKind = iota
+	joinFieldPtr
+	joinFieldSlice
+	joinFieldSlicePtr
+)
+
+// joinField maps the columns of a table to a field of the joined struct
+type joinField struct {
+	table *schemas.Table
+	name  string
+	// index is the index of the field, -1 means the joined struct itself
+	index int
+	kind  joinFieldKind
+	start int
+	end   int
+}
+
+// FindJoined finds the joined rows of the tables joined by beans, i.e.
+//
+//	type UserOrders struct {
+//		User
+//		Orders []Order
+//	}
+//	var users []UserOrders
+//	err := engine.Join(xorm.LeftJoin, &Order{}, "order.user_id = user.id").FindJoined(&users)
+//
+// The main table is given by Table, or else it's the first embedded struct of the element,
+// or else the element itself. Every table is mapped to the field of its struct, the pointer
+// to it or the slice of them, the field with the name of its alias is chosen if a table is
+// joined more than once. The columns of the tables are selected by their names and scanned
+// in order, so there is no need to alias them. The rows are collected into the slice fields
+// by the primary key of the main table, and a pointer field is nil if its table has no
+// matched row. The tables without a field are only joined, Cols and Omit are ignored and
+// Limit limits the joined rows.
+func (session *Session) FindJoined(rowsSlicePtr interface{}) error {
+	defer session.beginOperation(contexts.OperationFind)()
+
+	if session.isAutoClose {
+		defer session.Close()
+	}
+	defer session.resetStatement()
+	if session.statement.LastError != nil {
+		return session.statement.LastError
+	}
+
+	sliceValue := reflect.Indirect(reflect.ValueOf(rowsSlicePtr))
+	if sliceValue.Kind() != reflect.Slice {
+		return errors.New("needs a pointer to a slice")
+	}
+	elemType := sliceValue.Type().Elem()
+	isPointer := elemType.Kind() == reflect.Ptr
+	if isPointer {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return errors.New("needs a pointer to a slice of struct")
+	}
+
+	fields, err := session.joinFields(elemType)
+	if err != nil {
+		return err
+	}
+
+	var (
+		quoter     = session.engine.dialect.Quoter()
+		columnStrs []string
+		hasSlice   bool
+	)
+	for i := range fields {
+		field := &fields[i]
+		field.start = len(columnStrs)
+		for _, col := range field.table.Columns() {
+			if col.MapType == schemas.ONLYTODB {
+				continue
+			}
+			columnStrs = append(columnStrs, quoter.Quote(field.name)+"."+quoter.Quote(col.Name))
+		}
+		field.end = len(columnStrs)
+		hasSlice = hasSlice || field.kind == joinFieldSlice || field.kind == joinFieldSlicePtr
+	}
+	session.statement.SelectStr = strings.Join(columnStrs, ", ")
+
+	var autoCond builder.Cond
+	if col := session.statement.RefTable.DeletedColumn(); col != nil && !session.statement.GetUnscoped() {
+		autoCond = session.statement.CondDeleted(col)
+	}
+	sqlStr, args, err := session.statement.GenFindSQL(autoCond)
+	if err != nil {
+		return err
+	}
+
+	rows, err := session.queryRows(sqlStr, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var (
+		elems    []reflect.Value
+		elemKeys = make(map[string]int)
+		seen     = make(map[string]bool)
+	)
+	for rows.Next() {
+		scanResults := make([]interface{}, len(columnStrs))
+		for i := range scanResults {
+			var cell interface{}
+			scanResults[i] = &cell
+		}
+		if err := rows.Scan(scanResults...); err != nil {
+			return err
+		}
+
+		var (
+			main         = fields[0]
+			key          = joinRowKey(main.table, scanResults[main.start:main.end], main.table.Columns())
+			idx, existed = elemKeys[key]
+		)
+		if !hasSlice || !existed {
+			elems = append(elems, reflect.New(elemType).Elem())
+			idx, existed = len(elems)-1, false
+			elemKeys[key] = idx
+		}
+
+		for i, field := range fields {
+			isSlice := field.kind == joinFieldSlice || field.kind == joinFieldSlicePtr
+			// the other fields are scanned by the first row of the element
+			if existed && !isSlice {
+				continue
+			}
+			if joinRowIsNull(scanResults[field.start:field.end]) {
+				continue
+			}
+			if isSlice {
+				childKey := fmt.Sprintf("%d\x00%d\x00%s", idx, i, joinRowKey(field.table, scanResults[field.start:field.end], field.table.Columns()))
+				if seen[childKey] {
+					continue
+				}
+				seen[childKey] = true
+			}
+			if err := session.scanJoinField(elems[idx], field, scanResults[field.start:field.end]); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	for _, elem := range elems {
+		if isPointer {
+			sliceValue.Set(reflect.Append(sliceValue, elem.Addr()))
+		} else {
+			sliceValue.Set(reflect.Append(sliceValue, elem))
+		}
+	}
+	return session.executeProcessors()
+}
+
+// joinFields maps the main table and the joined tables to the fields of elemType, the main
+// table is the first one
+func (session *Session) joinFields(elemType reflect.Type) ([]joinField, error) {
+	var (
+		statement = session.statement
+		used      = make(map[int]bool)
+		mainType  reflect.Type
+	)
+	if statement.RefTable != nil {
+		mainType = statement.RefTable.Type
+	} else {
+		mainType = elemType
+		for i := 0; i < elemType.NumField(); i++ {
+			field := elemType.Field(i)
+			if field.Anonymous && field.Type.Kind() == reflect.Struct && !isJoinedType(statement.JoinTables(), field.Type) {
+				mainType = field.Type
+				break
+			}
+		}
+		if err := statement.SetRefValue(reflect.New(mainType)); err != nil {
+			return nil, err
+		}
+	}
+	if len(statement.JoinTables()) == 0 {
+		return nil, errors.New("FindJoined needs the tables joined by beans")
+	}
+
+	var mainName = statement.TableAlias
+	if mainName == "" {
+		mainName = statement.TableName()
+	}
+	var fields = []joinField{{table: statement.RefTable, name: mainName, index: -1}}
+	if mainType != elemType {
+		index, kind, ok := findJoinField(elemType, mainType, mainName, used)
+		if !ok || kind == joinFieldSlice || kind == joinFieldSlicePtr {
+			return nil, fmt.Errorf("%v has no field of the main table %s", elemType, statement.RefTable.Name)
+		}
+		fields[0].index, fields[0].kind = index, kind
+		used[index] = true
+	}
+
+	for _, joinTable := range statement.JoinTables() {
+		index, kind, ok := findJoinField(elemType, joinTable.Table.Type, joinTable.Name, used)
+		if !ok {
+			continue
+		}
+		used[index] = true
+		fields = append(fields, joinField{table: joinTable.Table, name: joinTable.Name, index: index, kind: kind})
+	}
+	return fields, nil
+}
+
+func isJoinedType(joinTables []statements.JoinTable, tp reflect.Type) bool {
+	for _, joinTable := range joinTables {
+		if joinTable.Table.Type == tp {
+			return true
+		}
+	}
+	return false
+}
+
+// findJoinField returns the field of elemType whose type is tp, *tp, []tp or []*tp, the one
+// named by the alias is preferred
+func findJoinField(elemType, tp reflect.Type, alias string, used map[int]bool) (int, joinFieldKind, bool) {
+	var (
+		found = -1
+		kind  joinFieldKind
+	)
+	if idx := strings.LastIndexAny(alias, ".`\"]"); idx >= 0 {
+		alias = alias[idx+1:]
+	}
+	for i := 0; i < elemType.NumField(); i++ {
+		if used[i] {
+			continue
+		}
+		field := elemType.Field(i)
+		var k joinFieldKind
+		switch {
+		case field.Type == tp:
+			k = joinFieldStruct
+		case field.Type.Kind() == reflect.Ptr && field.Type.Elem() == tp:
+			k = joinFieldPtr
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem() == tp:
+			k = joinFieldSlice
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Ptr && field.Type.Elem().Elem() == tp:
+			k = joinFieldSlicePtr
+		default:
+			continue
+		}
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		if found < 0 {
+			found, kind = i, k
+		}
+		if strings.EqualFold(field.Name, strings.Replace(alias, "_", "", -1)) {
+			return i, k, true
+		}
+	}
+	return found, kind, found >= 0
+}
+
+// scanJoinField scans the columns of a table into its field of elem
+func (session *Session) scanJoinField(elem reflect.Value, field joinField, scanResults []interface{}) error {
+	var target reflect.Value
+	switch field.kind {
+	case joinFieldStruct:
+		if field.index < 0 {
+			target = elem
+		} else {
+			target = elem.Field(field.index)
+		}
+	case joinFieldPtr:
+		fieldValue := elem.Field(field.index)
+		fieldValue.Set(reflect.New(field.table.Type))
+		target = fieldValue.Elem()
+	case joinFieldSlice, joinFieldSlicePtr:
+		target = reflect.New(field.table.Type).Elem()
+	}
+
+	var names = make([]string, 0, field.end-field.start)
+	for _, col := range field.table.Columns() {
+		if col.MapType != schemas.ONLYTODB {
+			names = append(names, col.Name)
+		}
+	}
+	bean := target.Addr().Interface()
+	executeBeforeSet(bean, names, scanResults)
+	if _, err := session.slice2Bean(scanResults, names, bean, &target, field.table); err != nil {
+		return err
+	}
+
+	switch field.kind {
+	case joinFieldSlice:
+		fieldValue := elem.Field(field.index)
+		fieldValue.Set(reflect.Append(fieldValue, target))
+	case joinFieldSlicePtr:
+		fieldValue := elem.Field(field.index)
+		fieldValue.Set(reflect.Append(fieldValue, target.Addr()))
+	}
+	return nil
+}
+
+// joinRowKey returns the key of the row of a table, which is its primary key or all the
+// columns if it has no primary key
+func joinRowKey(table *schemas.Table, scanResults []interface{}, columns []*schemas.Column) string {
+	var (
+		values []interface{}
+		i      int
+	)
+	for _, col := range columns {
+		if col.MapType == schemas.ONLYTODB {
+			continue
+		}
+		if len(table.PrimaryKeys) == 0 || col.IsPrimaryKey {
+			values = append(values, reflect.Indirect(reflect.ValueOf(scanResults[i])).Interface())
+		}
+		i++
+	}
+	return fmt.Sprintf("%v", values)
+}
+
+// joinRowIsNull returns true if all the columns of a table are NULL, i.e. a LEFT JOIN
+// matches no row
+func joinRowIsNull(scanResults []interface{}) bool {
+	for _, cell := range scanResults {
+		if reflect.Indirect(reflect.ValueOf(cell)).Interface() != nil {
+			return false
+		}
+	}
+	return true
+}
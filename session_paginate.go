@@ -0,0 +1,275 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/xormplus/xorm/internal/statements"
+	"github.com/xormplus/xorm/schemas"
+)
+
+// ErrInvalidCursor represents an error when a cursor of Paginate could not be decoded
+var ErrInvalidCursor = statements.ErrInvalidCursor
+
+// Page holds the cursors of the pages around the one read by a Paginator
+type Page struct {
+	// Next is the cursor of the next page, it's empty if there is no more row
+	Next string
+	// Prev is the cursor of the previous page, it's empty on the first page
+	Prev string
+}
+
+// Paginator reads a page of keyset pagination
+type Paginator struct {
+	session  *Session
+	cursor   string
+	pageSize int
+	orders   []statements.KeysetOrder
+	err      error
+}
+
+// Paginate returns a Paginator reading the page of pageSize rows after the cursor, or the
+// first page if cursor is empty, i.e.
+//
+//	var users []User
+//	page, err := engine.Where("age > ?", 18).Paginate(cursor, 20, "created DESC", "id DESC").Find(&users)
+//
+// The rows are read by the condition on the ordering columns of the last row instead of
+// an offset, so the ordering columns should identify a row, i.e. end with the primary key,
+// and should not be NULL. The columns of the primary key are used if there is no ordering
+// column given for Find. The cursors of the next and the previous pages are returned by
+// Page, which are opaque tokens and should be passed as is. OrderBy and Limit are ignored.
+func (session *Session) Paginate(cursor string, pageSize int, orderCols ...string) *Paginator {
+	orders, err := statements.ParseKeysetOrders(orderCols)
+	if err == nil && pageSize <= 0 {
+		err = errors.New("page size should be greater than 0")
+	}
+	return &Paginator{
+		session:  session,
+		cursor:   cursor,
+		pageSize: pageSize,
+		orders:   orders,
+		err:      err,
+	}
+}
+
+// Find reads the page into rowsSlicePtr which is a pointer to a slice of structs or of
+// pointers to them, the rows are appended to it
+func (paginator *Paginator) Find(rowsSlicePtr interface{}, condiBean ...interface{}) (*Page, error) {
+	session := paginator.session
+	if session.isAutoClose {
+		defer session.Close()
+	}
+	defer session.resetStatement()
+	if paginator.err != nil {
+		return nil, paginator.err
+	}
+
+	sliceValue := reflect.Indirect(reflect.ValueOf(rowsSlicePtr))
+	if sliceValue.Kind() != reflect.Slice {
+		return nil, errors.New("needs a pointer to a slice")
+	}
+	elemType := sliceValue.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return nil, errors.New("needs a pointer to a slice of struct")
+	}
+	table, err := session.engine.tagParser.ParseWithCache(reflect.New(elemType).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	var orders = paginator.orders
+	if len(orders) == 0 {
+		for _, pk := range table.PrimaryKeys {
+			orders = append(orders, statements.KeysetOrder{Column: pk})
+		}
+	}
+	cursor, err := paginator.prepare(orders)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		preloads = session.statement.Preloads
+		rows     = reflect.New(sliceValue.Type())
+	)
+	if err := session.find(rows.Interface(), condiBean...); err != nil {
+		return nil, err
+	}
+
+	var values = make([]reflect.Value, 0, rows.Elem().Len())
+	for i := 0; i < rows.Elem().Len(); i++ {
+		values = append(values, rows.Elem().Index(i))
+	}
+	values, page, err := paginator.page(cursor, values, func(row reflect.Value) ([]interface{}, error) {
+		return session.keysetValues(table, orders, reflect.Indirect(row))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var pageRows = reflect.New(sliceValue.Type())
+	pageRows.Elem().Set(reflect.Append(pageRows.Elem(), values...))
+	if len(preloads) > 0 {
+		if err := session.preload(pageRows.Interface(), preloads); err != nil {
+			return nil, err
+		}
+	}
+	sliceValue.Set(reflect.AppendSlice(sliceValue, pageRows.Elem()))
+	return page, nil
+}
+
+// Query reads the page of the query given by SQL, or of the table given by Table, into
+// maps, the ordering columns should be given and be selected
+func (paginator *Paginator) Query() ([]map[string]interface{}, *Page, error) {
+	session := paginator.session
+	if session.isAutoClose {
+		defer session.Close()
+	}
+	defer session.resetStatement()
+	if paginator.err != nil {
+		return nil, nil, paginator.err
+	}
+	if len(paginator.orders) == 0 {
+		return nil, nil, errors.New("Paginate needs ordering columns for Query")
+	}
+
+	// the raw query is read as a sub query to add the condition, the order and the limit
+	if session.statement.RawSQL != "" {
+		rawSQL, rawParams := session.statement.RawSQL, session.statement.RawParams
+		session.statement.RawSQL, session.statement.RawParams = "", nil
+		session.statement.From(rawSQL, "keyset_page", rawParams...)
+		if session.statement.LastError != nil {
+			return nil, nil, session.statement.LastError
+		}
+	}
+	if session.statement.SelectStr == "" && session.statement.RefTable == nil {
+		session.statement.SelectStr = "*"
+	}
+	cursor, err := paginator.prepare(paginator.orders)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sqlStr, args, err := session.statement.GenQuerySQL()
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := session.queryAll(sqlStr, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var values = make([]reflect.Value, 0, len(result))
+	for _, row := range result {
+		values = append(values, reflect.ValueOf(row))
+	}
+	values, page, err := paginator.page(cursor, values, func(row reflect.Value) ([]interface{}, error) {
+		var (
+			m         = row.Interface().(map[string]interface{})
+			keyValues = make([]interface{}, 0, len(paginator.orders))
+		)
+		for _, order := range paginator.orders {
+			v, ok := m[order.Name()]
+			if !ok {
+				return nil, fmt.Errorf("ordering column %s is not selected", order.Column)
+			}
+			keyValues = append(keyValues, v)
+		}
+		return keyValues, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows = make([]map[string]interface{}, 0, len(values))
+	for _, v := range values {
+		rows = append(rows, v.Interface().(map[string]interface{}))
+	}
+	return rows, page, nil
+}
+
+// prepare decodes the cursor and adds the keyset condition, order and limit of the page,
+// one more row is read to know if there is a next page
+func (paginator *Paginator) prepare(orders []statements.KeysetOrder) (*statements.Cursor, error) {
+	cursor, err := statements.DecodeCursor(paginator.cursor)
+	if err != nil {
+		return nil, err
+	}
+	if err := paginator.session.statement.Keyset(orders, cursor, paginator.pageSize+1); err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// page trims the rows read to the page and restores the order of a backward page, then
+// returns the cursors of the pages around it
+func (paginator *Paginator) page(cursor *statements.Cursor, rows []reflect.Value, keyValues func(reflect.Value) ([]interface{}, error)) ([]reflect.Value, *Page, error) {
+	var (
+		backward = cursor != nil && cursor.Backward
+		hasMore  = len(rows) > paginator.pageSize
+		page     = new(Page)
+	)
+	if hasMore {
+		rows = rows[:paginator.pageSize]
+	}
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if len(rows) == 0 {
+		return rows, page, nil
+	}
+
+	// there are rows after the page if more rows are read forward or it's read backward,
+	// and there are rows before it if more rows are read backward or it's read by a cursor
+	if hasMore || backward {
+		values, err := keyValues(rows[len(rows)-1])
+		if err != nil {
+			return nil, nil, err
+		}
+		if page.Next, err = statements.EncodeCursor(statements.Cursor{Values: values}); err != nil {
+			return nil, nil, err
+		}
+	}
+	if (backward && hasMore) || (!backward && cursor != nil) {
+		values, err := keyValues(rows[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		if page.Prev, err = statements.EncodeCursor(statements.Cursor{Values: values, Backward: true}); err != nil {
+			return nil, nil, err
+		}
+	}
+	return rows, page, nil
+}
+
+// keysetValues returns the values of the ordering columns of a struct as they are stored
+func (session *Session) keysetValues(table *schemas.Table, orders []statements.KeysetOrder, elem reflect.Value) ([]interface{}, error) {
+	var values = make([]interface{}, 0, len(orders))
+	for _, order := range orders {
+		col := table.GetColumn(order.Name())
+		if col == nil {
+			return nil, fmt.Errorf("ordering column %s is not a column of %s", order.Column, table.Name)
+		}
+		fieldValue, err := col.ValueOfV(&elem)
+		if err != nil {
+			return nil, err
+		}
+		v, err := session.statement.Value2Interface(col, *fieldValue)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
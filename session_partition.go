@@ -0,0 +1,170 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/xormplus/xorm/internal/statements"
+	"github.com/xormplus/xorm/internal/utils"
+	"github.com/xormplus/xorm/schemas"
+)
+
+// ErrPartitionsRange represents the range of Partitions misses a bound or from is not before to
+var ErrPartitionsRange = statements.ErrPartitionsRange
+
+// Partitions reads the existing partitions of the partitioned table holding the records
+// in [from, to) as one table, i.e. Find, Count, Sum and Get. The table is partitioned by
+// the column tagged by partition(daily), partition(monthly) or partition(yearly), and
+// its partitions are named by the table name and the period, i.e. event_202610.
+// The writes of a bean go to the partition of the bean's partition column, the writes
+// without a bean, or with a zero partition column, need Table to give the partition.
+// Both bounds are needed and from should be before to, otherwise the reads return
+// ErrPartitionsRange.
+func (session *Session) Partitions(from, to time.Time) *Session {
+	session.statement.Partitions(from, to, session.existingTables)
+	return session
+}
+
+// existingTables returns the tables of names which exist in the database
+func (session *Session) existingTables(names []string) ([]string, error) {
+	tables, err := session.engine.dialect.GetTables(session.getQueryer(), session.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing = make([]string, 0, len(names))
+	for _, name := range names {
+		for _, table := range tables {
+			if strings.EqualFold(table.Name, name) {
+				existing = append(existing, name)
+				break
+			}
+		}
+	}
+	return existing, nil
+}
+
+// SyncPartitions synchronizes the partitions of the partitioned tables of the beans
+// holding the records in [from, to) like Sync2, the tables which are not partitioned
+// are synchronized as Sync2 does. Sync2 synchronizes the partition of now and the ones
+// ahead of it given by partition(period,ahead), 1 by default.
+func (session *Session) SyncPartitions(from, to time.Time, beans ...interface{}) error {
+	if session.isAutoClose {
+		session.isAutoClose = false
+		defer session.Close()
+	}
+
+	for _, bean := range beans {
+		table, err := session.engine.tagParser.ParseWithCache(utils.ReflectValue(bean))
+		if err != nil {
+			return err
+		}
+		if table.PartitionColumn() == nil {
+			if err := session.Sync2(bean); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := session.syncPartitions(bean, table, from, to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncAheadPartitions synchronizes the partition of now and the ones ahead of it
+func (session *Session) syncAheadPartitions(bean interface{}, table *schemas.Table) error {
+	col := table.PartitionColumn()
+	from := time.Now().In(session.engine.DatabaseTZ)
+	if col.TimeZone != nil {
+		from = from.In(col.TimeZone)
+	}
+	to := from
+	for i := 0; i <= col.PartitionAhead; i++ {
+		to = col.PartitionPeriod.Next(to)
+	}
+	return session.syncPartitions(bean, table, from, to)
+}
+
+func (session *Session) syncPartitions(bean interface{}, table *schemas.Table, from, to time.Time) error {
+	autoResetStatement := session.autoResetStatement
+	defer func() {
+		session.autoResetStatement = autoResetStatement
+	}()
+
+	for _, name := range session.statement.PartitionTableNames(table, from, to) {
+		session.statement.AltTableName = name
+		if err := session.Sync2(bean); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertMultiPartitions inserts the beans of a partitioned table into their partitions,
+// one insert for every partition
+func (session *Session) insertMultiPartitions(rowsSlicePtr interface{}) (int64, error) {
+	sliceValue := reflect.Indirect(reflect.ValueOf(rowsSlicePtr))
+	if err := session.statement.SetRefBean(sliceValue.Index(0).Interface()); err != nil {
+		return 0, err
+	}
+	col := session.statement.RefTable.PartitionColumn()
+	if col == nil || session.statement.AltTableName != "" ||
+		((col.IsCreated || col.IsUpdated) && session.statement.UseAutoTime) {
+		return session.innerInsertMulti(rowsSlicePtr)
+	}
+
+	var (
+		names   []string
+		groups  = make(map[string]reflect.Value)
+		indexes = make(map[string][]int)
+	)
+	for i := 0; i < sliceValue.Len(); i++ {
+		elem := sliceValue.Index(i)
+		if err := session.statement.SetRefBean(elem.Interface()); err != nil {
+			return 0, err
+		}
+		name := session.statement.TableName()
+		group, ok := groups[name]
+		if !ok {
+			names = append(names, name)
+			group = reflect.MakeSlice(sliceValue.Type(), 0, sliceValue.Len())
+		}
+		groups[name] = reflect.Append(group, elem)
+		indexes[name] = append(indexes[name], i)
+	}
+	if len(names) == 1 {
+		return session.innerInsertMulti(rowsSlicePtr)
+	}
+
+	autoResetStatement := session.autoResetStatement
+	session.autoResetStatement = false
+	defer func() {
+		session.autoResetStatement = autoResetStatement
+		if autoResetStatement {
+			session.resetStatement()
+		}
+	}()
+
+	var affected int64
+	for _, name := range names {
+		group := groups[name]
+		cnt, err := session.innerInsertMulti(group.Interface())
+		affected += cnt
+		if err != nil {
+			return affected, err
+		}
+		// the struct elements are copied into the group, copy them back with the ids
+		if group.Type().Elem().Kind() == reflect.Struct {
+			for j, i := range indexes[name] {
+				sliceValue.Index(i).Set(group.Index(j))
+			}
+		}
+	}
+	return affected, nil
+}
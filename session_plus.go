@@ -530,6 +530,11 @@ func (session *Session) Query() *ResultMap {
 	params := session.statement.RawParams
 	i := len(params)
 
+	qc := session.newQueryCache("map", strings.Replace(sql, rownumber, "", -1), params)
+	if v, ok := qc.get(); ok {
+		return &ResultMap{Result: copyInterfaceMaps(v.([]map[string]interface{}))}
+	}
+
 	var result []map[string]interface{}
 	var err error
 	if i == 1 {
@@ -556,6 +561,9 @@ func (session *Session) Query() *ResultMap {
 			}
 		}
 	}
+	if err == nil {
+		qc.put(copyInterfaceMaps(result))
+	}
 	r := &ResultMap{Result: result, Error: err}
 	return r
 }
@@ -574,6 +582,11 @@ func (session *Session) QueryWithDateFormat(dateFormat string) *ResultMap {
 	params := session.statement.RawParams
 	i := len(params)
 
+	qc := session.newQueryCache("map:"+dateFormat, strings.Replace(sql, rownumber, "", -1), params)
+	if v, ok := qc.get(); ok {
+		return &ResultMap{Result: copyInterfaceMaps(v.([]map[string]interface{}))}
+	}
+
 	var result []map[string]interface{}
 	var err error
 	if i == 1 {
@@ -601,6 +614,9 @@ func (session *Session) QueryWithDateFormat(dateFormat string) *ResultMap {
 			}
 		}
 	}
+	if err == nil {
+		qc.put(copyInterfaceMaps(result))
+	}
 	r := &ResultMap{Result: result, Error: err}
 	return r
 }
@@ -0,0 +1,415 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/xormplus/builder"
+	"github.com/xormplus/xorm/schemas"
+)
+
+// preloadBatchSize is the max number of the keys of an IN query loading the related rows
+const preloadBatchSize = 500
+
+// Preload loads the relations of the beans found by Find, FindAndCount or Get, i.e.
+//
+//	type User struct {
+//		Id     int64
+//		Orders []Order `xorm:"has_many"`
+//	}
+//	type Order struct {
+//		Id     int64
+//		UserId int64
+//		Items  []*Item `xorm:"has_many"`
+//	}
+//	var users []User
+//	err := engine.Preload("Orders.Items").Find(&users)
+//
+// A path is the field names of the relations joined by dots. The relations are tagged by
+// belongs_to, has_many or many2many, and every relation is loaded by batched IN queries
+// of its keys, instead of one query for every bean.
+func (session *Session) Preload(paths ...string) *Session {
+	session.statement.Preloads = append(session.statement.Preloads, paths...)
+	return session
+}
+
+// preload loads the relations of the paths into the beans, beans is a pointer to a struct,
+// or a pointer to a slice or a map of them
+func (session *Session) preload(beans interface{}, paths []string) error {
+	var (
+		v        = reflect.Indirect(reflect.ValueOf(beans))
+		elems    []reflect.Value
+		setBacks []func()
+	)
+	switch v.Kind() {
+	case reflect.Struct:
+		elems = append(elems, v)
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			elems = append(elems, reflect.Indirect(v.Index(i)))
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			elem := v.MapIndex(key)
+			if elem.Kind() == reflect.Ptr {
+				elems = append(elems, elem.Elem())
+				continue
+			}
+			// the struct values of a map are not addressable, preload the copies and set them back
+			var (
+				mapKey = key
+				copied = reflect.New(elem.Type()).Elem()
+			)
+			copied.Set(elem)
+			elems = append(elems, copied)
+			setBacks = append(setBacks, func() {
+				v.SetMapIndex(mapKey, copied)
+			})
+		}
+	}
+
+	var elemType reflect.Type
+	switch v.Kind() {
+	case reflect.Struct:
+		elemType = v.Type()
+	case reflect.Slice, reflect.Map:
+		elemType = v.Type().Elem()
+		if elemType.Kind() == reflect.Ptr {
+			elemType = elemType.Elem()
+		}
+	}
+	if elemType == nil || elemType.Kind() != reflect.Struct {
+		return errors.New("Preload needs a struct, or a slice or a map of structs")
+	}
+	if len(elems) == 0 {
+		return nil
+	}
+
+	table, err := session.engine.tagParser.ParseWithCache(reflect.New(elemType).Elem())
+	if err != nil {
+		return err
+	}
+	if err := session.preloadPaths(elems, table, paths); err != nil {
+		return err
+	}
+	for _, setBack := range setBacks {
+		setBack()
+	}
+	return nil
+}
+
+// preloadPaths loads the relations of the paths into elems which are the struct values of
+// table, the relations sharing a field are loaded once
+func (session *Session) preloadPaths(elems []reflect.Value, table *schemas.Table, paths []string) error {
+	var (
+		names []string
+		rests = make(map[string][]string)
+	)
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		var name, rest = path, ""
+		if idx := strings.Index(path, "."); idx >= 0 {
+			name, rest = path[:idx], path[idx+1:]
+		}
+		name = strings.ToLower(name)
+		if _, ok := rests[name]; !ok {
+			names = append(names, name)
+			rests[name] = nil
+		}
+		if rest != "" {
+			rests[name] = append(rests[name], rest)
+		}
+	}
+
+	for _, name := range names {
+		relation := table.GetRelation(name)
+		if relation == nil {
+			return fmt.Errorf("%v has no relation %s to preload", table.Type, name)
+		}
+		if err := session.preloadRelation(elems, table, relation, rests[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preloadRelation loads the related rows of the relation into elems, then the relations of
+// the paths of the related rows
+func (session *Session) preloadRelation(elems []reflect.Value, table *schemas.Table, relation *schemas.Relation, paths []string) error {
+	relTable, err := session.engine.tagParser.ParseWithCache(reflect.New(relation.ElemType).Elem())
+	if err != nil {
+		return err
+	}
+
+	switch relation.Type {
+	case schemas.BelongsTo:
+		col := table.GetColumn(relation.ForeignKey)
+		if col == nil {
+			return fmt.Errorf("%v has no column %s of relation %s", table.Type, relation.ForeignKey, relation.FieldName)
+		}
+		pk, err := preloadPK(relTable)
+		if err != nil {
+			return err
+		}
+		keys, elemKeys, err := preloadKeys(elems, col)
+		if err != nil {
+			return err
+		}
+		rows, err := session.preloadRows(relTable, relation.ElemType, pk, keys, paths)
+		if err != nil {
+			return err
+		}
+		rowsByKey, err := groupPreloadRows(rows, pk)
+		if err != nil {
+			return err
+		}
+		for i, elem := range elems {
+			related := rowsByKey[elemKeys[i]]
+			if len(related) == 0 {
+				continue
+			}
+			setPreloadField(elem.FieldByName(relation.FieldName), related[:1])
+		}
+	case schemas.HasMany:
+		pk, err := preloadPK(table)
+		if err != nil {
+			return err
+		}
+		col := relTable.GetColumn(relation.ForeignKey)
+		if col == nil {
+			return fmt.Errorf("%v has no column %s of relation %s", relTable.Type, relation.ForeignKey, relation.FieldName)
+		}
+		keys, elemKeys, err := preloadKeys(elems, pk)
+		if err != nil {
+			return err
+		}
+		rows, err := session.preloadRows(relTable, relation.ElemType, col, keys, paths)
+		if err != nil {
+			return err
+		}
+		rowsByKey, err := groupPreloadRows(rows, col)
+		if err != nil {
+			return err
+		}
+		for i, elem := range elems {
+			setPreloadField(elem.FieldByName(relation.FieldName), rowsByKey[elemKeys[i]])
+		}
+	case schemas.ManyToMany:
+		pk, err := preloadPK(table)
+		if err != nil {
+			return err
+		}
+		relPK, err := preloadPK(relTable)
+		if err != nil {
+			return err
+		}
+		keys, elemKeys, err := preloadKeys(elems, pk)
+		if err != nil {
+			return err
+		}
+		refKeys, refsByKey, err := session.preloadJoinTable(relation, keys)
+		if err != nil {
+			return err
+		}
+		rows, err := session.preloadRows(relTable, relation.ElemType, relPK, refKeys, paths)
+		if err != nil {
+			return err
+		}
+		rowsByKey, err := groupPreloadRows(rows, relPK)
+		if err != nil {
+			return err
+		}
+		for i, elem := range elems {
+			var related []reflect.Value
+			for _, refKey := range refsByKey[elemKeys[i]] {
+				related = append(related, rowsByKey[refKey]...)
+			}
+			setPreloadField(elem.FieldByName(relation.FieldName), related)
+		}
+	default:
+		return fmt.Errorf("unknown relation type %v of %s", relation.Type, relation.FieldName)
+	}
+	return nil
+}
+
+// preloadRows finds the rows of table whose column is in keys by batched IN queries and
+// loads the relations of the paths into them, the rows are pointers to the structs
+func (session *Session) preloadRows(table *schemas.Table, elemType reflect.Type, col *schemas.Column, keys []interface{}, paths []string) ([]reflect.Value, error) {
+	var rows []reflect.Value
+	for start := 0; start < len(keys); start += preloadBatchSize {
+		end := start + preloadBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		slicePtr := reflect.New(reflect.SliceOf(reflect.PtrTo(elemType)))
+		if len(table.PrimaryKeys) == 1 {
+			session.Asc(table.PrimaryKeys[0])
+		}
+		if err := session.In(col.Name, keys[start:end]...).find(slicePtr.Interface()); err != nil {
+			return nil, err
+		}
+		sliceValue := slicePtr.Elem()
+		for i := 0; i < sliceValue.Len(); i++ {
+			rows = append(rows, sliceValue.Index(i))
+		}
+	}
+
+	if len(paths) > 0 && len(rows) > 0 {
+		var elems = make([]reflect.Value, 0, len(rows))
+		for _, row := range rows {
+			elems = append(elems, row.Elem())
+		}
+		if err := session.preloadPaths(elems, table, paths); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// preloadJoinTable reads the join table of a many2many relation, it returns the distinct
+// keys of the related rows and the keys of them by the keys of the beans
+func (session *Session) preloadJoinTable(relation *schemas.Relation, keys []interface{}) ([]interface{}, map[string][]string, error) {
+	var (
+		quoter    = session.engine.dialect.Quoter()
+		refKeys   []interface{}
+		refsByKey = make(map[string][]string)
+		seen      = make(map[string]bool)
+	)
+	for start := 0; start < len(keys); start += preloadBatchSize {
+		end := start + preloadBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		sqlStr, args, err := builder.Select(quoter.Quote(relation.JoinForeignKey), quoter.Quote(relation.JoinReferences)).
+			From(quoter.Quote(session.engine.TableName(relation.JoinTable, true))).
+			Where(builder.In(quoter.Quote(relation.JoinForeignKey), keys[start:end]...)).
+			ToSQL()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := func() error {
+			rows, err := session.queryRows(sqlStr, args...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var key, refKey interface{}
+				if err := rows.Scan(&key, &refKey); err != nil {
+					return err
+				}
+				if key == nil || refKey == nil {
+					continue
+				}
+				keyStr, refKeyStr := preloadKey(key), preloadKey(refKey)
+				refsByKey[keyStr] = append(refsByKey[keyStr], refKeyStr)
+				if !seen[refKeyStr] {
+					seen[refKeyStr] = true
+					if bs, ok := refKey.([]byte); ok {
+						refKey = string(bs)
+					}
+					refKeys = append(refKeys, refKey)
+				}
+			}
+			return rows.Err()
+		}(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return refKeys, refsByKey, nil
+}
+
+// preloadPK returns the only primary key column of table
+func preloadPK(table *schemas.Table) (*schemas.Column, error) {
+	cols := table.PKColumns()
+	if len(cols) != 1 {
+		return nil, fmt.Errorf("%v needs one primary key to preload its relations", table.Type)
+	}
+	return cols[0], nil
+}
+
+// preloadKeys returns the distinct values of col of elems and the key of every elem, the
+// key of an elem whose value is NULL is empty
+func preloadKeys(elems []reflect.Value, col *schemas.Column) ([]interface{}, []string, error) {
+	var (
+		keys     []interface{}
+		elemKeys = make([]string, len(elems))
+		seen     = make(map[string]bool)
+	)
+	for i := range elems {
+		fieldValue, err := col.ValueOfV(&elems[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		value := reflect.Indirect(*fieldValue)
+		if !value.IsValid() {
+			continue
+		}
+		key := preloadKey(value.Interface())
+		elemKeys[i] = key
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, value.Interface())
+		}
+	}
+	return keys, elemKeys, nil
+}
+
+// groupPreloadRows groups the rows by their values of col
+func groupPreloadRows(rows []reflect.Value, col *schemas.Column) (map[string][]reflect.Value, error) {
+	var rowsByKey = make(map[string][]reflect.Value)
+	for _, row := range rows {
+		elem := row.Elem()
+		fieldValue, err := col.ValueOfV(&elem)
+		if err != nil {
+			return nil, err
+		}
+		value := reflect.Indirect(*fieldValue)
+		if !value.IsValid() {
+			continue
+		}
+		key := preloadKey(value.Interface())
+		rowsByKey[key] = append(rowsByKey[key], row)
+	}
+	return rowsByKey, nil
+}
+
+// preloadKey returns the key to match the values of the columns read from the structs
+// and the database
+func preloadKey(v interface{}) string {
+	if bs, ok := v.([]byte); ok {
+		return string(bs)
+	}
+	return fmt.Sprint(v)
+}
+
+// setPreloadField sets the related rows, which are pointers to the structs, to the field
+// of a struct, a pointer to it or a slice of them
+func setPreloadField(field reflect.Value, rows []reflect.Value) {
+	switch field.Kind() {
+	case reflect.Ptr:
+		field.Set(rows[0])
+	case reflect.Struct:
+		field.Set(rows[0].Elem())
+	case reflect.Slice:
+		slice := reflect.MakeSlice(field.Type(), 0, len(rows))
+		for _, row := range rows {
+			if field.Type().Elem().Kind() == reflect.Ptr {
+				slice = reflect.Append(slice, row)
+			} else {
+				slice = reflect.Append(slice, row.Elem())
+			}
+		}
+		field.Set(slice)
+	}
+}
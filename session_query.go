@@ -225,13 +225,24 @@ func (session *Session) QueryString(sqlOrArgs ...interface{}) ([]map[string]stri
 		return nil, err
 	}
 
+	qc := session.newQueryCache("strings", sqlStr, args)
+	if v, ok := qc.get(); ok {
+		session.resetStatement()
+		return copyStringMaps(v.([]map[string]string)), nil
+	}
+
 	rows, err := session.queryRows(sqlStr, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	return rows2Strings(rows)
+	result, err := rows2Strings(rows)
+	if err != nil {
+		return nil, err
+	}
+	qc.put(copyStringMaps(result))
+	return result, nil
 }
 
 // QuerySliceString runs a raw sql and return records as [][]string
@@ -298,13 +309,24 @@ func (session *Session) QueryInterface(sqlOrArgs ...interface{}) ([]map[string]i
 		return nil, err
 	}
 
+	qc := session.newQueryCache("interfaces", sqlStr, args)
+	if v, ok := qc.get(); ok {
+		session.resetStatement()
+		return copyInterfaceMaps(v.([]map[string]interface{})), nil
+	}
+
 	rows, err := session.queryRows(sqlStr, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	return rows2Interfaces(rows)
+	result, err := rows2Interfaces(rows)
+	if err != nil {
+		return nil, err
+	}
+	qc.put(copyInterfaceMaps(result))
+	return result, nil
 }
 
 // QueryExpr returns the query as bound SQL
@@ -0,0 +1,110 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xormplus/xorm/caches"
+	"github.com/xormplus/xorm/internal/utils"
+)
+
+// CacheFor caches the results of the next raw query, i.e. QueryString, QueryInterface, Query
+// and QueryWithDateFormat which are also used by SqlMapClient and SqlTemplateClient, keyed
+// by the SQL and its args. The results expire after ttl, a ttl not greater than 0 means they
+// never expire. They are removed once xorm writes to any of the tables the query reads.
+// Queries in a transaction are never cached.
+func (session *Session) CacheFor(ttl time.Duration, tables ...string) *Session {
+	var bareTables = make([]string, 0, len(tables))
+	for _, table := range tables {
+		bareTables = append(bareTables, utils.BareTableName(table))
+	}
+	session.statement.UseQueryCache = true
+	session.statement.QueryCacheTTL = ttl
+	session.statement.QueryCacheTables = bareTables
+	return session
+}
+
+// queryCache is the cache entry of a raw query
+type queryCache struct {
+	cacher caches.QueryCacher
+	key    string
+	tables []string
+	ttl    time.Duration
+}
+
+// newQueryCache returns the cache entry of the query if CacheFor is called, the ttl and the
+// tables are copied since the statement will be reset by the query
+func (session *Session) newQueryCache(kind, sqlStr string, args []interface{}) *queryCache {
+	if !session.statement.UseQueryCache || !session.isAutoCommit {
+		return nil
+	}
+	cacher := session.engine.cacherMgr.GetQueryCacher()
+	if cacher == nil {
+		return nil
+	}
+	return &queryCache{
+		cacher: cacher,
+		key:    fmt.Sprintf("%s\x00%s\x00%#v", kind, sqlStr, args),
+		tables: session.statement.QueryCacheTables,
+		ttl:    session.statement.QueryCacheTTL,
+	}
+}
+
+func (qc *queryCache) get() (interface{}, bool) {
+	if qc == nil {
+		return nil, false
+	}
+	return qc.cacher.Get(qc.key)
+}
+
+func (qc *queryCache) put(value interface{}) {
+	if qc != nil {
+		qc.cacher.Put(qc.key, qc.tables, value, qc.ttl)
+	}
+}
+
+// clearQueryCache removes the cached query results of the tables written by sqlStr. In a
+// transaction they are removed again once it's committed, so that the results cached by the
+// other sessions before that are not kept.
+func (session *Session) clearQueryCache(sqlStr string) {
+	tables := utils.WrittenTables(sqlStr)
+	if len(tables) == 0 {
+		return
+	}
+	session.engine.clearQueryCache(tables...)
+	if !session.isAutoCommit {
+		session.txWrittenTables = append(session.txWrittenTables, tables...)
+	}
+}
+
+func (engine *Engine) clearQueryCache(tables ...string) {
+	if cacher := engine.cacherMgr.GetQueryCacher(); cacher != nil {
+		cacher.ClearTables(tables...)
+	}
+}
+
+func copyStringMaps(src []map[string]string) []map[string]string {
+	var dst = make([]map[string]string, len(src))
+	for i, m := range src {
+		dst[i] = make(map[string]string, len(m))
+		for k, v := range m {
+			dst[i][k] = v
+		}
+	}
+	return dst
+}
+
+func copyInterfaceMaps(src []map[string]interface{}) []map[string]interface{} {
+	var dst = make([]map[string]interface{}, len(src))
+	for i, m := range src {
+		dst[i] = make(map[string]interface{}, len(m))
+		for k, v := range m {
+			dst[i][k] = v
+		}
+	}
+	return dst
+}
@@ -9,6 +9,7 @@ import (
 	"reflect"
 
 	"github.com/xormplus/builder"
+	"github.com/xormplus/xorm/contexts"
 	"github.com/xormplus/xorm/core"
 	// "github.com/xormplus/xorm/internal/statements"
 )
@@ -23,6 +24,16 @@ func (session *Session) queryPreprocess(sqlStr *string, paramStr ...interface{})
 }
 
 func (session *Session) queryRows(sqlStr string, args ...interface{}) (*core.Rows, error) {
+	return session.doQueryRows(session.readFromSubordinate(), sqlStr, args...)
+}
+
+// queryMasterRows is like queryRows but never reads from the subordinates of an
+// engine group, it should be used when the query changes data, i.e. RETURNING
+func (session *Session) queryMasterRows(sqlStr string, args ...interface{}) (*core.Rows, error) {
+	return session.doQueryRows(false, sqlStr, args...)
+}
+
+func (session *Session) doQueryRows(useSubordinate bool, sqlStr string, args ...interface{}) (*core.Rows, error) {
 	defer session.resetStatement()
 	if session.statement.LastError != nil {
 		return nil, session.statement.LastError
@@ -40,9 +51,10 @@ func (session *Session) queryRows(sqlStr string, args ...interface{}) (*core.Row
 		}
 	}
 
+	ctx := session.operationContext(contexts.OperationQuery)
 	if session.isAutoCommit {
 		var db *core.DB
-		if session.sessionType == groupSession {
+		if useSubordinate {
 			db = session.engine.engineGroup.Subordinate().DB()
 		} else {
 			db = session.DB()
@@ -55,21 +67,21 @@ func (session *Session) queryRows(sqlStr string, args ...interface{}) (*core.Row
 				return nil, err
 			}
 
-			rows, err := stmt.QueryContext(session.ctx, args...)
+			rows, err := stmt.QueryContext(ctx, args...)
 			if err != nil {
 				return nil, err
 			}
 			return rows, nil
 		}
 
-		rows, err := db.QueryContext(session.ctx, sqlStr, args...)
+		rows, err := db.QueryContext(ctx, sqlStr, args...)
 		if err != nil {
 			return nil, err
 		}
 		return rows, nil
 	}
 
-	rows, err := session.tx.QueryContext(session.ctx, sqlStr, args...)
+	rows, err := session.tx.QueryContext(ctx, sqlStr, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -256,11 +268,18 @@ func (session *Session) queryResult(sqlStr string, args ...interface{}) (Result,
 	return rows2Result(rows)
 }
 
-func (session *Session) exec(sqlStr string, args ...interface{}) (sql.Result, error) {
+func (session *Session) exec(sqlStr string, args ...interface{}) (res sql.Result, err error) {
 	defer session.resetStatement()
 
 	session.queryPreprocess(&sqlStr, args...)
 
+	defer func() {
+		if err == nil {
+			session.clearQueryCache(sqlStr)
+			session.markWrite()
+		}
+	}()
+
 	session.lastSQL = sqlStr
 	session.lastSQLArgs = args
 
@@ -272,8 +291,9 @@ func (session *Session) exec(sqlStr string, args ...interface{}) (sql.Result, er
 		}
 	}
 
+	ctx := session.operationContext(contexts.OperationExec)
 	if !session.isAutoCommit {
-		return session.tx.ExecContext(session.ctx, sqlStr, args...)
+		return session.tx.ExecContext(ctx, sqlStr, args...)
 	}
 
 	if session.prepareStmt {
@@ -282,14 +302,14 @@ func (session *Session) exec(sqlStr string, args ...interface{}) (sql.Result, er
 			return nil, err
 		}
 
-		res, err := stmt.ExecContext(session.ctx, args...)
+		res, err := stmt.ExecContext(ctx, args...)
 		if err != nil {
 			return nil, err
 		}
 		return res, nil
 	}
 
-	return session.DB().ExecContext(session.ctx, sqlStr, args...)
+	return session.DB().ExecContext(ctx, sqlStr, args...)
 }
 
 func convertSQLOrArgs(sqlOrArgs ...interface{}) (string, []interface{}, error) {
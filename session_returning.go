@@ -0,0 +1,208 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/xormplus/builder"
+	"github.com/xormplus/xorm/core"
+	"github.com/xormplus/xorm/internal/utils"
+	"github.com/xormplus/xorm/schemas"
+)
+
+// Returning fills the beans with the given columns, or all the columns if none is given,
+// of the rows affected by the following Insert, InsertMulti, Update or Delete. So database
+// defaults, triggers and computed columns could be read back without another query.
+// It uses RETURNING on postgres and sqlite3(3.35.0 or later), OUTPUT on mssql and
+// RETURNING INTO on oracle, and selects the rows again by the primary keys on mysql and
+// older sqlite3.
+// Update and Delete fill the bean with the first affected row.
+func (session *Session) Returning(columns ...string) *Session {
+	session.statement.Returning(columns...)
+	return session
+}
+
+// returning keeps what is needed to fill the beans, since the statement will be reset
+// once the SQL has been executed
+type returning struct {
+	table     *schemas.Table
+	tableName string
+	cols      []string
+	supported bool
+}
+
+func (session *Session) newReturning() *returning {
+	if !session.statement.IsReturning || session.statement.RefTable == nil {
+		return nil
+	}
+	return &returning{
+		table:     session.statement.RefTable,
+		tableName: session.statement.TableName(),
+		cols:      session.statement.ReturningColumnNames(),
+		supported: session.statement.SupportReturning(),
+	}
+}
+
+func (r *returning) hasColumn(colName string) bool {
+	for _, col := range r.cols {
+		if strings.EqualFold(col, colName) {
+			return true
+		}
+	}
+	return false
+}
+
+// outArgs returns the out parameters of oracle RETURNING INTO which will be filled to bean
+func (r *returning) outArgs(bean interface{}) ([]interface{}, error) {
+	args := make([]interface{}, 0, len(r.cols))
+	for _, colName := range r.cols {
+		col := r.table.GetColumn(colName)
+		if col == nil {
+			return nil, ErrFieldIsNotExist{colName, r.table.Name}
+		}
+		fieldValue, err := col.ValueOf(bean)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, sql.Out{Dest: fieldValue.Addr().Interface()})
+	}
+	return args, nil
+}
+
+// scanReturning fills bean with the current row. The load processors are not
+// invoked since the bean is not loaded by a query.
+func (session *Session) scanReturning(rows *core.Rows, fields []string, table *schemas.Table, bean interface{}) error {
+	scanResults := make([]interface{}, len(fields))
+	for i := 0; i < len(fields); i++ {
+		var cell interface{}
+		scanResults[i] = &cell
+	}
+	if err := rows.Scan(scanResults...); err != nil {
+		return err
+	}
+
+	afterClosures, afterProcessors := session.afterClosures, session.afterProcessors
+	session.afterClosures = nil
+	defer func() {
+		session.afterClosures, session.afterProcessors = afterClosures, afterProcessors
+	}()
+
+	dataStruct := utils.ReflectValue(bean)
+	_, err := session.slice2Bean(scanResults, fields, bean, &dataStruct, table)
+	return err
+}
+
+// queryReturning executes a SQL with RETURNING or OUTPUT and fills the beans with the
+// returned rows in order, the number of returned rows is returned as affected rows
+func (session *Session) queryReturning(r *returning, beans []interface{}, sqlStr string, args ...interface{}) (int64, error) {
+	rows, err := session.queryMasterRows(sqlStr, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	fields, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	var affected int64
+	for rows.Next() {
+		if int(affected) < len(beans) {
+			if err := session.scanReturning(rows, fields, r.table, beans[affected]); err != nil {
+				return affected, err
+			}
+		}
+		affected++
+	}
+	if err := rows.Err(); err != nil {
+		return affected, err
+	}
+	session.clearQueryCache(sqlStr)
+	session.markWrite()
+	return affected, nil
+}
+
+// execReturning executes an insert, update or delete SQL with the returning clause
+// and fills bean with the first returned row
+func (session *Session) execReturning(r *returning, bean interface{}, sqlStr string, args ...interface{}) (int64, error) {
+	if session.engine.dialect.URI().DBType != schemas.ORACLE {
+		return session.queryReturning(r, []interface{}{bean}, sqlStr, args...)
+	}
+
+	outArgs, err := r.outArgs(bean)
+	if err != nil {
+		return 0, err
+	}
+	res, err := session.exec(sqlStr, append(args, outArgs...)...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// reselectReturning fills the beans by selecting the rows of the primary keys again, it's
+// used on the databases which cannot return the affected rows
+func (session *Session) reselectReturning(r *returning, beans []interface{}, pks []schemas.PK) error {
+	if len(r.table.PrimaryKeys) == 0 {
+		return fmt.Errorf("returning on %s needs the primary keys of table %s", session.engine.dialect.URI().DBType, r.tableName)
+	}
+
+	quoter := session.engine.dialect.Quoter()
+	for i, bean := range beans {
+		if i >= len(pks) || len(pks[i]) != len(r.table.PrimaryKeys) {
+			continue
+		}
+
+		cond := builder.NewCond()
+		for j, colName := range r.table.PrimaryKeys {
+			cond = cond.And(builder.Eq{quoter.Quote(colName): pks[i][j]})
+		}
+		condSQL, condArgs, err := builder.ToSQL(cond)
+		if err != nil {
+			return err
+		}
+
+		sqlStr := fmt.Sprintf("SELECT %s FROM %s WHERE %s", quoter.Join(r.cols, ","), quoter.Quote(r.tableName), condSQL)
+		if err := session.queryReturningRow(r, bean, sqlStr, condArgs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (session *Session) queryReturningRow(r *returning, bean interface{}, sqlStr string, args ...interface{}) error {
+	rows, err := session.queryMasterRows(sqlStr, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return rows.Err()
+	}
+	fields, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	return session.scanReturning(rows, fields, r.table, bean)
+}
+
+// beanPK returns the primary key values of bean, the second return value is false if
+// any of them is zero
+func beanPK(table *schemas.Table, bean interface{}) (schemas.PK, bool) {
+	if len(table.PrimaryKeys) == 0 {
+		return nil, false
+	}
+	pk, err := table.IDOfV(reflect.ValueOf(bean))
+	if err != nil || pk.IsZero() {
+		return nil, false
+	}
+	return pk, true
+}
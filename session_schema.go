@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/xormplus/xorm/internal/utils"
@@ -224,6 +225,77 @@ func (session *Session) addUnique(tableName, uqeName string) error {
 	return err
 }
 
+func (session *Session) addForeignKey(tableName string, fk *schemas.ForeignKey) error {
+	sqlStr := session.engine.dialect.CreateForeignKeySQL(tableName, fk)
+	if sqlStr == "" {
+		session.engine.logger.Warnf("Table %s foreign key %s cannot be added to an existing table on %s",
+			tableName, fk.XName(tableName), session.engine.dialect.URI().DBType)
+		return nil
+	}
+	_, err := session.exec(sqlStr)
+	return err
+}
+
+func (session *Session) dropForeignKey(tableName string, fk *schemas.ForeignKey) error {
+	sqlStr := session.engine.dialect.DropForeignKeySQL(tableName, fk)
+	if sqlStr == "" {
+		session.engine.logger.Warnf("Table %s foreign key %s cannot be dropped from an existing table on %s",
+			tableName, fk.XName(tableName), session.engine.dialect.URI().DBType)
+		return nil
+	}
+	_, err := session.exec(sqlStr)
+	return err
+}
+
+// syncForeignKeys adds the foreign keys declared on the struct but missing on the database,
+// recreates the changed ones and drops the ones created by xorm which are no longer declared.
+// Foreign keys which were not created by xorm are left untouched.
+func (session *Session) syncForeignKeys(table, oriTable *schemas.Table, tbNameWithSchema string) error {
+	var foundFKNames = make(map[string]bool)
+	var addedFKs = make([]*schemas.ForeignKey, 0)
+
+	var fkNames = make([]string, 0, len(table.ForeignKeys))
+	for name := range table.ForeignKeys {
+		fkNames = append(fkNames, name)
+	}
+	sort.Strings(fkNames)
+
+	for _, name := range fkNames {
+		fk := table.ForeignKeys[name]
+		var oriFK *schemas.ForeignKey
+		for name2, fk2 := range oriTable.ForeignKeys {
+			if fk.Equal(fk2) {
+				oriFK = fk2
+				foundFKNames[name2] = true
+				break
+			}
+		}
+		if oriFK == nil {
+			addedFKs = append(addedFKs, fk)
+		}
+	}
+
+	for name2, fk2 := range oriTable.ForeignKeys {
+		if foundFKNames[name2] {
+			continue
+		}
+		if !fk2.IsRegular {
+			session.engine.logger.Warnf("Table %s has foreign key %s but struct has not related tag", tbNameWithSchema, fk2.Name)
+			continue
+		}
+		if err := session.dropForeignKey(tbNameWithSchema, fk2); err != nil {
+			return err
+		}
+	}
+
+	for _, fk := range addedFKs {
+		if err := session.addForeignKey(tbNameWithSchema, fk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Sync2 synchronize structs to database tables
 func (session *Session) Sync2(beans ...interface{}) error {
 	engine := session.engine
@@ -244,12 +316,24 @@ func (session *Session) Sync2(beans ...interface{}) error {
 		session.resetStatement()
 	}()
 
+	// the referenced tables should be created before the foreign keys referencing them
+	beans, err = engine.sortBeansByForeignKeys(beans)
+	if err != nil {
+		return err
+	}
+
 	for _, bean := range beans {
 		v := utils.ReflectValue(bean)
 		table, err := engine.tagParser.ParseWithCache(v)
 		if err != nil {
 			return err
 		}
+		if table.PartitionColumn() != nil && len(session.statement.AltTableName) == 0 {
+			if err := session.syncAheadPartitions(bean, table); err != nil {
+				return err
+			}
+			continue
+		}
 		var tbName string
 		if len(session.statement.AltTableName) > 0 {
 			tbName = session.statement.AltTableName
@@ -425,6 +509,10 @@ func (session *Session) Sync2(beans ...interface{}) error {
 			}
 		}
 
+		if err = session.syncForeignKeys(table, oriTable, tbNameWithSchema); err != nil {
+			return err
+		}
+
 		// check all the columns which removed from struct fields but left on database tables.
 		for _, colName := range oriTable.ColumnsSeq() {
 			if table.GetColumn(colName) == nil {
@@ -8,11 +8,15 @@ import (
 	"database/sql"
 	"errors"
 	"reflect"
+
+	"github.com/xormplus/xorm/contexts"
 )
 
 // Count counts the records. bean's non-empty fields
 // are conditions.
 func (session *Session) Count(bean ...interface{}) (int64, error) {
+	defer session.beginOperation(contexts.OperationCount)()
+
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -33,6 +37,8 @@ func (session *Session) Count(bean ...interface{}) (int64, error) {
 
 // sum call sum some column. bean's non-empty fields are conditions.
 func (session *Session) sum(res interface{}, bean interface{}, columnNames ...string) error {
+	defer session.beginOperation(contexts.OperationSum)()
+
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -0,0 +1,67 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+// With adds a common table expression named by name to the WITH clause of the query, i.e.
+//
+//	sub := engine.Table("order").Select("user_id, SUM(amount) AS total").GroupBy("user_id")
+//	err := engine.With("totals", sub).Table("totals").Where("total > ?", 100).Find(&totals)
+//
+// name could have the columns, i.e. tree(id, parent_id). query is a *Session built like
+// the one of Find, which is used up as a Find does, a SQL string with args, or a builder.
+func (session *Session) With(name string, query interface{}, args ...interface{}) *Session {
+	return session.with(name, false, query, args)
+}
+
+// WithRecursive adds a recursive common table expression to the WITH clause like With,
+// query is usually a SQL string with a UNION ALL of the initial rows and the recursive
+// ones. WITH RECURSIVE is rendered as WITH for SQL Server and Oracle.
+func (session *Session) WithRecursive(name string, query interface{}, args ...interface{}) *Session {
+	return session.with(name, true, query, args)
+}
+
+func (session *Session) with(name string, recursive bool, query interface{}, args []interface{}) *Session {
+	query, args, err := session.subQuery(query, args)
+	if err != nil {
+		session.statement.LastError = err
+		return session
+	}
+	session.statement.With(name, recursive, query, args...)
+	return session
+}
+
+// From selects from the sub query named by alias instead of the table, the columns of the
+// beans are qualified by alias if there are joins. query is a *Session, a SQL string with
+// args, or a builder. Where, Join, GroupBy, OrderBy and Limit are applied to the outer query.
+func (session *Session) From(query interface{}, alias string, args ...interface{}) *Session {
+	query, args, err := session.subQuery(query, args)
+	if err != nil {
+		session.statement.LastError = err
+		return session
+	}
+	session.statement.From(query, alias, args...)
+	return session
+}
+
+// subQuery generates the SQL and args of a *Session, the other queries are returned as is
+func (session *Session) subQuery(query interface{}, args []interface{}) (interface{}, []interface{}, error) {
+	sub, ok := query.(*Session)
+	if !ok {
+		return query, args, nil
+	}
+	if sub.isAutoClose {
+		defer sub.Close()
+	}
+	defer sub.resetStatement()
+	if sub.statement.LastError != nil {
+		return nil, nil, sub.statement.LastError
+	}
+
+	sqlStr, subArgs, err := sub.statement.GenQuerySQL()
+	if err != nil {
+		return nil, nil, err
+	}
+	return sqlStr, subArgs, nil
+}
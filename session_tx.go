@@ -4,11 +4,15 @@
 
 package xorm
 
+import "github.com/xormplus/xorm/contexts"
+
 // Begin a transaction
 func (session *Session) Begin() error {
 	if session.isAutoCommit {
-		tx, err := session.DB().BeginTx(session.ctx, nil)
+		session.txID = newSessionID()
+		tx, err := session.DB().BeginTx(session.operationContext(contexts.OperationBegin), nil)
 		if err != nil {
+			session.txID = ""
 			return err
 		}
 		session.isAutoCommit = false
@@ -26,6 +30,8 @@ func (session *Session) Rollback() error {
 		session.saveLastSQL("ROLL BACK")
 		session.isCommitedOrRollbacked = true
 		session.isAutoCommit = true
+		session.txWrittenTables = nil
+		session.txID = ""
 
 		return session.tx.Rollback()
 	}
@@ -38,11 +44,18 @@ func (session *Session) Commit() error {
 		session.saveLastSQL("COMMIT")
 		session.isCommitedOrRollbacked = true
 		session.isAutoCommit = true
+		session.txID = ""
 
 		if err := session.tx.Commit(); err != nil {
 			return err
 		}
 
+		session.markWrite()
+		if len(session.txWrittenTables) > 0 {
+			session.engine.clearQueryCache(session.txWrittenTables...)
+			session.txWrittenTables = nil
+		}
+
 		// handle processors after tx committed
 		closureCallFunc := func(closuresPtr *[]func(interface{}), bean interface{}) {
 			if closuresPtr != nil {
@@ -5,6 +5,7 @@
 package xorm
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/xormplus/builder"
 	"github.com/xormplus/xorm/caches"
+	"github.com/xormplus/xorm/contexts"
 	"github.com/xormplus/xorm/internal/utils"
 	"github.com/xormplus/xorm/schemas"
 )
@@ -43,7 +45,7 @@ func (session *Session) cacheUpdate(table *schemas.Table, tableName, sqlStr stri
 	}
 
 	cacher := session.engine.GetCacher(tableName)
-	session.engine.logger.Debugf("[cache] get cache sql: %v, %v", newsql, args[nStart:])
+	session.engine.logger.Debugf("[cache] get cache sql: %v, %v", newsql, session.engine.db.Redactor.Redact(newsql, args[nStart:]))
 	ids, err := caches.GetCacheSql(cacher, tableName, newsql, args[nStart:])
 	if err != nil {
 		rows, err := session.NoCache().queryRows(newsql, args[nStart:]...)
@@ -111,7 +113,7 @@ func (session *Session) cacheUpdate(table *schemas.Table, tableName, sqlStr stri
 					if err != nil {
 						session.engine.logger.Errorf("%v", err)
 					} else {
-						session.engine.logger.Debugf("[cache] set bean field: %v, %v, %v", bean, colName, fieldValue.Interface())
+						session.engine.logger.Debugf("[cache] set bean field: %v, %v", tableName, colName)
 						if col.IsVersion && session.statement.CheckVersion {
 							session.incrVersionFieldValue(fieldValue)
 						} else {
@@ -124,7 +126,7 @@ func (session *Session) cacheUpdate(table *schemas.Table, tableName, sqlStr stri
 				}
 			}
 
-			session.engine.logger.Debugf("[cache] update cache: %v, %v, %v", tableName, id, bean)
+			session.engine.logger.Debugf("[cache] update cache: %v, %v", tableName, id)
 			cacher.PutBean(tableName, sid, bean)
 		}
 	}
@@ -140,6 +142,8 @@ func (session *Session) cacheUpdate(table *schemas.Table, tableName, sqlStr stri
 //         You should call UseBool if you have bool to use.
 //        2.float32 & float64 may be not inexact as conditions
 func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int64, error) {
+	defer session.beginOperation(contexts.OperationUpdate)()
+
 	if session.isAutoClose {
 		defer session.Close()
 	}
@@ -176,6 +180,10 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 			return 0, ErrTableNotFound
 		}
 
+		if err := session.statement.SetBlindIndexes(session.statement.RefTable, bean); err != nil {
+			return 0, err
+		}
+
 		if session.statement.ColumnStr() == "" {
 			colNames, args, err = session.statement.BuildUpdates(v, false, false,
 				false, false, true)
@@ -186,14 +194,23 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 			return 0, err
 		}
 	} else if isMap {
-		colNames = make([]string, 0)
-		args = make([]interface{}, 0)
+		var columns []string
 		bValue := reflect.Indirect(reflect.ValueOf(bean))
 
 		for _, v := range bValue.MapKeys() {
-			colNames = append(colNames, session.engine.Quote(v.String())+" = ?")
+			columns = append(columns, v.String())
 			args = append(args, bValue.MapIndex(v).Interface())
 		}
+		if table := session.statement.RefTable; table != nil {
+			if columns, args, err = session.statement.EncryptMapValues(table, columns, args); err != nil {
+				return 0, err
+			}
+		}
+
+		colNames = make([]string, 0, len(columns))
+		for _, colName := range columns {
+			colNames = append(colNames, session.engine.Quote(colName)+" = ?")
+		}
 	} else {
 		return 0, ErrParamsType
 	}
@@ -400,21 +417,50 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 		}
 	}
 
-	sqlStr = fmt.Sprintf("UPDATE %v%v SET %v %v%v",
+	var (
+		r  *returning
+		pk schemas.PK
+	)
+	if isStruct {
+		r = session.newReturning()
+		if pk = session.statement.IDParam(); pk == nil && table != nil {
+			pk, _ = beanPK(table, bean)
+		}
+	}
+
+	sqlStr = fmt.Sprintf("UPDATE %v%v SET %v%v %v%v%v",
 		top,
 		tableAlias,
 		strings.Join(colNames, ", "),
+		session.statement.GenOutputSQL("INSERTED"),
 		fromSQL,
-		condSQL)
+		condSQL,
+		session.statement.GenReturningSQL())
 
-	res, err := session.exec(sqlStr, append(args, condArgs...)...)
+	var affected int64
+	if r != nil && r.supported {
+		affected, err = session.execReturning(r, bean, sqlStr, append(args, condArgs...)...)
+	} else {
+		var res sql.Result
+		if res, err = session.exec(sqlStr, append(args, condArgs...)...); err == nil {
+			affected, err = res.RowsAffected()
+		}
+	}
 	if err != nil {
 		return 0, err
-	} else if doIncVer {
+	}
+	if doIncVer && (r == nil || !r.hasColumn(table.Version)) {
 		if verValue != nil && verValue.IsValid() && verValue.CanSet() {
 			session.incrVersionFieldValue(verValue)
 		}
 	}
+	if r != nil && !r.supported {
+		if pk == nil {
+			session.engine.logger.Warnf("[returning] the updated row of %s cannot be selected without primary key", tableName)
+		} else if err := session.reselectReturning(r, []interface{}{bean}, []schemas.PK{pk}); err != nil {
+			return affected, err
+		}
+	}
 
 	if cacher := session.engine.GetCacher(tableName); cacher != nil && session.statement.UseCache {
 		// session.cacheUpdate(table, tableName, sqlStr, args...)
@@ -453,7 +499,7 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 	cleanupProcessorsClosures(&session.afterClosures) // cleanup after used
 	// --
 
-	return res.RowsAffected()
+	return affected, nil
 }
 
 func (session *Session) genUpdateColumns(bean interface{}) ([]string, []interface{}, error) {
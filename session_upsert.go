@@ -0,0 +1,160 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/xormplus/xorm/contexts"
+	"github.com/xormplus/xorm/internal/utils"
+)
+
+// Upsert inserts one bean or a slice of beans, and updates the existing rows when the
+// conflictCols, or the primary keys if none is given, conflict with an existing row.
+// It generates ON CONFLICT ... DO UPDATE on postgres and sqlite3, ON DUPLICATE KEY UPDATE
+// on mysql and MERGE on mssql and oracle. Cols and Omit limit the inserted columns, and
+// like Update, zero fields are not updated unless AllCols or MustCols is used. The updated
+// column is always refreshed and the version column is increased on update.
+// The returned affected rows follow the database, i.e. mysql counts an updated row as 2.
+func (session *Session) Upsert(bean interface{}, conflictCols ...string) (int64, error) {
+	defer session.beginOperation(contexts.OperationUpsert)()
+
+	if session.isAutoClose {
+		defer session.Close()
+	}
+
+	session.autoResetStatement = false
+	defer func() {
+		session.autoResetStatement = true
+		session.resetStatement()
+	}()
+
+	return session.upsert(bean, conflictCols)
+}
+
+// InsertOrUpdate is an alias of Upsert
+func (session *Session) InsertOrUpdate(bean interface{}, conflictCols ...string) (int64, error) {
+	return session.Upsert(bean, conflictCols...)
+}
+
+func (session *Session) upsert(bean interface{}, conflictCols []string) (int64, error) {
+	var beans []interface{}
+	sliceValue := reflect.Indirect(reflect.ValueOf(bean))
+	if sliceValue.Kind() == reflect.Slice {
+		if sliceValue.Len() <= 0 {
+			return 0, ErrNoElementsOnSlice
+		}
+		for i := 0; i < sliceValue.Len(); i++ {
+			v := sliceValue.Index(i)
+			if v.Kind() == reflect.Interface {
+				v = v.Elem()
+			}
+			if v.Kind() != reflect.Ptr {
+				v = v.Addr()
+			}
+			beans = append(beans, v.Interface())
+		}
+	} else {
+		beans = []interface{}{bean}
+	}
+
+	if err := session.statement.SetRefBean(beans[0]); err != nil {
+		return 0, err
+	}
+	tableName := session.statement.TableName()
+	if len(tableName) <= 0 {
+		return 0, ErrTableNotFound
+	}
+	table := session.statement.RefTable
+
+	var (
+		colNames []string
+		rows     = make([][]interface{}, 0, len(beans))
+		nonZeros = make(map[string]bool)
+	)
+	for i, elem := range beans {
+		for _, closure := range session.beforeClosures {
+			closure(elem)
+		}
+		if processor, ok := interface{}(elem).(BeforeInsertProcessor); ok {
+			processor.BeforeInsert()
+		}
+		if err := session.statement.SetBlindIndexes(table, elem); err != nil {
+			return 0, err
+		}
+
+		cols, args, err := session.genInsertColumns(elem)
+		if err != nil {
+			return 0, err
+		}
+		if i == 0 {
+			colNames = cols
+		} else if strings.Join(colNames, ",") != strings.Join(cols, ",") {
+			return 0, errors.New("all the upserted beans should have the same inserted columns")
+		}
+		rows = append(rows, args)
+
+		for _, colName := range cols {
+			if nonZeros[colName] {
+				continue
+			}
+			fieldValue, err := table.GetColumn(colName).ValueOf(elem)
+			if err != nil {
+				return 0, err
+			}
+			nonZeros[colName] = !utils.IsValueZero(*fieldValue)
+		}
+	}
+	cleanupProcessorsClosures(&session.beforeClosures)
+
+	conflictCols, err := session.statement.UpsertConflictColumns(colNames, conflictCols)
+	if err != nil {
+		return 0, err
+	}
+	updateCols := session.statement.UpsertUpdateColumns(colNames, conflictCols, func(colName string) bool {
+		return !nonZeros[colName]
+	})
+
+	sqlStr, args, err := session.statement.GenUpsertSQL(colNames, rows, conflictCols, updateCols)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := session.exec(sqlStr, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	session.cacheInsert(tableName)
+	if cacher := session.engine.GetCacher(tableName); cacher != nil && session.statement.UseCache {
+		cacher.ClearBeans(tableName)
+	}
+
+	for _, elem := range beans {
+		if session.isAutoCommit {
+			for _, closure := range session.afterClosures {
+				closure(elem)
+			}
+			if processor, ok := elem.(AfterInsertProcessor); ok {
+				processor.AfterInsert()
+			}
+		} else if len(session.afterClosures) > 0 {
+			if value, has := session.afterInsertBeans[elem]; has && value != nil {
+				*value = append(*value, session.afterClosures...)
+			} else {
+				afterClosures := make([]func(interface{}), len(session.afterClosures))
+				copy(afterClosures, session.afterClosures)
+				session.afterInsertBeans[elem] = &afterClosures
+			}
+		} else if _, ok := elem.(AfterInsertProcessor); ok {
+			session.afterInsertBeans[elem] = nil
+		}
+	}
+	cleanupProcessorsClosures(&session.afterClosures)
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,968 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xormplus/builder"
+	"github.com/xormplus/xorm/internal/utils"
+	"github.com/xormplus/xorm/schemas"
+)
+
+var (
+	// ErrShardKeyRequired the shard key is required but neither given nor found in the bean
+	ErrShardKeyRequired = errors.New("The shard key is required")
+	// ErrNoShards no engine is given to the sharded engine
+	ErrNoShards = errors.New("At least one shard is needed")
+)
+
+// ShardFunc returns the index of the shard of the key, shards is the number of the shards
+type ShardFunc func(key interface{}, shards int) (int, error)
+
+// HashShard chooses the shard by the fnv hash of the key
+func HashShard() ShardFunc {
+	return func(key interface{}, shards int) (int, error) {
+		h := fnv.New32a()
+		h.Write([]byte(fmt.Sprint(key)))
+		return int(h.Sum32() % uint32(shards)), nil
+	}
+}
+
+// RangeShard chooses the shard by the integer key, the i-th shard holds the keys less than
+// bounds[i] and not less than bounds[i-1], the last shard holds the rest. So there should be
+// one bound less than the shards.
+func RangeShard(bounds ...int64) ShardFunc {
+	return func(key interface{}, shards int) (int, error) {
+		k, err := shardKeyInt(key)
+		if err != nil {
+			return 0, err
+		}
+		idx := sort.Search(len(bounds), func(i int) bool {
+			return k < bounds[i]
+		})
+		return idx, nil
+	}
+}
+
+// LookupShard chooses the shard by a lookup table keyed by fmt.Sprint(key)
+func LookupShard(table map[string]int) ShardFunc {
+	return func(key interface{}, shards int) (int, error) {
+		idx, ok := table[fmt.Sprint(key)]
+		if !ok {
+			return 0, fmt.Errorf("No shard for the key %v", key)
+		}
+		return idx, nil
+	}
+}
+
+func shardKeyInt(key interface{}) (int64, error) {
+	v := reflect.Indirect(reflect.ValueOf(key))
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.String:
+		return strconv.ParseInt(v.String(), 10, 64)
+	}
+	return 0, fmt.Errorf("Unsupported shard key %v", key)
+}
+
+// ShardedEngine routes the operations to one of the engines by the shard key, which is the
+// value of the field tagged by shard, i.e. `xorm:"shard"`, or given by ShardKey. The queries
+// without a shard key are sent to all the shards and the results are merged by the order of
+// OrderBy, Asc or Desc. The writes across shards are not atomic.
+type ShardedEngine struct {
+	engines   []*Engine
+	shardFunc ShardFunc
+}
+
+// NewShardedEngine creates a sharded engine, the index returned by shardFunc is the index
+// of engines
+func NewShardedEngine(engines []*Engine, shardFunc ShardFunc) (*ShardedEngine, error) {
+	if len(engines) == 0 {
+		return nil, ErrNoShards
+	}
+	return &ShardedEngine{
+		engines:   engines,
+		shardFunc: shardFunc,
+	}, nil
+}
+
+// Shards returns all the engines
+func (se *ShardedEngine) Shards() []*Engine {
+	return se.engines
+}
+
+// Shard returns the engine of the shard key
+func (se *ShardedEngine) Shard(key interface{}) (*Engine, error) {
+	idx, err := se.shardFunc(key, len(se.engines))
+	if err != nil {
+		return nil, err
+	}
+	if idx < 0 || idx >= len(se.engines) {
+		return nil, fmt.Errorf("Shard %d of the key %v is out of range", idx, key)
+	}
+	return se.engines[idx], nil
+}
+
+// ShardOf returns the engine of the shard key in bean, the second return value is false
+// if bean has no shard key column or its value is zero
+func (se *ShardedEngine) ShardOf(bean interface{}) (*Engine, bool, error) {
+	key, ok, err := se.shardKeyOf(bean)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	engine, err := se.Shard(key)
+	return engine, err == nil, err
+}
+
+func (se *ShardedEngine) shardKeyOf(bean interface{}) (interface{}, bool, error) {
+	v := reflect.Indirect(reflect.ValueOf(bean))
+	if v.Kind() != reflect.Struct {
+		return nil, false, nil
+	}
+	table, err := se.engines[0].TableInfo(bean)
+	if err != nil {
+		return nil, false, err
+	}
+	col := table.ShardKeyColumn()
+	if col == nil {
+		return nil, false, nil
+	}
+	fieldValue, err := col.ValueOfV(&v)
+	if err != nil {
+		return nil, false, err
+	}
+	if utils.IsValueZero(*fieldValue) {
+		return nil, false, nil
+	}
+	return fieldValue.Interface(), true, nil
+}
+
+// Sync2 synchronizes the structs to all the shards
+func (se *ShardedEngine) Sync2(beans ...interface{}) error {
+	for _, engine := range se.engines {
+		if err := engine.Sync2(beans...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes all the shards
+func (se *ShardedEngine) Close() error {
+	for _, engine := range se.engines {
+		if err := engine.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewSession creates a sharded session
+func (se *ShardedEngine) NewSession() *ShardedSession {
+	return &ShardedSession{engine: se}
+}
+
+// ShardKey routes the operations of the session to the shard of the key
+func (se *ShardedEngine) ShardKey(key interface{}) *ShardedSession {
+	return se.NewSession().ShardKey(key)
+}
+
+// Where provides custom query condition on all the shards
+func (se *ShardedEngine) Where(query interface{}, args ...interface{}) *ShardedSession {
+	return se.NewSession().Where(query, args...)
+}
+
+// In provides a query string like "id in (1, 2, 3)" on all the shards
+func (se *ShardedEngine) In(column string, args ...interface{}) *ShardedSession {
+	return se.NewSession().In(column, args...)
+}
+
+// Cols only use the parameters as select or update columns
+func (se *ShardedEngine) Cols(columns ...string) *ShardedSession {
+	return se.NewSession().Cols(columns...)
+}
+
+// Omit only not use the parameters as select or update columns
+func (se *ShardedEngine) Omit(columns ...string) *ShardedSession {
+	return se.NewSession().Omit(columns...)
+}
+
+// OrderBy orders the records on every shard and the merged records
+func (se *ShardedEngine) OrderBy(order string) *ShardedSession {
+	return se.NewSession().OrderBy(order)
+}
+
+// Asc orders the records on every shard and the merged records ascendingly
+func (se *ShardedEngine) Asc(colNames ...string) *ShardedSession {
+	return se.NewSession().Asc(colNames...)
+}
+
+// Desc orders the records on every shard and the merged records descendingly
+func (se *ShardedEngine) Desc(colNames ...string) *ShardedSession {
+	return se.NewSession().Desc(colNames...)
+}
+
+// Limit limits the merged records
+func (se *ShardedEngine) Limit(limit int, start ...int) *ShardedSession {
+	return se.NewSession().Limit(limit, start...)
+}
+
+// Table sets the table name on all the shards
+func (se *ShardedEngine) Table(tableNameOrBean interface{}) *ShardedSession {
+	return se.NewSession().Table(tableNameOrBean)
+}
+
+// Insert inserts the beans, or the elements of the slices, to their shards
+func (se *ShardedEngine) Insert(beans ...interface{}) (int64, error) {
+	return se.NewSession().Insert(beans...)
+}
+
+// Get retrieves one record from the shard of the key in bean, or the first shard which has it
+func (se *ShardedEngine) Get(bean interface{}) (bool, error) {
+	return se.NewSession().Get(bean)
+}
+
+// Update updates the records on the shard of the key in condiBean, or bean if there are no
+// conditions, or all the shards
+func (se *ShardedEngine) Update(bean interface{}, condiBean ...interface{}) (int64, error) {
+	return se.NewSession().Update(bean, condiBean...)
+}
+
+// Delete deletes the records on the shard of the key in bean, or all the shards
+func (se *ShardedEngine) Delete(bean interface{}) (int64, error) {
+	return se.NewSession().Delete(bean)
+}
+
+// Find retrieves the records from the shard of the key in condiBean, or all the shards
+func (se *ShardedEngine) Find(rowsSlicePtr interface{}, condiBean ...interface{}) error {
+	return se.NewSession().Find(rowsSlicePtr, condiBean...)
+}
+
+// Count counts the records on the shard of the key in bean, or all the shards
+func (se *ShardedEngine) Count(bean ...interface{}) (int64, error) {
+	return se.NewSession().Count(bean...)
+}
+
+// Iterate iterates the records on the shard of the key in bean, or all the shards
+func (se *ShardedEngine) Iterate(bean interface{}, fun IterFunc) error {
+	return se.NewSession().Iterate(bean, fun)
+}
+
+type shardOrder struct {
+	column string
+	desc   bool
+}
+
+// ShardedSession records the conditions and applies them to the sessions of the shards
+// when an operation is executed, so it could be used more than once.
+type ShardedSession struct {
+	engine *ShardedEngine
+	ops    []func(*Session) *Session
+
+	key    interface{}
+	hasKey bool
+
+	// the conditions which may contain the shard key
+	eqConds []builder.Eq
+	hasOr   bool
+	// there are the conditions whose shard key is unknown, i.e. the raw SQLs
+	hasOtherConds bool
+
+	orders []shardOrder
+	limit  int
+	start  int
+}
+
+// Apply applies fn to the session of every shard, it's useful for the methods of
+// Session which are not provided by ShardedSession
+func (ss *ShardedSession) Apply(fn func(*Session) *Session) *ShardedSession {
+	ss.ops = append(ss.ops, fn)
+	return ss
+}
+
+// ShardKey routes the operations to the shard of the key
+func (ss *ShardedSession) ShardKey(key interface{}) *ShardedSession {
+	ss.key = key
+	ss.hasKey = true
+	return ss
+}
+
+func (ss *ShardedSession) addCond(query interface{}) {
+	switch cond := query.(type) {
+	case builder.Eq:
+		ss.eqConds = append(ss.eqConds, cond)
+	case map[string]interface{}:
+		ss.eqConds = append(ss.eqConds, builder.Eq(cond))
+	default:
+		ss.hasOtherConds = true
+	}
+}
+
+// Where provides custom query condition, a builder.Eq or map[string]interface{} containing
+// the shard key column routes the operations to the shard of its value
+func (ss *ShardedSession) Where(query interface{}, args ...interface{}) *ShardedSession {
+	ss.addCond(query)
+	return ss.Apply(func(session *Session) *Session {
+		return session.Where(query, args...)
+	})
+}
+
+// And provides custom query condition, see Where
+func (ss *ShardedSession) And(query interface{}, args ...interface{}) *ShardedSession {
+	ss.addCond(query)
+	return ss.Apply(func(session *Session) *Session {
+		return session.And(query, args...)
+	})
+}
+
+// Or provides custom query condition, the shard key in the conditions is ignored after it
+func (ss *ShardedSession) Or(query interface{}, args ...interface{}) *ShardedSession {
+	ss.hasOr = true
+	return ss.Apply(func(session *Session) *Session {
+		return session.Or(query, args...)
+	})
+}
+
+// ID provides converting id as a query condition
+func (ss *ShardedSession) ID(id interface{}) *ShardedSession {
+	ss.hasOtherConds = true
+	return ss.Apply(func(session *Session) *Session {
+		return session.ID(id)
+	})
+}
+
+// In provides a query string like "id in (1, 2, 3)"
+func (ss *ShardedSession) In(column string, args ...interface{}) *ShardedSession {
+	ss.hasOtherConds = true
+	return ss.Apply(func(session *Session) *Session {
+		return session.In(column, args...)
+	})
+}
+
+// Cols only use the parameters as select or update columns
+func (ss *ShardedSession) Cols(columns ...string) *ShardedSession {
+	return ss.Apply(func(session *Session) *Session {
+		return session.Cols(columns...)
+	})
+}
+
+// Omit only not use the parameters as select or update columns
+func (ss *ShardedSession) Omit(columns ...string) *ShardedSession {
+	return ss.Apply(func(session *Session) *Session {
+		return session.Omit(columns...)
+	})
+}
+
+// Table sets the table name
+func (ss *ShardedSession) Table(tableNameOrBean interface{}) *ShardedSession {
+	return ss.Apply(func(session *Session) *Session {
+		return session.Table(tableNameOrBean)
+	})
+}
+
+// OrderBy orders the records like "name desc, id", only the column names could be used
+// since the records of the shards are merged by the values of the columns
+func (ss *ShardedSession) OrderBy(order string) *ShardedSession {
+	for _, part := range strings.Split(order, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		ss.orders = append(ss.orders, shardOrder{
+			column: fields[0],
+			desc:   len(fields) > 1 && strings.EqualFold(fields[1], "desc"),
+		})
+	}
+	return ss.Apply(func(session *Session) *Session {
+		return session.OrderBy(order)
+	})
+}
+
+// Asc orders the records ascendingly
+func (ss *ShardedSession) Asc(colNames ...string) *ShardedSession {
+	for _, col := range colNames {
+		ss.orders = append(ss.orders, shardOrder{column: col})
+	}
+	return ss.Apply(func(session *Session) *Session {
+		return session.Asc(colNames...)
+	})
+}
+
+// Desc orders the records descendingly
+func (ss *ShardedSession) Desc(colNames ...string) *ShardedSession {
+	for _, col := range colNames {
+		ss.orders = append(ss.orders, shardOrder{column: col, desc: true})
+	}
+	return ss.Apply(func(session *Session) *Session {
+		return session.Desc(colNames...)
+	})
+}
+
+// Limit limits the merged records
+func (ss *ShardedSession) Limit(limit int, start ...int) *ShardedSession {
+	ss.limit = limit
+	if len(start) > 0 {
+		ss.start = start[0]
+	}
+	return ss
+}
+
+// route returns the engines the operation should be sent to
+func (ss *ShardedSession) route(beans ...interface{}) ([]*Engine, error) {
+	if ss.hasKey {
+		engine, err := ss.engine.Shard(ss.key)
+		if err != nil {
+			return nil, err
+		}
+		return []*Engine{engine}, nil
+	}
+
+	for _, bean := range beans {
+		if bean == nil {
+			continue
+		}
+		engine, ok, err := ss.engine.ShardOf(bean)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return []*Engine{engine}, nil
+		}
+	}
+
+	if len(ss.eqConds) > 0 && !ss.hasOr {
+		for _, bean := range beans {
+			key, ok, err := ss.condKey(bean)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				engine, err := ss.engine.Shard(key)
+				if err != nil {
+					return nil, err
+				}
+				return []*Engine{engine}, nil
+			}
+		}
+	}
+	return ss.engine.engines, nil
+}
+
+// routeUpdate returns the engines of an update, the conditions are routed before bean
+func (ss *ShardedSession) routeUpdate(bean interface{}, condiBean []interface{}) ([]*Engine, error) {
+	if ss.hasKey {
+		return ss.route()
+	}
+
+	beans := make([]interface{}, 0, len(condiBean)+1)
+	beans = append(beans, condiBean...)
+	for _, condi := range beans {
+		if condi == nil {
+			continue
+		}
+		engine, ok, err := ss.engine.ShardOf(condi)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return []*Engine{engine}, nil
+		}
+	}
+
+	// the key of the conditions is looked up by the table of bean too
+	beans = append(beans, bean)
+	if len(ss.eqConds) > 0 && !ss.hasOr {
+		for _, condi := range beans {
+			key, ok, err := ss.condKey(condi)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				engine, err := ss.engine.Shard(key)
+				if err != nil {
+					return nil, err
+				}
+				return []*Engine{engine}, nil
+			}
+		}
+	}
+
+	// the records matching the other conditions may be on any shard
+	if len(condiBean) > 0 || len(ss.eqConds) > 0 || ss.hasOr || ss.hasOtherConds {
+		return ss.engine.engines, nil
+	}
+	return ss.route(bean)
+}
+
+// condKey returns the shard key in the Eq conditions
+func (ss *ShardedSession) condKey(bean interface{}) (interface{}, bool, error) {
+	if bean == nil || reflect.Indirect(reflect.ValueOf(bean)).Kind() != reflect.Struct {
+		return nil, false, nil
+	}
+	table, err := ss.engine.engines[0].TableInfo(bean)
+	if err != nil {
+		return nil, false, err
+	}
+	if table.ShardKey == "" {
+		return nil, false, nil
+	}
+	for _, cond := range ss.eqConds {
+		for col, value := range cond {
+			if strings.EqualFold(utils.BareTableName(col), table.ShardKey) {
+				return value, true, nil
+			}
+		}
+	}
+	return nil, false, nil
+}
+
+func (ss *ShardedSession) newSession(engine *Engine) *Session {
+	session := engine.NewSession()
+	for _, op := range ss.ops {
+		session = op(session)
+	}
+	return session
+}
+
+// limitSession limits the records of a shard, the records of the skipped ones are needed
+// if there are more than one shard
+func (ss *ShardedSession) limitSession(session *Session, shards int) *Session {
+	if ss.limit <= 0 {
+		return session
+	}
+	if shards == 1 {
+		return session.Limit(ss.limit, ss.start)
+	}
+	return session.Limit(ss.limit + ss.start)
+}
+
+// each executes fn on the engines concurrently and returns the first error
+func (ss *ShardedSession) each(engines []*Engine, fn func(int, *Session) error) error {
+	if len(engines) == 1 {
+		session := ss.newSession(engines[0])
+		defer session.Close()
+		return fn(0, session)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(engines))
+	)
+	for i, engine := range engines {
+		wg.Add(1)
+		go func(i int, engine *Engine) {
+			defer wg.Done()
+			session := ss.newSession(engine)
+			defer session.Close()
+			errs[i] = fn(i, session)
+		}(i, engine)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Insert inserts the beans, or the elements of the slices, to their shards
+func (ss *ShardedSession) Insert(beans ...interface{}) (int64, error) {
+	var (
+		shardBeans = make(map[*Engine][]interface{})
+		engines    []*Engine
+	)
+	add := func(engine *Engine, bean interface{}) {
+		if _, ok := shardBeans[engine]; !ok {
+			engines = append(engines, engine)
+		}
+		shardBeans[engine] = append(shardBeans[engine], bean)
+	}
+
+	for _, bean := range beans {
+		sliceValue := reflect.Indirect(reflect.ValueOf(bean))
+		if sliceValue.Kind() != reflect.Slice {
+			shards, err := ss.route(bean)
+			if err != nil {
+				return 0, err
+			}
+			if len(shards) != 1 {
+				return 0, ErrShardKeyRequired
+			}
+			add(shards[0], bean)
+			continue
+		}
+
+		// split the slice to the slices of the shards so that they could be inserted in batch
+		var slices = make(map[*Engine]reflect.Value)
+		for i := 0; i < sliceValue.Len(); i++ {
+			elem := sliceValue.Index(i)
+			elemBean := elem.Interface()
+			if elem.Kind() == reflect.Struct {
+				elemBean = elem.Addr().Interface()
+			}
+			shards, err := ss.route(elemBean)
+			if err != nil {
+				return 0, err
+			}
+			if len(shards) != 1 {
+				return 0, ErrShardKeyRequired
+			}
+			slice, ok := slices[shards[0]]
+			if !ok {
+				slice = reflect.New(sliceValue.Type())
+				slices[shards[0]] = slice
+				add(shards[0], slice.Interface())
+			}
+			slice.Elem().Set(reflect.Append(slice.Elem(), elem))
+		}
+	}
+
+	var affected = make([]int64, len(engines))
+	err := ss.each(engines, func(i int, session *Session) error {
+		n, err := session.Insert(shardBeans[engines[i]]...)
+		affected[i] = n
+		return err
+	})
+	return sum(affected), err
+}
+
+// Get retrieves one record from the shard of the key in bean, or the first shard which has it
+func (ss *ShardedSession) Get(bean interface{}) (bool, error) {
+	engines, err := ss.route(bean)
+	if err != nil {
+		return false, err
+	}
+	for _, engine := range engines {
+		session := ss.newSession(engine)
+		has, err := session.Get(bean)
+		session.Close()
+		if err != nil || has {
+			return has, err
+		}
+	}
+	return false, nil
+}
+
+// Update updates the records on the shard of the key in condiBean or the conditions, or
+// all the shards. The key in bean is the new value, so it routes the update only if there
+// are no other conditions.
+func (ss *ShardedSession) Update(bean interface{}, condiBean ...interface{}) (int64, error) {
+	engines, err := ss.routeUpdate(bean, condiBean)
+	if err != nil {
+		return 0, err
+	}
+	var affected = make([]int64, len(engines))
+	err = ss.each(engines, func(i int, session *Session) error {
+		n, err := session.Update(bean, condiBean...)
+		affected[i] = n
+		return err
+	})
+	return sum(affected), err
+}
+
+// Delete deletes the records on the shard of the key in bean, or all the shards
+func (ss *ShardedSession) Delete(bean interface{}) (int64, error) {
+	engines, err := ss.route(bean)
+	if err != nil {
+		return 0, err
+	}
+	var affected = make([]int64, len(engines))
+	err = ss.each(engines, func(i int, session *Session) error {
+		n, err := session.Delete(bean)
+		affected[i] = n
+		return err
+	})
+	return sum(affected), err
+}
+
+// Count counts the records on the shard of the key in bean, or all the shards
+func (ss *ShardedSession) Count(bean ...interface{}) (int64, error) {
+	engines, err := ss.route(bean...)
+	if err != nil {
+		return 0, err
+	}
+	var counts = make([]int64, len(engines))
+	err = ss.each(engines, func(i int, session *Session) error {
+		n, err := session.Count(bean...)
+		counts[i] = n
+		return err
+	})
+	return sum(counts), err
+}
+
+// Find retrieves the records from the shard of the key in condiBean, or all the shards.
+// The records of the shards are merged by the order and limited after merged, only a
+// pointer to a slice is supported.
+func (ss *ShardedSession) Find(rowsSlicePtr interface{}, condiBean ...interface{}) error {
+	sliceValue := reflect.Indirect(reflect.ValueOf(rowsSlicePtr))
+	if sliceValue.Kind() != reflect.Slice {
+		return ErrPtrSliceType
+	}
+
+	// the type of the elements is used to find the shard key column of the conditions
+	var routeBeans = condiBean
+	if elemType := derefType(sliceValue.Type().Elem()); len(routeBeans) == 0 && elemType.Kind() == reflect.Struct {
+		routeBeans = []interface{}{reflect.New(elemType).Interface()}
+	}
+	engines, err := ss.route(routeBeans...)
+	if err != nil {
+		return err
+	}
+
+	var results = make([]reflect.Value, len(engines))
+	err = ss.each(engines, func(i int, session *Session) error {
+		result := reflect.New(sliceValue.Type())
+		if err := ss.limitSession(session, len(engines)).Find(result.Interface(), condiBean...); err != nil {
+			return err
+		}
+		results[i] = result.Elem()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	merged := reflect.MakeSlice(sliceValue.Type(), 0, 0)
+	for _, result := range results {
+		merged = reflect.AppendSlice(merged, result)
+	}
+
+	if len(engines) > 1 {
+		if len(ss.orders) > 0 && merged.Len() > 1 {
+			less, err := ss.lessFunc(sliceValue.Type().Elem())
+			if err != nil {
+				return err
+			}
+			sort.SliceStable(merged.Interface(), func(i, j int) bool {
+				return less(merged.Index(i), merged.Index(j))
+			})
+		}
+		merged = ss.limitSlice(merged)
+	}
+
+	sliceValue.Set(reflect.AppendSlice(sliceValue, merged))
+	return nil
+}
+
+func (ss *ShardedSession) limitSlice(v reflect.Value) reflect.Value {
+	if ss.limit <= 0 {
+		return v
+	}
+	start := ss.start
+	if start > v.Len() {
+		start = v.Len()
+	}
+	end := start + ss.limit
+	if end > v.Len() {
+		end = v.Len()
+	}
+	return v.Slice(start, end)
+}
+
+// Iterate iterates the records on the shard of the key in bean, or all the shards. The
+// records of the shards are merged by the order one by one, so that they are not loaded
+// into the memory at once.
+func (ss *ShardedSession) Iterate(bean interface{}, fun IterFunc) error {
+	engines, err := ss.route(bean)
+	if err != nil {
+		return err
+	}
+	if len(engines) == 1 {
+		session := ss.limitSession(ss.newSession(engines[0]), 1)
+		defer session.Close()
+		return session.Iterate(bean, fun)
+	}
+
+	var less func(a, b reflect.Value) bool
+	if len(ss.orders) > 0 {
+		if less, err = ss.lessFunc(reflect.TypeOf(bean)); err != nil {
+			return err
+		}
+	}
+
+	beanType := reflect.Indirect(reflect.ValueOf(bean)).Type()
+	var (
+		rowsList = make([]*Rows, 0, len(engines))
+		heads    = make([]reflect.Value, len(engines))
+	)
+	defer func() {
+		for _, rows := range rowsList {
+			rows.Close()
+		}
+	}()
+	next := func(i int) error {
+		heads[i] = reflect.Value{}
+		if !rowsList[i].Next() {
+			// Rows.Err returns sql.ErrNoRows at the end of the rows
+			if err := rowsList[i].Err(); err != sql.ErrNoRows {
+				return err
+			}
+			return nil
+		}
+		head := reflect.New(beanType)
+		if err := rowsList[i].Scan(head.Interface()); err != nil {
+			return err
+		}
+		heads[i] = head
+		return nil
+	}
+	for i, engine := range engines {
+		session := ss.limitSession(ss.newSession(engine), len(engines))
+		session.isAutoClose = true
+		rows, err := session.Rows(bean)
+		if err != nil {
+			session.Close()
+			return err
+		}
+		rowsList = append(rowsList, rows)
+		if err := next(i); err != nil {
+			return err
+		}
+	}
+
+	for idx, n := 0, 0; ss.limit <= 0 || n < ss.start+ss.limit; n++ {
+		var min = -1
+		for i, head := range heads {
+			if !head.IsValid() {
+				continue
+			}
+			if min < 0 || (less != nil && less(head, heads[min])) {
+				min = i
+			}
+		}
+		if min < 0 {
+			return nil
+		}
+
+		if n >= ss.start {
+			if err := fun(idx, heads[min].Interface()); err != nil {
+				return err
+			}
+			idx++
+		}
+		if err := next(min); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// lessFunc returns the function comparing two records of the type by the order
+func (ss *ShardedSession) lessFunc(recordType reflect.Type) (func(a, b reflect.Value) bool, error) {
+	recordType = derefType(recordType)
+	if recordType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Records of type %v cannot be merged by order", recordType)
+	}
+	table, err := ss.engine.engines[0].TableInfo(reflect.New(recordType).Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	var cols = make([]*schemas.Column, len(ss.orders))
+	for i, order := range ss.orders {
+		cols[i] = table.GetColumn(utils.BareTableName(order.column))
+		if cols[i] == nil {
+			return nil, fmt.Errorf("Records cannot be merged by order column %s", order.column)
+		}
+	}
+
+	return func(a, b reflect.Value) bool {
+		a, b = reflect.Indirect(a), reflect.Indirect(b)
+		for i, col := range cols {
+			av, err := col.ValueOfV(&a)
+			if err != nil {
+				return false
+			}
+			bv, err := col.ValueOfV(&b)
+			if err != nil {
+				return false
+			}
+			c := compareValues(*av, *bv)
+			if c == 0 {
+				continue
+			}
+			if ss.orders[i].desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	}, nil
+}
+
+// compareValues compares two values of the same type, nil is less than any other value
+func compareValues(a, b reflect.Value) int {
+	if a.Kind() == reflect.Ptr {
+		switch {
+		case a.IsNil() && b.IsNil():
+			return 0
+		case a.IsNil():
+			return -1
+		case b.IsNil():
+			return 1
+		}
+		return compareValues(a.Elem(), b.Elem())
+	}
+
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareOrdered(a.Int() < b.Int(), a.Int() > b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return compareOrdered(a.Uint() < b.Uint(), a.Uint() > b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return compareOrdered(a.Float() < b.Float(), a.Float() > b.Float())
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	case reflect.Bool:
+		return compareOrdered(!a.Bool() && b.Bool(), a.Bool() && !b.Bool())
+	case reflect.Struct:
+		if a.Type().ConvertibleTo(schemas.TimeType) {
+			at := a.Convert(schemas.TimeType).Interface().(time.Time)
+			bt := b.Convert(schemas.TimeType).Interface().(time.Time)
+			return compareOrdered(at.Before(bt), at.After(bt))
+		}
+	}
+	return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+}
+
+func compareOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+func sum(values []int64) int64 {
+	var total int64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/xormplus/xorm/caches"
 	"github.com/xormplus/xorm/convert"
 	"github.com/xormplus/xorm/dialects"
+	"github.com/xormplus/xorm/log"
 	"github.com/xormplus/xorm/names"
 	"github.com/xormplus/xorm/schemas"
 )
@@ -31,6 +32,7 @@ type Parser struct {
 	tableMapper  names.Mapper
 	handlers     map[string]Handler
 	cacherMgr    *caches.Manager
+	redactor     *log.Redactor
 	tableCache   sync.Map // map[reflect.Type]*schemas.Table
 }
 
@@ -63,6 +65,11 @@ func (parser *Parser) SetColumnMapper(mapper names.Mapper) {
 	parser.columnMapper = mapper
 }
 
+// SetRedactor sets the redactor which the sensitive columns of the parsed tables are added to
+func (parser *Parser) SetRedactor(redactor *log.Redactor) {
+	parser.redactor = redactor
+}
+
 func (parser *Parser) ParseWithCache(v reflect.Value) (*schemas.Table, error) {
 	t := v.Type()
 	tableI, ok := parser.tableCache.Load(t)
@@ -77,6 +84,14 @@ func (parser *Parser) ParseWithCache(v reflect.Value) (*schemas.Table, error) {
 
 	parser.tableCache.Store(t, table)
 
+	if parser.redactor != nil {
+		for _, col := range table.Columns() {
+			if col.IsSensitive {
+				parser.redactor.AddSensitiveColumns(col.Name)
+			}
+		}
+	}
+
 	if parser.cacherMgr.GetDefaultCacher() != nil {
 		if v.CanAddr() {
 			gob.Register(v.Addr().Interface())
@@ -110,6 +125,27 @@ func addIndex(indexName string, table *schemas.Table, col *schemas.Column, index
 	}
 }
 
+func addForeignKey(fkName string, table *schemas.Table, col *schemas.Column, refTable, refCol, onDelete, onUpdate string) error {
+	if fkName == "" {
+		fkName = col.Name
+	}
+	fk, ok := table.ForeignKeys[fkName]
+	if !ok {
+		fk = schemas.NewForeignKey(fkName, refTable)
+		table.AddForeignKey(fk)
+	} else if !strings.EqualFold(fk.RefTable, refTable) {
+		return fmt.Errorf("foreign key %s of field %s references %s but %s before", fkName, col.FieldName, refTable, fk.RefTable)
+	}
+	fk.AddColumn(col.Name, refCol)
+	if onDelete != "" {
+		fk.OnDelete = onDelete
+	}
+	if onUpdate != "" {
+		fk.OnUpdate = onUpdate
+	}
+	return nil
+}
+
 // Parse parses a struct as a table information
 func (parser *Parser) Parse(v reflect.Value) (*schemas.Table, error) {
 	t := v.Type()
@@ -229,6 +265,12 @@ func (parser *Parser) Parse(v reflect.Value) (*schemas.Table, error) {
 					}
 				}
 
+				// the field of a relation holds the related rows and is not a column
+				if ctx.relation != nil {
+					table.AddRelation(ctx.relation)
+					continue
+				}
+
 				if col.SQLType.Name == "" {
 					col.SQLType = schemas.Type2SQLType(fieldType)
 				}
@@ -252,6 +294,14 @@ func (parser *Parser) Parse(v reflect.Value) (*schemas.Table, error) {
 				for indexName, indexType := range ctx.indexNames {
 					addIndex(indexName, table, col, indexType)
 				}
+
+				if ctx.fkRefTable != "" {
+					if err := addForeignKey(ctx.fkName, table, col, ctx.fkRefTable, ctx.fkRefCol, ctx.fkOnDelete, ctx.fkOnUpdate); err != nil {
+						return nil, err
+					}
+				} else if ctx.fkOnDelete != "" || ctx.fkOnUpdate != "" {
+					return nil, fmt.Errorf("field %s tag ondelete or onupdate needs a fk tag", col.FieldName)
+				}
 			}
 		} else {
 			var sqlType schemas.SQLType
@@ -7,11 +7,14 @@ package tags
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/xormplus/xorm/caches"
 	"github.com/xormplus/xorm/dialects"
+	"github.com/xormplus/xorm/log"
 	"github.com/xormplus/xorm/names"
+	"github.com/xormplus/xorm/schemas"
 )
 
 type ParseTableName1 struct{}
@@ -42,3 +45,236 @@ func TestParseTableName(t *testing.T) {
 	assert.NoError(t, err)
 	assert.EqualValues(t, "p_parseTableName", table.Name)
 }
+
+type ParseFKUser struct {
+	Id int64
+}
+
+type ParseFKOrder struct {
+	Id       int64
+	UserId   int64 `xorm:"fk(parse_f_k_user.id) ondelete(cascade) onupdate('set null')"`
+	TenantId int64 `xorm:"fk(tenant,tenant.id)"`
+	TenantNo int64 `xorm:"'tenant_no' fk(tenant,tenant.no)"`
+}
+
+func TestParseForeignKey(t *testing.T) {
+	parser := NewParser(
+		"xorm",
+		dialects.QueryDialect("mysql"),
+		names.SnakeMapper{},
+		names.SnakeMapper{},
+		caches.NewManager(),
+	)
+
+	table, err := parser.Parse(reflect.ValueOf(new(ParseFKOrder)))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(table.ForeignKeys))
+
+	fk := table.ForeignKeys["user_id"]
+	assert.NotNil(t, fk)
+	assert.EqualValues(t, "parse_f_k_user", fk.RefTable)
+	assert.EqualValues(t, []string{"user_id"}, fk.Cols)
+	assert.EqualValues(t, []string{"id"}, fk.RefCols)
+	assert.EqualValues(t, schemas.FKCascade, fk.OnDelete)
+	assert.EqualValues(t, schemas.FKSetNull, fk.OnUpdate)
+
+	fk = table.ForeignKeys["tenant"]
+	assert.NotNil(t, fk)
+	assert.EqualValues(t, "tenant", fk.RefTable)
+	assert.EqualValues(t, []string{"tenant_id", "tenant_no"}, fk.Cols)
+	assert.EqualValues(t, []string{"id", "no"}, fk.RefCols)
+	assert.EqualValues(t, "FK_parse_f_k_order_tenant", fk.XName(table.Name))
+
+	type ParseFKBadAction struct {
+		UserId int64 `xorm:"fk(user.id) ondelete(explode)"`
+	}
+	_, err = parser.Parse(reflect.ValueOf(new(ParseFKBadAction)))
+	assert.Error(t, err)
+
+	type ParseFKNoRef struct {
+		UserId int64 `xorm:"ondelete(cascade)"`
+	}
+	_, err = parser.Parse(reflect.ValueOf(new(ParseFKNoRef)))
+	assert.Error(t, err)
+}
+
+type ParseShardKey struct {
+	Id       int64
+	TenantId int64 `xorm:"shard index"`
+}
+
+func TestParseShardKey(t *testing.T) {
+	parser := NewParser(
+		"xorm",
+		dialects.QueryDialect("mysql"),
+		names.SnakeMapper{},
+		names.SnakeMapper{},
+		caches.NewManager(),
+	)
+
+	table, err := parser.Parse(reflect.ValueOf(new(ParseShardKey)))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "tenant_id", table.ShardKey)
+	assert.NotNil(t, table.ShardKeyColumn())
+	assert.True(t, table.ShardKeyColumn().IsShardKey)
+	assert.EqualValues(t, 1, len(table.Indexes))
+}
+
+type ParsePartition struct {
+	Id      int64
+	Created time.Time `xorm:"created partition(monthly,3)"`
+}
+
+type ParsePartitionNoTime struct {
+	Id      int64
+	Created int64 `xorm:"partition(monthly)"`
+}
+
+func TestParsePartition(t *testing.T) {
+	parser := NewParser(
+		"xorm",
+		dialects.QueryDialect("mysql"),
+		names.SnakeMapper{},
+		names.SnakeMapper{},
+		caches.NewManager(),
+	)
+
+	table, err := parser.Parse(reflect.ValueOf(new(ParsePartition)))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "created", table.Partition)
+	assert.NotNil(t, table.PartitionColumn())
+	assert.EqualValues(t, schemas.PartitionMonthly, table.PartitionColumn().PartitionPeriod)
+	assert.EqualValues(t, 3, table.PartitionColumn().PartitionAhead)
+
+	_, err = parser.Parse(reflect.ValueOf(new(ParsePartitionNoTime)))
+	assert.Error(t, err)
+}
+
+type ParseRelationUser struct {
+	Id     int64
+	Orders []*ParseRelationOrder `xorm:"has_many"`
+	Roles  []ParseRelationRole   `xorm:"many2many(user_role)"`
+}
+
+type ParseRelationOrder struct {
+	Id      int64
+	BuyerId int64
+	Buyer   *ParseRelationUser `xorm:"belongs_to(buyer_id)"`
+}
+
+type ParseRelationRole struct {
+	Id int64
+}
+
+type ParseRelationNoSlice struct {
+	Id    int64
+	Order ParseRelationOrder `xorm:"has_many"`
+}
+
+func TestParseRelations(t *testing.T) {
+	parser := NewParser(
+		"xorm",
+		dialects.QueryDialect("mysql"),
+		names.SnakeMapper{},
+		names.SnakeMapper{},
+		caches.NewManager(),
+	)
+
+	table, err := parser.Parse(reflect.ValueOf(new(ParseRelationUser)))
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"id"}, table.ColumnsSeq())
+	assert.EqualValues(t, 2, len(table.Relations))
+
+	orders := table.GetRelation("orders")
+	assert.NotNil(t, orders)
+	assert.EqualValues(t, schemas.HasMany, orders.Type)
+	assert.EqualValues(t, reflect.TypeOf(ParseRelationOrder{}), orders.ElemType)
+	assert.EqualValues(t, "parse_relation_user_id", orders.ForeignKey)
+
+	roles := table.GetRelation("Roles")
+	assert.NotNil(t, roles)
+	assert.EqualValues(t, schemas.ManyToMany, roles.Type)
+	assert.EqualValues(t, "user_role", roles.JoinTable)
+	assert.EqualValues(t, "parse_relation_user_id", roles.JoinForeignKey)
+	assert.EqualValues(t, "parse_relation_role_id", roles.JoinReferences)
+
+	table, err = parser.Parse(reflect.ValueOf(new(ParseRelationOrder)))
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"id", "buyer_id"}, table.ColumnsSeq())
+	buyer := table.GetRelation("Buyer")
+	assert.NotNil(t, buyer)
+	assert.EqualValues(t, schemas.BelongsTo, buyer.Type)
+	assert.EqualValues(t, "buyer_id", buyer.ForeignKey)
+
+	_, err = parser.Parse(reflect.ValueOf(new(ParseRelationNoSlice)))
+	assert.Error(t, err)
+}
+
+type ParseSensitive struct {
+	Id       int64
+	Name     string
+	Password string `xorm:"sensitive"`
+	ApiToken string `xorm:"'token' sensitive"`
+}
+
+func TestParseSensitive(t *testing.T) {
+	parser := NewParser(
+		"xorm",
+		dialects.QueryDialect("mysql"),
+		names.SnakeMapper{},
+		names.SnakeMapper{},
+		caches.NewManager(),
+	)
+	redactor := log.NewRedactor()
+	parser.SetRedactor(redactor)
+
+	table, err := parser.ParseWithCache(reflect.ValueOf(new(ParseSensitive)))
+	assert.NoError(t, err)
+	assert.False(t, table.GetColumn("name").IsSensitive)
+	assert.True(t, table.GetColumn("password").IsSensitive)
+	assert.True(t, table.GetColumn("token").IsSensitive)
+
+	assert.False(t, redactor.IsSensitive("name"))
+	assert.True(t, redactor.IsSensitive("password"))
+	assert.True(t, redactor.IsSensitive("token"))
+}
+
+type ParseEncrypt struct {
+	Id         int64
+	Email      string  `xorm:"encrypt(pii)"`
+	EmailIndex *string `xorm:"blindindex(email)"`
+}
+
+type ParseEncryptNoKey struct {
+	Id    int64
+	Email string `xorm:"encrypt"`
+}
+
+type ParseBlindIndexNotString struct {
+	Id         int64
+	Email      string `xorm:"encrypt(pii)"`
+	EmailIndex int64  `xorm:"blindindex(email)"`
+}
+
+func TestParseEncrypt(t *testing.T) {
+	parser := NewParser(
+		"xorm",
+		dialects.QueryDialect("mysql"),
+		names.SnakeMapper{},
+		names.SnakeMapper{},
+		caches.NewManager(),
+	)
+
+	table, err := parser.Parse(reflect.ValueOf(new(ParseEncrypt)))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "pii", table.GetColumn("email").EncryptKey)
+	assert.EqualValues(t, "", table.GetColumn("email").BlindIndexOf)
+	assert.EqualValues(t, "", table.GetColumn("email_index").EncryptKey)
+	assert.EqualValues(t, "email", table.GetColumn("email_index").BlindIndexOf)
+
+	_, err = parser.Parse(reflect.ValueOf(new(ParseEncryptNoKey)))
+	assert.Error(t, err)
+
+	_, err = parser.Parse(reflect.ValueOf(new(ParseBlindIndexNotString)))
+	assert.Error(t, err)
+}
@@ -45,10 +45,16 @@ type Context struct {
 	isIndex         bool
 	isUnique        bool
 	indexNames      map[string]int
+	fkName          string
+	fkRefTable      string
+	fkRefCol        string
+	fkOnDelete      string
+	fkOnUpdate      string
 	parser          *Parser
 	hasCacheTag     bool
 	hasNoCacheTag   bool
 	ignoreNext      bool
+	relation        *schemas.Relation
 }
 
 // Handler describes tag handler for XORM
@@ -57,25 +63,36 @@ type Handler func(ctx *Context) error
 var (
 	// defaultTagHandlers enumerates all the default tag handler
 	defaultTagHandlers = map[string]Handler{
-		"<-":       OnlyFromDBTagHandler,
-		"->":       OnlyToDBTagHandler,
-		"PK":       PKTagHandler,
-		"NULL":     NULLTagHandler,
-		"NOT":      IgnoreTagHandler,
-		"AUTOINCR": AutoIncrTagHandler,
-		"DEFAULT":  DefaultTagHandler,
-		"CREATED":  CreatedTagHandler,
-		"UPDATED":  UpdatedTagHandler,
-		"DELETED":  DeletedTagHandler,
-		"VERSION":  VersionTagHandler,
-		"UTC":      UTCTagHandler,
-		"LOCAL":    LocalTagHandler,
-		"NOTNULL":  NotNullTagHandler,
-		"INDEX":    IndexTagHandler,
-		"UNIQUE":   UniqueTagHandler,
-		"CACHE":    CacheTagHandler,
-		"NOCACHE":  NoCacheTagHandler,
-		"COMMENT":  CommentTagHandler,
+		"<-":         OnlyFromDBTagHandler,
+		"->":         OnlyToDBTagHandler,
+		"PK":         PKTagHandler,
+		"NULL":       NULLTagHandler,
+		"NOT":        IgnoreTagHandler,
+		"AUTOINCR":   AutoIncrTagHandler,
+		"DEFAULT":    DefaultTagHandler,
+		"CREATED":    CreatedTagHandler,
+		"UPDATED":    UpdatedTagHandler,
+		"DELETED":    DeletedTagHandler,
+		"VERSION":    VersionTagHandler,
+		"UTC":        UTCTagHandler,
+		"LOCAL":      LocalTagHandler,
+		"NOTNULL":    NotNullTagHandler,
+		"INDEX":      IndexTagHandler,
+		"UNIQUE":     UniqueTagHandler,
+		"CACHE":      CacheTagHandler,
+		"NOCACHE":    NoCacheTagHandler,
+		"COMMENT":    CommentTagHandler,
+		"SENSITIVE":  SensitiveTagHandler,
+		"ENCRYPT":    EncryptTagHandler,
+		"BLINDINDEX": BlindIndexTagHandler,
+		"FK":         FKTagHandler,
+		"ONDELETE":   OnDeleteTagHandler,
+		"ONUPDATE":   OnUpdateTagHandler,
+		"SHARD":      ShardTagHandler,
+		"PARTITION":  PartitionTagHandler,
+		"BELONGS_TO": BelongsToTagHandler,
+		"HAS_MANY":   HasManyTagHandler,
+		"MANY2MANY":  Many2ManyTagHandler,
 	}
 )
 
@@ -163,6 +180,140 @@ func VersionTagHandler(ctx *Context) error {
 	return nil
 }
 
+// ShardTagHandler describes shard tag handler, the column is the shard key of ShardedEngine
+func ShardTagHandler(ctx *Context) error {
+	ctx.col.IsShardKey = true
+	return nil
+}
+
+// PartitionTagHandler describes partition tag handler, partition(monthly) or
+// partition(monthly,3) partitions the table by the time of the column, the second
+// parameter is the number of the partitions after the current one created by Sync2
+func PartitionTagHandler(ctx *Context) error {
+	if len(ctx.params) == 0 || len(ctx.params) > 2 {
+		return fmt.Errorf("field %s tag partition should be partition(period) or partition(period,ahead)", ctx.col.FieldName)
+	}
+	fieldType := ctx.fieldValue.Type()
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if !fieldType.ConvertibleTo(schemas.TimeType) {
+		return fmt.Errorf("field %s tag partition needs a time field", ctx.col.FieldName)
+	}
+
+	period, err := schemas.ParsePartitionPeriod(ctx.params[0])
+	if err != nil {
+		return err
+	}
+	ctx.col.PartitionPeriod = period
+	ctx.col.PartitionAhead = 1
+	if len(ctx.params) == 2 {
+		ahead, err := strconv.Atoi(strings.TrimSpace(ctx.params[1]))
+		if err != nil || ahead < 0 {
+			return fmt.Errorf("field %s tag partition ahead %s is invalid", ctx.col.FieldName, ctx.params[1])
+		}
+		ctx.col.PartitionAhead = ahead
+	}
+	return nil
+}
+
+// relationElemType returns the struct type of the related rows held by the field, the
+// field of a belongs_to relation is a struct or a pointer to it, and the other ones are a
+// slice of them
+func relationElemType(ctx *Context, isSlice bool) (reflect.Type, error) {
+	tp := ctx.fieldValue.Type()
+	if isSlice {
+		if tp.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("field %s tag %s needs a slice field", ctx.col.FieldName, strings.ToLower(ctx.tagName))
+		}
+		tp = tp.Elem()
+	}
+	if tp.Kind() == reflect.Ptr {
+		tp = tp.Elem()
+	}
+	if tp.Kind() != reflect.Struct || tp.ConvertibleTo(schemas.TimeType) {
+		return nil, fmt.Errorf("field %s tag %s needs a struct field", ctx.col.FieldName, strings.ToLower(ctx.tagName))
+	}
+	return tp, nil
+}
+
+// relationParam returns the i-th parameter of the relation tag or def if it's absent
+func relationParam(ctx *Context, i int, def string) string {
+	if i < len(ctx.params) {
+		if param := strings.Trim(strings.TrimSpace(ctx.params[i]), "'\"`"); param != "" {
+			return param
+		}
+	}
+	return def
+}
+
+// BelongsToTagHandler describes belongs_to tag handler, belongs_to or belongs_to(user_id)
+// loads the row referenced by the column of the table into the field by Preload, the
+// column is the field name followed by _id by default
+func BelongsToTagHandler(ctx *Context) error {
+	if len(ctx.params) > 1 {
+		return fmt.Errorf("field %s tag belongs_to should be belongs_to or belongs_to(column)", ctx.col.FieldName)
+	}
+	elemType, err := relationElemType(ctx, false)
+	if err != nil {
+		return err
+	}
+	ctx.relation = &schemas.Relation{
+		Type:       schemas.BelongsTo,
+		FieldName:  ctx.col.FieldName,
+		ElemType:   elemType,
+		ForeignKey: relationParam(ctx, 0, ctx.parser.columnMapper.Obj2Table(ctx.col.FieldName)+"_id"),
+	}
+	return nil
+}
+
+// HasManyTagHandler describes has_many tag handler, has_many or has_many(user_id) loads
+// the rows whose column references the table into the field by Preload, the column is
+// the struct name followed by _id by default
+func HasManyTagHandler(ctx *Context) error {
+	if len(ctx.params) > 1 {
+		return fmt.Errorf("field %s tag has_many should be has_many or has_many(column)", ctx.col.FieldName)
+	}
+	elemType, err := relationElemType(ctx, true)
+	if err != nil {
+		return err
+	}
+	ctx.relation = &schemas.Relation{
+		Type:       schemas.HasMany,
+		FieldName:  ctx.col.FieldName,
+		ElemType:   elemType,
+		ForeignKey: relationParam(ctx, 0, ctx.parser.columnMapper.Obj2Table(ctx.table.Type.Name())+"_id"),
+	}
+	return nil
+}
+
+// Many2ManyTagHandler describes many2many tag handler, many2many(user_role) or
+// many2many(user_role,user_id,role_id) loads the rows referenced by the join table into
+// the field by Preload, the columns of the join table are the struct names followed by
+// _id by default
+func Many2ManyTagHandler(ctx *Context) error {
+	if len(ctx.params) != 1 && len(ctx.params) != 3 {
+		return fmt.Errorf("field %s tag many2many should be many2many(join_table) or many2many(join_table,column,ref_column)", ctx.col.FieldName)
+	}
+	elemType, err := relationElemType(ctx, true)
+	if err != nil {
+		return err
+	}
+	joinTable := relationParam(ctx, 0, "")
+	if joinTable == "" {
+		return fmt.Errorf("field %s tag many2many needs a join table", ctx.col.FieldName)
+	}
+	ctx.relation = &schemas.Relation{
+		Type:           schemas.ManyToMany,
+		FieldName:      ctx.col.FieldName,
+		ElemType:       elemType,
+		JoinTable:      joinTable,
+		JoinForeignKey: relationParam(ctx, 1, ctx.parser.columnMapper.Obj2Table(ctx.table.Type.Name())+"_id"),
+		JoinReferences: relationParam(ctx, 2, ctx.parser.columnMapper.Obj2Table(elemType.Name())+"_id"),
+	}
+	return nil
+}
+
 // UTCTagHandler describes utc tag handler
 func UTCTagHandler(ctx *Context) error {
 	ctx.col.TimeZone = time.UTC
@@ -215,6 +366,55 @@ func UniqueTagHandler(ctx *Context) error {
 	return nil
 }
 
+// FKTagHandler describes foreign key tag handler, the referenced column could be
+// declared as fk(table.column) or as fk(name,table.column) for composite keys
+func FKTagHandler(ctx *Context) error {
+	var ref string
+	switch len(ctx.params) {
+	case 1:
+		ref = ctx.params[0]
+	case 2:
+		ctx.fkName = strings.Trim(strings.TrimSpace(ctx.params[0]), "'\"`")
+		ref = ctx.params[1]
+	default:
+		return fmt.Errorf("field %s tag fk should be fk(table.column) or fk(name,table.column)", ctx.col.FieldName)
+	}
+
+	ref = strings.Trim(strings.TrimSpace(ref), "'\"`")
+	idx := strings.LastIndex(ref, ".")
+	if idx <= 0 || idx == len(ref)-1 {
+		return fmt.Errorf("field %s tag fk references %s but should be table.column", ctx.col.FieldName, ref)
+	}
+	ctx.fkRefTable, ctx.fkRefCol = ref[:idx], ref[idx+1:]
+	return nil
+}
+
+// OnDeleteTagHandler describes the foreign key on delete action tag handler
+func OnDeleteTagHandler(ctx *Context) error {
+	if len(ctx.params) == 0 {
+		return fmt.Errorf("field %s tag ondelete needs an action", ctx.col.FieldName)
+	}
+	action, err := schemas.ParseFKAction(ctx.params[0])
+	if err != nil {
+		return err
+	}
+	ctx.fkOnDelete = action
+	return nil
+}
+
+// OnUpdateTagHandler describes the foreign key on update action tag handler
+func OnUpdateTagHandler(ctx *Context) error {
+	if len(ctx.params) == 0 {
+		return fmt.Errorf("field %s tag onupdate needs an action", ctx.col.FieldName)
+	}
+	action, err := schemas.ParseFKAction(ctx.params[0])
+	if err != nil {
+		return err
+	}
+	ctx.fkOnUpdate = action
+	return nil
+}
+
 // CommentTagHandler add comment to column
 func CommentTagHandler(ctx *Context) error {
 	if len(ctx.params) > 0 {
@@ -223,6 +423,39 @@ func CommentTagHandler(ctx *Context) error {
 	return nil
 }
 
+// SensitiveTagHandler marks the column as sensitive, its values are masked in the logs
+func SensitiveTagHandler(ctx *Context) error {
+	ctx.col.IsSensitive = true
+	return nil
+}
+
+// EncryptTagHandler describes encrypt tag handler, encrypt(keyname) encrypts the values of
+// the column by the cipher of the key registered to the engine
+func EncryptTagHandler(ctx *Context) error {
+	if len(ctx.params) != 1 || strings.Trim(ctx.params[0], "' ") == "" {
+		return fmt.Errorf("field %s tag encrypt should be encrypt(keyname)", ctx.col.FieldName)
+	}
+	ctx.col.EncryptKey = strings.Trim(ctx.params[0], "' ")
+	return nil
+}
+
+// BlindIndexTagHandler describes blindindex tag handler, blindindex(column) stores the blind
+// index of the encrypted column for the equality lookups, the field should be a string
+func BlindIndexTagHandler(ctx *Context) error {
+	if len(ctx.params) != 1 || strings.Trim(ctx.params[0], "' ") == "" {
+		return fmt.Errorf("field %s tag blindindex should be blindindex(column)", ctx.col.FieldName)
+	}
+	fieldType := ctx.fieldValue.Type()
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.Kind() != reflect.String {
+		return fmt.Errorf("field %s tag blindindex should be on a string field", ctx.col.FieldName)
+	}
+	ctx.col.BlindIndexOf = strings.Trim(ctx.params[0], "' ")
+	return nil
+}
+
 // SQLTypeTagHandler describes SQL Type tag handler
 func SQLTypeTagHandler(ctx *Context) error {
 	ctx.col.SQLType = schemas.SQLType{Name: ctx.tagName}
@@ -284,7 +517,9 @@ func ExtendsTagHandler(ctx *Context) error {
 		if err != nil {
 			return err
 		}
+		var colNames = make(map[string]string, len(parentTable.Columns()))
 		for _, col := range parentTable.Columns() {
+			var oriName = col.Name
 			col.FieldName = fmt.Sprintf("%v.%v", ctx.col.FieldName, col.FieldName)
 
 			var tagPrefix = ctx.col.FieldName
@@ -305,10 +540,17 @@ func ExtendsTagHandler(ctx *Context) error {
 			}
 
 			ctx.table.AddColumn(col)
+			colNames[oriName] = col.Name
 			for indexName, indexType := range col.Indexes {
 				addIndex(indexName, ctx.table, col, indexType)
 			}
 		}
+		for _, fk := range parentTable.ForeignKeys {
+			for i, colName := range fk.Cols {
+				fk.Cols[i] = colNames[colName]
+			}
+			ctx.table.AddForeignKey(fk)
+		}
 	default:
 		//TODO: warning
 	}
@@ -0,0 +1,188 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemorySpan is a span recorded by a MemoryTracer
+type MemorySpan struct {
+	Name       string
+	Attributes map[string]interface{}
+	Err        error
+	Start      time.Time
+	End        time.Time
+
+	tracer *MemoryTracer
+}
+
+// MemoryTracer records the ended spans in memory
+type MemoryTracer struct {
+	mutex sync.Mutex
+	spans []*MemorySpan
+}
+
+var _ Tracer = &MemoryTracer{}
+
+// NewMemoryTracer creates a MemoryTracer
+func NewMemoryTracer() *MemoryTracer {
+	return &MemoryTracer{}
+}
+
+// Start implements Tracer
+func (t *MemoryTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, &memorySpan{&MemorySpan{
+		Name:       name,
+		Attributes: make(map[string]interface{}),
+		Start:      time.Now(),
+		tracer:     t,
+	}}
+}
+
+// Spans returns the ended spans
+func (t *MemoryTracer) Spans() []*MemorySpan {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]*MemorySpan(nil), t.spans...)
+}
+
+// Reset removes the recorded spans
+func (t *MemoryTracer) Reset() {
+	t.mutex.Lock()
+	t.spans = nil
+	t.mutex.Unlock()
+}
+
+type memorySpan struct {
+	*MemorySpan
+}
+
+func (s *memorySpan) SetAttributes(attrs ...Attribute) {
+	for _, attr := range attrs {
+		s.Attributes[attr.Key] = attr.Value
+	}
+}
+
+func (s *memorySpan) RecordError(err error) {
+	s.Err = err
+}
+
+func (s *memorySpan) End() {
+	s.MemorySpan.End = time.Now()
+	s.tracer.mutex.Lock()
+	s.tracer.spans = append(s.tracer.spans, s.MemorySpan)
+	s.tracer.mutex.Unlock()
+}
+
+// DefaultBuckets are the upper bounds of the buckets of the histograms of a MemoryMeter
+// in seconds
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HistogramData is the data of a histogram with some attributes, the last one of
+// BucketCounts is the count of the values greater than all the bounds
+type HistogramData struct {
+	Count        int64
+	Sum          float64
+	Bounds       []float64
+	BucketCounts []int64
+}
+
+// MemoryMeter records the metrics in memory by the names and the attributes
+type MemoryMeter struct {
+	mutex      sync.Mutex
+	counters   map[string]int64
+	histograms map[string]*HistogramData
+}
+
+var _ Meter = &MemoryMeter{}
+
+// NewMemoryMeter creates a MemoryMeter
+func NewMemoryMeter() *MemoryMeter {
+	return &MemoryMeter{
+		counters:   make(map[string]int64),
+		histograms: make(map[string]*HistogramData),
+	}
+}
+
+// metricKey returns the key of a metric with the attributes, the order of the attributes
+// doesn't matter
+func metricKey(name string, attrs []Attribute) string {
+	var parts = make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		parts = append(parts, fmt.Sprintf("%s=%v", attr.Key, attr.Value))
+	}
+	sort.Strings(parts)
+	return name + "{" + strings.Join(parts, ",") + "}"
+}
+
+// Histogram implements Meter
+func (m *MemoryMeter) Histogram(name, unit, description string) Histogram {
+	return &memoryHistogram{m, name}
+}
+
+// Counter implements Meter
+func (m *MemoryMeter) Counter(name, unit, description string) Counter {
+	return &memoryCounter{m, name}
+}
+
+// CounterValue returns the value of a counter with the attributes
+func (m *MemoryMeter) CounterValue(name string, attrs ...Attribute) int64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.counters[metricKey(name, attrs)]
+}
+
+// HistogramValue returns the data of a histogram with the attributes
+func (m *MemoryMeter) HistogramValue(name string, attrs ...Attribute) HistogramData {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	data, ok := m.histograms[metricKey(name, attrs)]
+	if !ok {
+		return HistogramData{Bounds: DefaultBuckets, BucketCounts: make([]int64, len(DefaultBuckets)+1)}
+	}
+	return HistogramData{
+		Count:        data.Count,
+		Sum:          data.Sum,
+		Bounds:       data.Bounds,
+		BucketCounts: append([]int64(nil), data.BucketCounts...),
+	}
+}
+
+type memoryCounter struct {
+	meter *MemoryMeter
+	name  string
+}
+
+func (c *memoryCounter) Add(ctx context.Context, value int64, attrs ...Attribute) {
+	key := metricKey(c.name, attrs)
+	c.meter.mutex.Lock()
+	c.meter.counters[key] += value
+	c.meter.mutex.Unlock()
+}
+
+type memoryHistogram struct {
+	meter *MemoryMeter
+	name  string
+}
+
+func (h *memoryHistogram) Record(ctx context.Context, value float64, attrs ...Attribute) {
+	key := metricKey(h.name, attrs)
+	h.meter.mutex.Lock()
+	defer h.meter.mutex.Unlock()
+	data, ok := h.meter.histograms[key]
+	if !ok {
+		data = &HistogramData{Bounds: DefaultBuckets, BucketCounts: make([]int64, len(DefaultBuckets)+1)}
+		h.meter.histograms[key] = data
+	}
+	data.Count++
+	data.Sum += value
+	data.BucketCounts[sort.SearchFloat64s(data.Bounds, value)]++
+}
@@ -0,0 +1,168 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package telemetry provides a hook which traces the SQLs by spans and records their
+// latencies, calls and errors as metrics. The tracer and the meter follow the ones of
+// OpenTelemetry, so they could be adapted by a few lines, i.e.
+//
+//	engine.AddHook(telemetry.NewHook("mysql", otelTracer{tracer}, otelMeter{meter}))
+//
+// The in-memory tracer and meter of the package could be used without any dependency.
+package telemetry
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/xormplus/xorm/contexts"
+)
+
+// Attribute represents a key value pair of a span or a metric
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// enumerate all the attribute keys, the ones of db follow the semantic conventions of
+// OpenTelemetry
+const (
+	KeyDBSystem     = "db.system"
+	KeyDBStatement  = "db.statement"
+	KeyDBOperation  = "db.operation"
+	KeyDBTable      = "db.sql.table"
+	KeyRowsAffected = "db.rows_affected"
+	KeySessionID    = "xorm.session.id"
+	KeyTxID         = "xorm.tx.id"
+	KeyInTx         = "xorm.tx"
+)
+
+// enumerate all the metric names
+const (
+	MetricDuration = "db.client.duration"
+	MetricCalls    = "db.client.calls"
+	MetricErrors   = "db.client.errors"
+)
+
+// Span represents a span started by a Tracer
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts the spans
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Histogram records the distribution of the values
+type Histogram interface {
+	Record(ctx context.Context, value float64, attrs ...Attribute)
+}
+
+// Counter adds up the values
+type Counter interface {
+	Add(ctx context.Context, value int64, attrs ...Attribute)
+}
+
+// Meter creates the instruments of the metrics
+type Meter interface {
+	Histogram(name, unit, description string) Histogram
+	Counter(name, unit, description string) Counter
+}
+
+// Hook implements contexts.Hook, it starts a span for every SQL with the operation, the
+// table, the statement, the session and the transaction, and records the duration in
+// seconds, the calls and the errors by the operation and the table
+type Hook struct {
+	dbSystem string
+	tracer   Tracer
+	duration Histogram
+	calls    Counter
+	errors   Counter
+}
+
+var _ contexts.Hook = &Hook{}
+
+// NewHook creates a Hook, dbSystem is the name of the database, i.e. mysql. tracer or meter
+// could be nil if there is no span or no metric.
+func NewHook(dbSystem string, tracer Tracer, meter Meter) *Hook {
+	hook := &Hook{
+		dbSystem: dbSystem,
+		tracer:   tracer,
+	}
+	if meter != nil {
+		hook.duration = meter.Histogram(MetricDuration, "s", "The duration of the SQLs")
+		hook.calls = meter.Counter(MetricCalls, "{call}", "The number of the SQLs")
+		hook.errors = meter.Counter(MetricErrors, "{error}", "The number of the failed SQLs")
+	}
+	return hook
+}
+
+type spanKey struct{}
+
+// spanName returns the name of the span of a SQL, which is the operation and the table
+func spanName(c *contexts.ContextHook) string {
+	name := string(c.Operation)
+	if name == "" {
+		name = "sql"
+	}
+	if c.TableName != "" {
+		name += " " + c.TableName
+	}
+	return name
+}
+
+// BeforeProcess implements contexts.Hook
+func (h *Hook) BeforeProcess(c *contexts.ContextHook) (context.Context, error) {
+	if h.tracer == nil {
+		return c.Ctx, nil
+	}
+	ctx, span := h.tracer.Start(c.Ctx, spanName(c))
+	attrs := []Attribute{
+		{KeyDBSystem, h.dbSystem},
+		{KeyDBStatement, c.SQL},
+		{KeyDBOperation, string(c.Operation)},
+		{KeySessionID, c.SessionID},
+		{KeyInTx, c.InTx()},
+	}
+	if c.TableName != "" {
+		attrs = append(attrs, Attribute{KeyDBTable, c.TableName})
+	}
+	if c.InTx() {
+		attrs = append(attrs, Attribute{KeyTxID, c.TxID})
+	}
+	span.SetAttributes(attrs...)
+	return context.WithValue(ctx, spanKey{}, span), nil
+}
+
+// AfterProcess implements contexts.Hook
+func (h *Hook) AfterProcess(c *contexts.ContextHook) error {
+	var failed = c.Err != nil && c.Err != sql.ErrNoRows
+	if span, ok := c.Ctx.Value(spanKey{}).(Span); ok {
+		if c.RowsAffected >= 0 {
+			span.SetAttributes(Attribute{KeyRowsAffected, c.RowsAffected})
+		}
+		if failed {
+			span.RecordError(c.Err)
+		}
+		span.End()
+	}
+
+	if h.duration == nil {
+		return nil
+	}
+	attrs := []Attribute{
+		{KeyDBSystem, h.dbSystem},
+		{KeyDBOperation, string(c.Operation)},
+		{KeyDBTable, c.TableName},
+		{KeyInTx, c.InTx()},
+	}
+	h.duration.Record(c.Ctx, c.ExecuteTime.Seconds(), attrs...)
+	h.calls.Add(c.Ctx, 1, attrs...)
+	if failed {
+		h.errors.Add(c.Ctx, 1, attrs...)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package telemetry
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/xormplus/xorm/contexts"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testResult int64
+
+func (r testResult) LastInsertId() (int64, error) { return 0, nil }
+func (r testResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func process(t *testing.T, hooks *contexts.Hooks, info *contexts.OperationInfo, result sql.Result, err error) {
+	c := contexts.NewContextHook(contexts.WithOperation(context.Background(), info), "UPDATE user SET name = ?", []interface{}{"a"})
+	ctx, beforeErr := hooks.BeforeProcess(c)
+	assert.NoError(t, beforeErr)
+	c.End(ctx, result, err)
+	assert.EqualValues(t, err, hooks.AfterProcess(c))
+}
+
+func TestHook(t *testing.T) {
+	var (
+		tracer = NewMemoryTracer()
+		meter  = NewMemoryMeter()
+		hooks  contexts.Hooks
+	)
+	hooks.AddHook(NewHook("sqlite3", tracer, meter))
+
+	process(t, &hooks, &contexts.OperationInfo{
+		Operation: contexts.OperationUpdate,
+		TableName: "user",
+		SessionID: "s1",
+		TxID:      "t1",
+	}, testResult(2), nil)
+	failure := errors.New("failure")
+	process(t, &hooks, &contexts.OperationInfo{
+		Operation: contexts.OperationUpdate,
+		TableName: "user",
+		SessionID: "s2",
+	}, nil, failure)
+
+	spans := tracer.Spans()
+	if assert.EqualValues(t, 2, len(spans)) {
+		assert.EqualValues(t, "update user", spans[0].Name)
+		assert.EqualValues(t, map[string]interface{}{
+			KeyDBSystem:     "sqlite3",
+			KeyDBStatement:  "UPDATE user SET name = ?",
+			KeyDBOperation:  "update",
+			KeyDBTable:      "user",
+			KeySessionID:    "s1",
+			KeyTxID:         "t1",
+			KeyInTx:         true,
+			KeyRowsAffected: int64(2),
+		}, spans[0].Attributes)
+		assert.Nil(t, spans[0].Err)
+		assert.False(t, spans[0].End.Before(spans[0].Start))
+
+		assert.EqualValues(t, failure, spans[1].Err)
+		assert.EqualValues(t, false, spans[1].Attributes[KeyInTx])
+		assert.NotContains(t, spans[1].Attributes, KeyRowsAffected)
+	}
+
+	attrs := func(inTx bool) []Attribute {
+		return []Attribute{
+			{KeyInTx, inTx},
+			{KeyDBTable, "user"},
+			{KeyDBOperation, "update"},
+			{KeyDBSystem, "sqlite3"},
+		}
+	}
+	assert.EqualValues(t, 1, meter.CounterValue(MetricCalls, attrs(true)...))
+	assert.EqualValues(t, 0, meter.CounterValue(MetricErrors, attrs(true)...))
+	assert.EqualValues(t, 1, meter.CounterValue(MetricErrors, attrs(false)...))
+	data := meter.HistogramValue(MetricDuration, attrs(false)...)
+	assert.EqualValues(t, 1, data.Count)
+	assert.EqualValues(t, 1, data.BucketCounts[0])
+}
+
+func TestMemoryHistogram(t *testing.T) {
+	meter := NewMemoryMeter()
+	histogram := meter.Histogram("h", "s", "")
+	for _, v := range []float64{0.001, 0.002, 0.5, 20} {
+		histogram.Record(context.Background(), v)
+	}
+	data := meter.HistogramValue("h")
+	assert.EqualValues(t, 4, data.Count)
+	assert.InDelta(t, 20.503, data.Sum, 1e-9)
+	assert.EqualValues(t, 1, data.BucketCounts[0])
+	assert.EqualValues(t, 1, data.BucketCounts[1])
+	assert.EqualValues(t, 1, data.BucketCounts[7])
+	assert.EqualValues(t, 1, data.BucketCounts[len(DefaultBuckets)])
+
+	assert.EqualValues(t, 0, meter.HistogramValue("none").Count)
+}
@@ -0,0 +1,98 @@
+// Copyright 2020 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"strings"
+
+	"github.com/xormplus/xorm/schemas"
+)
+
+// WindowExpr represents a window function expression which is selected by Select, i.e.
+//
+//	rn := xorm.Window("ROW_NUMBER()").PartitionBy("user_id").OrderBy("amount DESC").As("rn")
+//	sub := engine.Table("order").Select("*, " + rn.String())
+//	err := engine.From(sub, "o").Where("rn = 1").Find(&orders)
+//
+// The columns are quoted by the quote of Select, so they are rendered for every dialect.
+type WindowExpr struct {
+	function    string
+	partitionBy []string
+	orderBy     string
+	frame       string
+	alias       string
+}
+
+// Window returns a window expression of the function, i.e. ROW_NUMBER(), RANK() or SUM(amount)
+func Window(function string) *WindowExpr {
+	return &WindowExpr{function: function}
+}
+
+// RowNumber returns the window expression of ROW_NUMBER()
+func RowNumber() *WindowExpr {
+	return Window("ROW_NUMBER()")
+}
+
+// Rank returns the window expression of RANK()
+func Rank() *WindowExpr {
+	return Window("RANK()")
+}
+
+// DenseRank returns the window expression of DENSE_RANK()
+func DenseRank() *WindowExpr {
+	return Window("DENSE_RANK()")
+}
+
+// PartitionBy adds the columns of PARTITION BY
+func (w *WindowExpr) PartitionBy(columns ...string) *WindowExpr {
+	w.partitionBy = append(w.partitionBy, columns...)
+	return w
+}
+
+// OrderBy sets the ORDER BY of the window, i.e. "amount DESC, id"
+func (w *WindowExpr) OrderBy(order string) *WindowExpr {
+	w.orderBy = order
+	return w
+}
+
+// Frame sets the frame of the window, i.e. "ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW"
+func (w *WindowExpr) Frame(frame string) *WindowExpr {
+	w.frame = frame
+	return w
+}
+
+// As sets the alias of the selected expression
+func (w *WindowExpr) As(alias string) *WindowExpr {
+	w.alias = alias
+	return w
+}
+
+// String returns the expression, the columns and the alias are quoted by `
+func (w *WindowExpr) String() string {
+	var buf strings.Builder
+	buf.WriteString(w.function)
+	buf.WriteString(" OVER (")
+	var clauses []string
+	if len(w.partitionBy) > 0 {
+		var cols = make([]string, 0, len(w.partitionBy))
+		for _, col := range w.partitionBy {
+			cols = append(cols, schemas.CommonQuoter.Quote(strings.TrimSpace(col)))
+		}
+		clauses = append(clauses, "PARTITION BY "+strings.Join(cols, ", "))
+	}
+	if w.orderBy != "" {
+		clauses = append(clauses, "ORDER BY "+w.orderBy)
+	}
+	if w.frame != "" {
+		clauses = append(clauses, w.frame)
+	}
+	buf.WriteString(strings.Join(clauses, " "))
+	buf.WriteString(")")
+	if w.alias != "" {
+		buf.WriteString(" AS ")
+		buf.WriteString(schemas.CommonQuoter.Quote(w.alias))
+	}
+	return buf.String()
+}
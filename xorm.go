@@ -50,6 +50,9 @@ func newEngine(driverName, dataSourceName string, dialect dialects.Dialect, db *
 	cacherMgr := caches.NewManager()
 	mapper := names.NewCacheMapper(new(names.SnakeMapper))
 	tagParser := tags.NewParser("xorm", dialect, mapper, mapper, cacherMgr)
+	redactor := log.NewRedactor()
+	tagParser.SetRedactor(redactor)
+	db.Redactor = redactor
 
 	engine := &Engine{
 		dialect:        dialect,
@@ -61,6 +64,7 @@ func newEngine(driverName, dataSourceName string, dialect dialects.Dialect, db *
 		dataSourceName: dataSourceName,
 		db:             db,
 		logSessionID:   false,
+		ciphers:        newCipherKeyring(),
 	}
 
 	if dialect.URI().DBType == schemas.SQLITE {
@@ -73,6 +77,12 @@ func newEngine(driverName, dataSourceName string, dialect dialects.Dialect, db *
 	logger.SetLevel(log.LOG_INFO)
 	engine.SetLogger(log.NewLoggerAdapter(logger))
 
+	if detector, ok := dialect.(dialects.VersionDetector); ok {
+		if err := detector.DetectVersion(db, engine.defaultContext); err != nil {
+			engine.logger.Warnf("detect the version of %s failed: %v", dialect.URI().DBType, err)
+		}
+	}
+
 	runtime.SetFinalizer(engine, func(engine *Engine) {
 		_ = engine.Close()
 	})